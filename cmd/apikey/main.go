@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/config"
+	"github.com/WeiAnAn/url-shortener/internal/database"
+	apikey "github.com/WeiAnAn/url-shortener/internal/domain/api_key"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Creates a new API key and prints it. The key can not be retrieved again.
+func main() {
+	name := flag.String("name", "", "name of the API key owner")
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "path of the YAML or TOML config file, environment variables override it")
+	flag.Parse()
+	if *name == "" {
+		log.Fatal("-name is required")
+	}
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var store apikey.Store
+	switch persistentStore := cfg.Store.PersistentStore; persistentStore {
+	case "mongo":
+		c, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.Store.MongoDBURI))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer c.Disconnect(context.Background())
+		store = apikey.NewMongoStore(c, "short_urls")
+	case "sqlite", "postgres":
+		dialect := database.Dialect(persistentStore)
+		dsn := cfg.Store.SQLitePath
+		if dialect == database.POSTGRES {
+			dsn = cfg.Store.PostgresURI
+		}
+		db, err := database.Open(ctx, dialect, dsn)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+		store = apikey.NewSQLStore(db, dialect)
+	default:
+		log.Fatalf("API keys can not be created for PERSISTENT_STORE %s", persistentStore)
+	}
+
+	s := apikey.NewService(store)
+	key, apiKey, err := s.Create(ctx, *name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("id: %s\nkey: %s\n", apiKey.ID, key)
+}
@@ -2,63 +2,548 @@ package main
 
 import (
 	"context"
-	"log"
+	"database/sql"
+	"flag"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
 	"time"
 
-	_ "github.com/WeiAnAn/url-shortener/internal/config"
+	"github.com/WeiAnAn/url-shortener/internal/bloom"
+	"github.com/WeiAnAn/url-shortener/internal/config"
+	"github.com/WeiAnAn/url-shortener/internal/database"
+	"github.com/WeiAnAn/url-shortener/internal/domain/analytics"
+	apikey "github.com/WeiAnAn/url-shortener/internal/domain/api_key"
 	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
+	"github.com/WeiAnAn/url-shortener/internal/generator"
+	"github.com/WeiAnAn/url-shortener/internal/health"
+	"github.com/WeiAnAn/url-shortener/internal/lock"
+	"github.com/WeiAnAn/url-shortener/internal/logger"
+	"github.com/WeiAnAn/url-shortener/internal/metrics"
 	"github.com/WeiAnAn/url-shortener/internal/middlewares"
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+	"github.com/WeiAnAn/url-shortener/internal/ratelimit"
+	"github.com/WeiAnAn/url-shortener/internal/screener"
+	"github.com/WeiAnAn/url-shortener/internal/tracing"
+	"github.com/WeiAnAn/url-shortener/internal/urlnorm"
 	"github.com/WeiAnAn/url-shortener/internal/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	"github.com/redis/rueidis"
-	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const CACHE_WARMUP_TIMEOUT = time.Minute
+
+type stores struct {
+	persistentStore shorturl.PersistentStore
+	cacheStore      shorturl.CacheStore
+	cacheLocker     shorturl.Locker
+	shortURLFilter  shorturl.ShortURLFilter
+	analyticsStore  analytics.Store
+	apiKeyStore     apikey.Store
+	limiter         ratelimit.Limiter
+	// counter is only set for the counter generator.
+	counter generator.Counter
+	// dependencies are checked by the readiness probe.
+	dependencies []health.Dependency
+}
+
 func main() {
-	c := setupMongo()
-	defer c.Disconnect(context.Background())
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "path of the YAML or TOML config file, environment variables override it")
+	printConfig := flag.Bool("print-config", false, "print the config with the secrets redacted and exit")
+	flag.Parse()
+
+	slog.SetDefault(logger.New(os.Stdout, "info"))
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		fatal("load config failed", slog.Any("error", err))
+	}
+	if err := cfg.Validate(); err != nil {
+		fatal("invalid config", slog.Any("error", err))
+	}
+	if *printConfig {
+		if err := cfg.Redacted().Print(os.Stdout); err != nil {
+			fatal("print config failed", slog.Any("error", err))
+		}
+		return
+	}
+	slog.SetDefault(logger.New(os.Stdout, cfg.Server.LogLevel))
+
+	var m *metrics.Metrics
+	if cfg.Server.MetricsEnabled {
+		m = metrics.New()
+	}
+
+	tp, shutdownTracing := setupTracing(cfg.Tracing)
+
+	s, closeStores := setupStores(cfg, m, tp)
+	clickRecorder := setupClickRecorder(cfg.Analytics, s.analyticsStore)
+	checker := health.NewChecker(cfg.Server.HealthCheckTimeout, s.dependencies...)
+	urlScreener, closeScreener := setupScreener(cfg.URL)
+	ss := setupShortURLService(cfg, s, urlScreener, m, tp)
+	r := setupRouter(cfg, s, ss, clickRecorder, checker, m, tp)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// the warm-up is canceled on shutdown, and waited for before the stores are closed
+	var warmUp sync.WaitGroup
+	if cfg.Cache.WarmupSize > 0 {
+		warmUp.Add(1)
+		go func() {
+			defer warmUp.Done()
+			warmUpCache(ctx, analytics.NewCacheWarmer(s.analyticsStore, ss), cfg.Cache.WarmupSize, cfg.Cache.WarmupWindow)
+		}()
+	}
+
+	server := &http.Server{
+		Addr:              ":" + strconv.Itoa(cfg.Server.Port),
+		Handler:           r,
+		ReadHeaderTimeout: cfg.Server.ReadTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		fatal("listen failed", slog.Any("error", err))
+	}
+
+	shutdownTimeout := cfg.Server.ShutdownTimeout
+	beforeShutdown := func() {
+		// give load balancers time to notice the failing readiness before refusing connections
+		checker.Shutdown()
+		time.Sleep(cfg.Server.ShutdownDelay)
+	}
+	if err := serve(ctx, server, listener, shutdownTimeout, beforeShutdown); err != nil {
+		slog.Error("drain requests failed", slog.Any("error", err))
+	}
+
+	// in-flight requests are done, so no more click events are recorded and the stores are not used anymore
+	c, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := clickRecorder.Close(c); err != nil {
+		slog.Error("flush click events failed", slog.Any("error", err))
+	}
+	// serve may also return on an error, so the warm-up is canceled here as well
+	stop()
+	warmUp.Wait()
+	closeStores(c)
+	closeScreener()
+	if err := shutdownTracing(c); err != nil {
+		slog.Error("export spans failed", slog.Any("error", err))
+	}
+	slog.Info("server stopped")
+}
+
+// fatal logs the message and exits, so the last line is logged as JSON as well.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// serve serves requests on listener until ctx is done, then calls beforeShutdown
+// and waits at most shutdownTimeout for in-flight requests.
+func serve(ctx context.Context, server *http.Server, listener net.Listener, shutdownTimeout time.Duration, beforeShutdown func()) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+	slog.Info("listening", slog.String("address", listener.Addr().String()))
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down server")
+	beforeShutdown()
+	c, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(c); err != nil {
+		server.Close()
+		return err
+	}
+	return nil
+}
+
+// setupStores creates the stores selected by PERSISTENT_STORE, CACHE_STORE and RATE_LIMIT_STORE.
+// The short url stores are traced, and instrumented if m is not nil.
+// The returned function closes the connections in the reverse order they were opened.
+func setupStores(cfg *config.Config, m *metrics.Metrics, tp trace.TracerProvider) (*stores, func(c context.Context)) {
+	s := &stores{}
+	var closers []func(c context.Context)
+
+	var redisClient rueidis.Client
+	getRedisClient := func() rueidis.Client {
+		if redisClient == nil {
+			redisClient = setupRedis(cfg.Store.RedisHost)
+			s.dependencies = append(s.dependencies, health.Dependency{Name: "redis", Check: func(c context.Context) error {
+				return redisClient.Do(c, redisClient.B().Ping().Build()).Error()
+			}})
+			closers = append(closers, func(c context.Context) { redisClient.Close() })
+		}
+		return redisClient
+	}
+
+	var mongoClient *mongo.Client
+	persistentStoreName := cfg.Store.PersistentStore
+	switch store := persistentStoreName; store {
+	case "mongo":
+		c := setupMongo(cfg.Store.MongoDBURI)
+		mongoClient = c
+		s.dependencies = append(s.dependencies, health.Dependency{Name: "mongo", Check: func(ctx context.Context) error {
+			return c.Ping(ctx, readpref.Primary())
+		}})
+		closers = append(closers, func(ctx context.Context) {
+			if err := c.Disconnect(ctx); err != nil {
+				slog.Error("disconnect mongo failed", slog.Any("error", err))
+			}
+		})
+		s.persistentStore = shorturl.NewMongoPersistentStore(c, "short_urls")
+		s.analyticsStore = analytics.NewMongoStore(c, "short_urls")
+		s.apiKeyStore = apikey.NewMongoStore(c, "short_urls")
+	case "sqlite", "postgres":
+		dialect := database.Dialect(store)
+		db := setupSQL(cfg.Store, dialect)
+		s.dependencies = append(s.dependencies, health.Dependency{Name: store, Check: db.PingContext})
+		closers = append(closers, func(c context.Context) {
+			if err := db.Close(); err != nil {
+				slog.Error("close database failed", slog.Any("error", err))
+			}
+		})
+		s.persistentStore = shorturl.NewSQLPersistentStore(db, dialect)
+		s.analyticsStore = analytics.NewSQLStore(db, dialect)
+		s.apiKeyStore = apikey.NewSQLStore(db, dialect)
+	case "memory":
+		s.persistentStore = shorturl.NewMemoryPersistentStore()
+		s.analyticsStore = analytics.NewMemoryStore()
+		s.apiKeyStore = apikey.NewMemoryStore()
+	default:
+		fatal("unknown PERSISTENT_STORE", slog.String("store", store))
+	}
+	if m != nil {
+		s.persistentStore = shorturl.NewMetricsPersistentStore(s.persistentStore, persistentStoreName, m)
+	}
+	s.persistentStore = shorturl.NewTracingPersistentStore(s.persistentStore, persistentStoreName, tp)
+
+	var cacheStore shorturl.TTLCacheStore
+	var cacheInvalidator shorturl.CacheInvalidator
+	cacheStoreName := cfg.Cache.Store
+	switch store := cacheStoreName; store {
+	case "redis":
+		redisCacheStore := shorturl.NewRedisCacheStore(getRedisClient(), cfg.Cache.ClientSideTTL)
+		if m != nil && cfg.Cache.ClientSideTTL > 0 {
+			m.RegisterCacheStats("client_side", func() (uint64, uint64) {
+				stats := redisCacheStore.Stats()
+				return stats.Hits, stats.Misses
+			})
+		}
+		cacheStore = redisCacheStore
+		cacheInvalidator = shorturl.NewRedisCacheInvalidator(getRedisClient())
+	case "memory":
+		cacheStore = shorturl.NewMemoryCacheStore(&utils.RealTime{})
+	default:
+		fatal("unknown CACHE_STORE", slog.String("store", store))
+	}
+	if m != nil {
+		cacheStore = shorturl.NewMetricsTTLCacheStore(cacheStore, cacheStoreName+"_cache", m)
+	}
+	cacheStore = shorturl.NewTracingTTLCacheStore(cacheStore, cacheStoreName+"_cache", tp)
+	s.cacheStore = cacheStore
+
+	if cfg.Cache.LocalEnabled {
+		twoTierCacheStore := shorturl.NewTwoTierCacheStore(
+			cacheStore,
+			cfg.Cache.LocalSize,
+			cfg.Cache.LocalTTL,
+			cacheInvalidator,
+			&utils.RealTime{},
+		)
+		twoTierCacheStore.Start()
+		closers = append(closers, func(c context.Context) { twoTierCacheStore.Close() })
+		s.cacheStore = twoTierCacheStore
+		if m != nil {
+			m.RegisterCacheStats("local", func() (uint64, uint64) {
+				stats := twoTierCacheStore.Stats()
+				return stats.Hits, stats.Misses
+			})
+			s.cacheStore = shorturl.NewMetricsCacheStore(twoTierCacheStore, "two_tier_cache", m)
+		}
+		s.cacheStore = shorturl.NewTracingCacheStore(s.cacheStore, "two_tier_cache", tp)
+	}
+
+	if cfg.BloomFilter.Enabled {
+		filter := setupBloomFilter(cfg.BloomFilter, s.persistentStore, getRedisClient)
+		closers = append(closers, func(c context.Context) { filter.Close() })
+		s.shortURLFilter = filter
+	}
+
+	if cfg.Cache.LockEnabled {
+		s.cacheLocker = lock.NewRedisLocker(getRedisClient())
+	}
+
+	switch store := cfg.RateLimit.Store; store {
+	case "redis":
+		s.limiter = ratelimit.NewRedisLimiter(getRedisClient())
+	case "memory":
+		s.limiter = ratelimit.NewMemoryLimiter(&utils.RealTime{})
+	default:
+		fatal("unknown RATE_LIMIT_STORE", slog.String("store", store))
+	}
 
-	redisClient := setupRedis()
-	defer redisClient.Close()
+	if cfg.ShortURL.Generator == generator.COUNTER {
+		switch store := cfg.ShortURL.CounterStore; store {
+		case "redis":
+			s.counter = generator.NewRedisCounter(getRedisClient(), "short_url")
+		case "mongo":
+			s.counter = generator.NewMongoCounter(mongoClient, "short_urls", "short_url")
+		case "memory":
+			s.counter = generator.NewMemoryCounter()
+		default:
+			fatal("unknown SHORT_URL_COUNTER_STORE", slog.String("store", store))
+		}
+	}
 
-	r := setupRouter(c, redisClient)
+	return s, func(c context.Context) {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i](c)
+		}
+	}
+}
 
-	r.Run() // listen and serve on 0.0.0.0:8080 (for windows "localhost:8080")
+// setupBloomFilter starts rebuilding the filter in the background, it is mirrored in redis if BLOOM_FILTER_REDIS is set.
+func setupBloomFilter(cfg config.BloomFilterConfig, ps shorturl.PersistentStore, getRedisClient func() rueidis.Client) *shorturl.BloomFilter {
+	var redisFilter *bloom.RedisFilter
+	if cfg.Redis {
+		m, k := bloom.Size(cfg.Capacity, cfg.FPRate)
+		redisFilter = bloom.NewRedisFilter(getRedisClient(), "short_url", m, k)
+	}
+
+	filter := shorturl.NewBloomFilter(ps, cfg.Capacity, cfg.FPRate, redisFilter)
+	filter.Start(cfg.RebuildInterval)
+	return filter
 }
 
-func setupRedis() rueidis.Client {
-	redisClient, err := rueidis.NewClient(rueidis.ClientOption{InitAddress: []string{viper.GetString("REDIS_HOST")}})
+// setupTracing exports spans as configured by TRACING_EXPORTER, the returned function flushes them.
+func setupTracing(cfg config.TracingConfig) (trace.TracerProvider, func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	tp, shutdown, err := tracing.NewTracerProvider(ctx, tracing.Config{
+		Exporter:     cfg.Exporter,
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		OTLPInsecure: cfg.OTLPInsecure,
+		SampleRatio:  cfg.SampleRatio,
+		ServiceName:  cfg.ServiceName,
+	})
 	if err != nil {
-		log.Fatal(err)
+		fatal("setup tracing failed", slog.Any("error", err))
+	}
+	return tp, shutdown
+}
+
+func setupSQL(cfg config.StoreConfig, dialect database.Dialect) *sql.DB {
+	dsn := cfg.SQLitePath
+	if dialect == database.POSTGRES {
+		dsn = cfg.PostgresURI
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	db, err := database.Open(ctx, dialect, dsn)
+	if err != nil {
+		fatal("open database failed", slog.Any("error", err))
+	}
+	return db
+}
+
+func setupRedis(host string) rueidis.Client {
+	redisClient, err := rueidis.NewClient(rueidis.ClientOption{InitAddress: []string{host}})
+	if err != nil {
+		fatal("connect redis failed", slog.Any("error", err))
 	}
 	return redisClient
 }
 
-func setupRouter(c *mongo.Client, redisClient rueidis.Client) *gin.Engine {
-	ps := shorturl.NewMongoPersistentStore(c, "short_urls")
-	cs := shorturl.NewRedisCacheStore(redisClient)
-	sr := shorturl.NewRepository(ps, cs, &utils.RealTime{})
-	sg := &utils.RandomBase62StringGenerator{}
-	ss := shorturl.NewService(sr, sg)
-	sc := shorturl.NewController(ss, viper.GetString("BASE_URL"))
+func setupClickRecorder(cfg config.AnalyticsConfig, store analytics.Store) *analytics.BufferedRecorder {
+	recorder := analytics.NewBufferedRecorder(store, cfg.BufferSize, cfg.BatchSize, cfg.FlushInterval)
+	recorder.Start()
+	return recorder
+}
 
-	r := gin.Default()
-	r.Use(middlewares.ErrorHandler())
+// setupScreener returns a nil screener if screening is disabled. The list files are reloaded until the returned
+// function is called.
+func setupScreener(cfg config.URLConfig) (shorturl.URLScreener, func()) {
+	var chain screener.Chain
+	var closers []func()
+	lists := []struct {
+		path string
+		new  func(path string) (*screener.ListScreener, error)
+	}{
+		{cfg.BlocklistFile, screener.NewBlocklistScreener},
+		{cfg.AllowlistFile, screener.NewAllowlistScreener},
+	}
+	for _, l := range lists {
+		if l.path == "" {
+			continue
+		}
+		list, err := l.new(l.path)
+		if err != nil {
+			fatal("load url list failed", slog.String("path", l.path), slog.Any("error", err))
+		}
+		list.Start(cfg.ListReloadInterval)
+		chain = append(chain, list)
+		closers = append(closers, list.Close)
+	}
+	if cfg.BlockPrivateHosts {
+		var resolver screener.Resolver
+		if cfg.ResolveHosts {
+			resolver = net.DefaultResolver
+		}
+		// checked first, since it does not depend on the lists
+		chain = append(screener.Chain{screener.NewPrivateHostScreener(resolver)}, chain...)
+	}
 
-	r.POST("/api/v1/urls", sc.CreateShortURL)
-	r.GET("/:url", sc.Redirect)
+	closeScreener := func() {
+		for _, closer := range closers {
+			closer()
+		}
+	}
+	if len(chain) == 0 {
+		return nil, closeScreener
+	}
+	return chain, closeScreener
+}
+
+// setupShortURLService creates the short url service shared by the controllers and the cache warm-up,
+// the url screener is optional.
+func setupShortURLService(cfg *config.Config, s *stores, us shorturl.URLScreener, m *metrics.Metrics, tp trace.TracerProvider) shorturl.Service {
+	rc := shorturl.RepositoryConfig{PopulateCacheOnSave: cfg.Cache.PopulateOnSave}
+	if m != nil {
+		rc.ExpiredLookups = m.ExpiredLookups
+	}
+	sr := shorturl.NewTracingRepository(shorturl.NewRepository(s.persistentStore, s.cacheStore, &utils.RealTime{}, s.cacheLocker, s.shortURLFilter, rc), tp)
+	sg, err := generator.New(cfg.ShortURL.Generator, generator.Options{
+		Alphabet: cfg.ShortURL.Alphabet,
+		Length:   cfg.ShortURL.Length,
+		NodeID:   cfg.ShortURL.NodeID,
+		Secret:   cfg.ShortURL.CounterSecret,
+		Counter:  s.counter,
+		Time:     &utils.RealTime{},
+	})
+	if err != nil {
+		fatal("setup short url generator failed", slog.Any("error", err))
+	}
+	un, err := urlnorm.New(cfg.URL.NormalizeRules, cfg.URL.TrackingParams)
+	if err != nil {
+		fatal("setup url normalizer failed", slog.Any("error", err))
+	}
+	ss := shorturl.NewTracingService(shorturl.NewService(sr, sg, un, us, shorturl.ServiceConfig{
+		ShortURLLength:    cfg.ShortURL.Length,
+		MaxRetry:          cfg.ShortURL.MaxRetry,
+		GrowLength:        cfg.ShortURL.GrowLength,
+		MaxShortURLLength: cfg.ShortURL.MaxLength,
+		DeduplicateURLs:   cfg.ShortURL.Deduplicate,
+	}), tp)
+	return ss
+}
+
+// setupRouter traces the requests, it also instruments them and serves /metrics if m is not nil.
+func setupRouter(cfg *config.Config, s *stores, ss shorturl.Service, clickRecorder analytics.Recorder, checker *health.Checker, m *metrics.Metrics, tp trace.TracerProvider) *gin.Engine {
+	// only requests to the controller are counted, the cache warm-up is not a redirect
+	var ms shorturl.Service = ss
+	if m != nil {
+		ms = shorturl.NewMetricsService(ss, m)
+	}
+	tracker := analytics.NewTracker(clickRecorder, cfg.Analytics.IPSalt)
+	sc := shorturl.NewController(ms, cfg.Server.BaseURL, tracker)
+	as := analytics.NewService(s.analyticsStore, ss)
+	ac := analytics.NewController(as)
+	hc := health.NewController(checker)
+
+	errorFormat := myerror.ErrorFormat(cfg.Server.ErrorFormat)
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		myerror.RegisterFieldNames(v)
+	}
+
+	r := gin.New()
+	// the client IP keys the rate limits, so X-Forwarded-For is only read from the configured proxies
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal("invalid SERVER_TRUSTED_PROXIES", slog.Any("error", err))
+	}
+	// handlers pass *gin.Context to the services, so it must expose the request id and the span of the request context
+	r.ContextWithFallback = true
+	r.Use(middlewares.RequestID(), middlewares.Logger(), middlewares.Recovery(errorFormat), middlewares.Tracing(tp))
+	if m != nil {
+		r.Use(middlewares.Metrics(m))
+		r.GET("/metrics", gin.WrapH(m.Handler()))
+	}
+	r.Use(middlewares.ErrorHandler(errorFormat))
+
+	// static routes take precedence over the /:url catch-all, the paths are also reserved aliases
+	r.GET("/healthz", hc.Liveness)
+	r.GET("/readyz", hc.Readiness)
+
+	api := r.Group("/api/v1")
+	if cfg.Server.APIKeyAuthEnabled {
+		ks := apikey.NewService(s.apiKeyStore)
+		api.Use(middlewares.APIKeyAuth(ks))
+	}
+	// a batch takes one of the creation budget per item
+	api.POST("/urls", rateLimit(s.limiter, "create", cfg.RateLimit.CreateLimit, cfg.RateLimit.CreateWindow, nil), sc.CreateShortURL)
+	api.POST("/urls:method", shorturl.RequireBatchMethod, rateLimit(s.limiter, "create", cfg.RateLimit.CreateLimit, cfg.RateLimit.CreateWindow, shorturl.BatchSize), sc.BatchCreateShortURLs)
+	api.GET("/urls/:id", sc.GetShortURL)
+	api.PATCH("/urls/:id", sc.UpdateShortURL)
+	api.DELETE("/urls/:id", sc.DeleteShortURL)
+	api.GET("/urls/:id/stats", ac.GetStats)
+	r.GET("/:url", rateLimit(s.limiter, "redirect", cfg.RateLimit.RedirectLimit, cfg.RateLimit.RedirectWindow, nil), sc.Redirect)
 
 	return r
 }
 
-func setupMongo() *mongo.Client {
+// warmUpCache loads the short urls most clicked within the window into the cache, until c is canceled.
+func warmUpCache(c context.Context, warmer *analytics.CacheWarmer, limit int, window time.Duration) {
+	ctx, cancel := context.WithTimeout(c, CACHE_WARMUP_TIMEOUT)
+	defer cancel()
+
+	since := time.Now().Add(-window)
+	warmed, err := warmer.WarmUp(ctx, since, limit)
+	if err != nil {
+		slog.Error("cache warm-up failed", slog.Int("short_urls", warmed), slog.Any("error", err))
+		return
+	}
+	slog.Info("cache warm-up finished", slog.Int("short_urls", warmed))
+}
+
+// rateLimit allows limit requests per window, a zero limit disables it. cost is the share of the limit
+// a request takes, a nil cost takes one.
+func rateLimit(limiter ratelimit.Limiter, name string, limit int, window time.Duration, cost func(c *gin.Context) int) gin.HandlerFunc {
+	if limit <= 0 {
+		return func(c *gin.Context) {}
+	}
+	if cost == nil {
+		return middlewares.RateLimit(limiter, name, limit, window)
+	}
+	return middlewares.RateLimitN(limiter, name, limit, window, cost)
+}
+
+func setupMongo(uri string) *mongo.Client {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(viper.GetString("MONGODB_URI")))
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
 	if err != nil {
-		log.Fatal(err)
+		fatal("connect mongo failed", slog.Any("error", err))
 	}
 
 	return client
@@ -0,0 +1,656 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/config"
+	"github.com/WeiAnAn/url-shortener/internal/domain/analytics"
+	apikey "github.com/WeiAnAn/url-shortener/internal/domain/api_key"
+	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
+	"github.com/WeiAnAn/url-shortener/internal/health"
+	"github.com/WeiAnAn/url-shortener/internal/metrics"
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+	"github.com/WeiAnAn/url-shortener/internal/ratelimit"
+	"github.com/WeiAnAn/url-shortener/internal/utils"
+	"github.com/gin-gonic/gin"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type testServer struct {
+	router      *gin.Engine
+	recorder    *analytics.BufferedRecorder
+	apiKeyStore apikey.Store
+	apiKey      string
+	checker     *health.Checker
+}
+
+func newTestStores() *stores {
+	return &stores{
+		persistentStore: shorturl.NewMemoryPersistentStore(),
+		cacheStore:      shorturl.NewMemoryCacheStore(&utils.RealTime{}),
+		analyticsStore:  analytics.NewMemoryStore(),
+		apiKeyStore:     apikey.NewMemoryStore(),
+		limiter:         ratelimit.NewMemoryLimiter(&utils.RealTime{}),
+	}
+}
+
+func setupTestServer(t *testing.T) *testServer {
+	return setupTestServerWithStores(t, newTestStores())
+}
+
+func setupTestServerWithStores(t *testing.T, s *stores) *testServer {
+	return setupTestServerWithTracing(t, s, trace.NewNoopTracerProvider())
+}
+
+func setupTestServerWithTracing(t *testing.T, s *stores, tp trace.TracerProvider) *testServer {
+	gin.SetMode(gin.TestMode)
+
+	key, _, err := apikey.NewService(s.apiKeyStore).Create(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := analytics.NewBufferedRecorder(s.analyticsStore, 100, 10, time.Hour)
+	recorder.Start()
+
+	checker := health.NewChecker(time.Second, s.dependencies...)
+
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	us, closeScreener := setupScreener(cfg.URL)
+	t.Cleanup(closeScreener)
+
+	m := metrics.New()
+	ss := setupShortURLService(cfg, s, us, m, tp)
+	return &testServer{setupRouter(cfg, s, ss, recorder, checker, m, tp), recorder, s.apiKeyStore, key, checker}
+}
+
+func (ts *testServer) do(method, path, apiKey, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, req)
+	return w
+}
+
+func (ts *testServer) createShortURL(t *testing.T, url string) string {
+	expireAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+	w := ts.do(http.MethodPost, "/api/v1/urls", ts.apiKey, `{"url": "`+url+`", "expireAt": "`+expireAt+`"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create short url response %d %s", w.Code, w.Body.String())
+	}
+
+	var res struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &res)
+	return res.ID
+}
+
+func TestCreateShortURLAndRedirect(t *testing.T) {
+	ts := setupTestServer(t)
+
+	id := ts.createShortURL(t, "https://example.com/long")
+
+	w := ts.do(http.MethodGet, "/"+id, "", "")
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "https://example.com/long" {
+		t.Errorf("redirect response %d %s", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestRedirectNotFound(t *testing.T) {
+	ts := setupTestServer(t)
+
+	w := ts.do(http.MethodGet, "/notfound", "", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestAPIRequireAPIKey(t *testing.T) {
+	ts := setupTestServer(t)
+
+	w := ts.do(http.MethodPost, "/api/v1/urls", "", `{}`)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestCreateShortURLRedirectToNormalizedURL(t *testing.T) {
+	ts := setupTestServer(t)
+
+	id := ts.createShortURL(t, "HTTPS://Example.com:443/a/../long")
+
+	w := ts.do(http.MethodGet, "/"+id, "", "")
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "https://example.com/long" {
+		t.Errorf("redirect response %d %s", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestCreateShortURLRejectPrivateHost(t *testing.T) {
+	ts := setupTestServer(t)
+
+	expireAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+	w := ts.do(http.MethodPost, "/api/v1/urls", ts.apiKey, `{"url": "http://169.254.169.254/latest/meta-data/", "expireAt": "`+expireAt+`"}`)
+
+	var body map[string]any
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusBadRequest || body["code"] != myerror.CODE_URL_BLOCKED {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRedirectShowWarningPageIfURLIsBlockedAfterCreation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	modTime := time.Now().Add(-time.Hour)
+	writeBlocklist(t, path, "# empty\n", modTime)
+	t.Setenv("URL_BLOCKLIST_FILE", path)
+	t.Setenv("URL_LIST_RELOAD_INTERVAL", "10ms")
+	ts := setupTestServer(t)
+	id := ts.createShortURL(t, "https://phishing.example/login")
+
+	writeBlocklist(t, path, "phishing.example\n", modTime.Add(time.Minute))
+	deadline := time.Now().Add(time.Second)
+	w := ts.do(http.MethodGet, "/"+id, "", "")
+	for w.Code == http.StatusFound && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		w = ts.do(http.MethodGet, "/"+id, "", "")
+	}
+	if w.Code != http.StatusForbidden || w.Header().Get("Location") != "" || !strings.Contains(w.Body.String(), "https://phishing.example/login") {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	expireAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+	w = ts.do(http.MethodPost, "/api/v1/urls", ts.apiKey, `{"url": "https://www.phishing.example/", "expireAt": "`+expireAt+`"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected blocked url not to be created, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func writeBlocklist(t *testing.T, path, content string, modTime time.Time) {
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBatchCreateShortURLsChargeRateLimitPerItem(t *testing.T) {
+	t.Setenv("RATE_LIMIT_CREATE_LIMIT", "3")
+	ts := setupTestServer(t)
+
+	expireAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+	item := `{"url": "https://example.com/long", "expireAt": "` + expireAt + `"}`
+	w := ts.do(http.MethodPost, "/api/v1/urls:batch", ts.apiKey, "["+item+","+item+"]")
+	if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != "1" {
+		t.Fatalf("batch response %d %v %s", w.Code, w.Header(), w.Body.String())
+	}
+
+	w = ts.do(http.MethodPost, "/api/v1/urls:batch", ts.apiKey, "["+item+","+item+"]")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected batch over the remaining limit to be rejected, got %d", w.Code)
+	}
+
+	ts.createShortURL(t, "https://example.com/long")
+	w = ts.do(http.MethodPost, "/api/v1/urls", ts.apiKey, item)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected batch items to share the creation limit, got %d", w.Code)
+	}
+}
+
+func TestBatchCreateShortURLsRejectBatchLargerThanRateLimit(t *testing.T) {
+	t.Setenv("RATE_LIMIT_CREATE_LIMIT", "3")
+	ts := setupTestServer(t)
+
+	expireAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+	item := `{"url": "https://example.com/long", "expireAt": "` + expireAt + `"}`
+	w := ts.do(http.MethodPost, "/api/v1/urls:batch", ts.apiKey, "["+strings.Repeat(item+",", 3)+item+"]")
+	if w.Code != http.StatusBadRequest || w.Header().Get("Retry-After") != "" || !strings.Contains(w.Body.String(), "allows 3 per") {
+		t.Errorf("expected batch which can never be allowed to be invalid, got %d %v %s", w.Code, w.Header(), w.Body.String())
+	}
+}
+
+func TestRedirectRateLimitIgnoreForwardedForOfUntrustedClient(t *testing.T) {
+	t.Setenv("RATE_LIMIT_REDIRECT_LIMIT", "1")
+	ts := setupTestServer(t)
+
+	for i, forwardedFor := range []string{"203.0.113.1", "203.0.113.2"} {
+		req := httptest.NewRequest(http.MethodGet, "/notfound", nil)
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		ts.router.ServeHTTP(w, req)
+		if i > 0 && w.Code != http.StatusTooManyRequests {
+			t.Errorf("expected spoofed X-Forwarded-For not to reset the limit, got %d", w.Code)
+		}
+	}
+}
+
+func TestRedirectRateLimitUseForwardedForOfTrustedProxy(t *testing.T) {
+	t.Setenv("RATE_LIMIT_REDIRECT_LIMIT", "1")
+	t.Setenv("SERVER_TRUSTED_PROXIES", "192.0.2.0/24")
+	ts := setupTestServer(t)
+
+	for _, forwardedFor := range []string{"203.0.113.1", "203.0.113.2"} {
+		req := httptest.NewRequest(http.MethodGet, "/notfound", nil)
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		ts.router.ServeHTTP(w, req)
+		if w.Code == http.StatusTooManyRequests {
+			t.Errorf("expected clients behind the trusted proxy to have their own limit, got %d", w.Code)
+		}
+	}
+}
+
+func TestBatchCreateShortURLsNotChargeRateLimitOfUnknownMethod(t *testing.T) {
+	t.Setenv("RATE_LIMIT_CREATE_LIMIT", "1")
+	ts := setupTestServer(t)
+
+	w := ts.do(http.MethodPost, "/api/v1/urls:unknown", ts.apiKey, "[]")
+	if w.Code != http.StatusNotFound || w.Header().Get("X-RateLimit-Remaining") != "" {
+		t.Errorf("expected unknown method to be not found before the rate limit, got %d %v", w.Code, w.Header())
+	}
+	ts.createShortURL(t, "https://example.com/long")
+}
+
+func TestBatchCreateShortURLsRejectTooLargeBody(t *testing.T) {
+	ts := setupTestServer(t)
+
+	w := ts.do(http.MethodPost, "/api/v1/urls:batch", ts.apiKey, "["+strings.Repeat(" ", shorturl.MAX_BATCH_BODY_SIZE)+"]")
+	if w.Code != http.StatusRequestEntityTooLarge || w.Header().Get("X-RateLimit-Remaining") != "59" {
+		t.Errorf("expected too large body to be rejected and charged as one, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestUpdateShortURLInvalidateCache(t *testing.T) {
+	ts := setupTestServer(t)
+	id := ts.createShortURL(t, "https://example.com/long")
+
+	ts.do(http.MethodGet, "/"+id, "", "")
+
+	w := ts.do(http.MethodPatch, "/api/v1/urls/"+id, ts.apiKey, `{"url": "https://example.com/new"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update response %d %s", w.Code, w.Body.String())
+	}
+
+	w = ts.do(http.MethodGet, "/"+id, "", "")
+	if w.Header().Get("Location") != "https://example.com/new" {
+		t.Errorf("expected redirect to updated url, got %s", w.Header().Get("Location"))
+	}
+}
+
+func TestDeleteShortURL(t *testing.T) {
+	ts := setupTestServer(t)
+	id := ts.createShortURL(t, "https://example.com/long")
+
+	ts.do(http.MethodGet, "/"+id, "", "")
+
+	w := ts.do(http.MethodDelete, "/api/v1/urls/"+id, ts.apiKey, "")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete response %d %s", w.Code, w.Body.String())
+	}
+
+	w = ts.do(http.MethodGet, "/"+id, "", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 after delete, got %d", w.Code)
+	}
+	w = ts.do(http.MethodGet, "/api/v1/urls/"+id, ts.apiKey, "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 after delete, got %d", w.Code)
+	}
+}
+
+func TestOtherOwnerCannotGetShortURL(t *testing.T) {
+	ts := setupTestServer(t)
+	id := ts.createShortURL(t, "https://example.com/long")
+
+	otherKey, _, err := apikey.NewService(ts.apiKeyStore).Create(context.Background(), "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := ts.do(http.MethodGet, "/api/v1/urls/"+id, otherKey, "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for other owner, got %d", w.Code)
+	}
+	w = ts.do(http.MethodGet, "/api/v1/urls/"+id, ts.apiKey, "")
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 for owner, got %d", w.Code)
+	}
+}
+
+func TestCreateShortURLReuseShortURLIfDeduplicated(t *testing.T) {
+	t.Setenv("SHORT_URL_DEDUPLICATE", "true")
+	ts := setupTestServer(t)
+	id := ts.createShortURL(t, "https://example.com/long")
+
+	expireAt := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second).Format(time.RFC3339)
+	w := ts.do(http.MethodPost, "/api/v1/urls", ts.apiKey, `{"url": "https://EXAMPLE.com/long", "expireAt": "`+expireAt+`"}`)
+	var res struct {
+		ID       string `json:"id"`
+		ExpireAt string `json:"expireAt"`
+		Reused   bool   `json:"reused"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &res)
+	if w.Code != http.StatusOK || res.ID != id || !res.Reused {
+		t.Fatalf("expected short url %s to be reused, got %d %s", id, w.Code, w.Body.String())
+	}
+	if res.ExpireAt != expireAt {
+		t.Errorf("expected the reused short url to be extended to %s, got %s", expireAt, res.ExpireAt)
+	}
+
+	otherKey, _, err := apikey.NewService(ts.apiKeyStore).Create(context.Background(), "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w = ts.do(http.MethodPost, "/api/v1/urls", otherKey, `{"url": "https://example.com/long", "expireAt": "`+expireAt+`"}`)
+	json.Unmarshal(w.Body.Bytes(), &res)
+	if res.ID == id || res.Reused {
+		t.Errorf("expected other owner not to reuse short url, got %s", w.Body.String())
+	}
+}
+
+func TestStatsCountRedirects(t *testing.T) {
+	ts := setupTestServer(t)
+	id := ts.createShortURL(t, "https://example.com/long")
+
+	for i := 0; i < 3; i++ {
+		ts.do(http.MethodGet, "/"+id, "", "")
+	}
+	ts.recorder.Close(context.Background())
+
+	w := ts.do(http.MethodGet, "/api/v1/urls/"+id+"/stats", ts.apiKey, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("stats response %d %s", w.Code, w.Body.String())
+	}
+
+	var stats analytics.Stats
+	json.Unmarshal(w.Body.Bytes(), &stats)
+	if stats.TotalClicks != 3 {
+		t.Errorf("expected 3 clicks, got %d", stats.TotalClicks)
+	}
+}
+
+func TestRedirectWithBloomFilter(t *testing.T) {
+	s := newTestStores()
+	filter := shorturl.NewBloomFilter(s.persistentStore, 1000, 0.01, nil)
+	filter.Rebuild(context.Background())
+	s.shortURLFilter = filter
+	ts := setupTestServerWithStores(t, s)
+
+	id := ts.createShortURL(t, "https://example.com/long")
+
+	w := ts.do(http.MethodGet, "/"+id, "", "")
+	if w.Code != http.StatusFound {
+		t.Errorf("expected 302 for created short url, got %d", w.Code)
+	}
+
+	w = ts.do(http.MethodGet, "/notfound", "", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+	if value, _ := s.cacheStore.Get(context.Background(), "notfound"); value != nil {
+		t.Error("short url which was never created is cached")
+	}
+}
+
+func TestCreateShortURLPopulateCache(t *testing.T) {
+	s := newTestStores()
+	ts := setupTestServerWithStores(t, s)
+
+	s.cacheStore.Set(context.Background(), "created", "", 300)
+	expireAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+	w := ts.do(http.MethodPost, "/api/v1/urls", ts.apiKey, `{"url": "https://example.com/long", "expireAt": "`+expireAt+`", "alias": "created"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create short url response %d %s", w.Code, w.Body.String())
+	}
+
+	value, _ := s.cacheStore.Get(context.Background(), "created")
+	if value == nil || *value != "https://example.com/long" {
+		t.Errorf("created short url is not cached, got %v", value)
+	}
+
+	w = ts.do(http.MethodGet, "/created", "", "")
+	if w.Code != http.StatusFound {
+		t.Errorf("expected 302 instead of the cached 404, got %d", w.Code)
+	}
+}
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("done"))
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(ctx, server, listener, time.Second, func() {})
+	}()
+
+	responded := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			responded <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		responded <- string(body)
+	}()
+
+	<-started
+	cancel()
+
+	if err := <-served; err != nil {
+		t.Errorf("serve returned %v", err)
+	}
+	if body := <-responded; body != "done" {
+		t.Errorf("in-flight request was cut off, got %q", body)
+	}
+	if _, err := http.Get("http://" + listener.Addr().String()); err == nil {
+		t.Error("server still accepts requests after shutdown")
+	}
+}
+
+func TestServeGivesUpAfterShutdownTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(ctx, server, listener, 50*time.Millisecond, func() {})
+	}()
+	go http.Get("http://" + listener.Addr().String())
+
+	<-started
+	cancel()
+
+	select {
+	case err := <-served:
+		if err != context.DeadlineExceeded {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("serve did not return after the shutdown timeout")
+	}
+}
+
+func TestHealthEndpointsAreNotShadowedByRedirect(t *testing.T) {
+	ts := setupTestServer(t)
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		w := ts.do(http.MethodGet, path, "", "")
+		if w.Code != http.StatusOK {
+			t.Errorf("%s expected 200, got %d", path, w.Code)
+		}
+		var report health.Report
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil || report.Status != health.STATUS_UP {
+			t.Errorf("%s unexpected body %s", path, w.Body.String())
+		}
+	}
+}
+
+func TestReadyzFailsWhenDependencyIsDown(t *testing.T) {
+	s := newTestStores()
+	s.dependencies = []health.Dependency{
+		{Name: "mongo", Check: func(c context.Context) error { return nil }},
+		{Name: "redis", Check: func(c context.Context) error { return errors.New("connection refused") }},
+	}
+	ts := setupTestServerWithStores(t, s)
+
+	w := ts.do(http.MethodGet, "/readyz", "", "")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", w.Code)
+	}
+	var report health.Report
+	json.Unmarshal(w.Body.Bytes(), &report)
+	if report.Status != health.STATUS_DOWN || report.Dependencies["redis"].Status != health.STATUS_DOWN || report.Dependencies["mongo"].Status != health.STATUS_UP {
+		t.Errorf("unexpected body %s", w.Body.String())
+	}
+
+	w = ts.do(http.MethodGet, "/healthz", "", "")
+	if w.Code != http.StatusOK {
+		t.Errorf("liveness should not depend on dependencies, got %d", w.Code)
+	}
+}
+
+func TestReadyzFailsDuringShutdown(t *testing.T) {
+	ts := setupTestServer(t)
+
+	ts.checker.Shutdown()
+	w := ts.do(http.MethodGet, "/readyz", "", "")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", w.Code)
+	}
+}
+
+func TestMetricsCountRedirectsAndCreatedShortURLs(t *testing.T) {
+	ts := setupTestServer(t)
+
+	short := ts.createShortURL(t, "https://example.com/long")
+	ts.do(http.MethodGet, "/"+short, "", "")
+	ts.do(http.MethodGet, "/notfound", "", "")
+
+	w := ts.do(http.MethodGet, "/metrics", "", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	for _, line := range []string{
+		`url_shortener_redirects_total{result="hit"} 1`,
+		`url_shortener_redirects_total{result="miss"} 1`,
+		`url_shortener_short_urls_created_total 1`,
+		`url_shortener_http_requests_total{method="GET",route="/:url",status="302"} 1`,
+	} {
+		if !strings.Contains(w.Body.String(), line) {
+			t.Errorf("metrics do not contain %s", line)
+		}
+	}
+}
+
+func TestRedirectTraceContinuesIncomingTraceContext(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	s := newTestStores()
+	s.persistentStore = shorturl.NewTracingPersistentStore(s.persistentStore, "memory", tp)
+	s.cacheStore = shorturl.NewTracingCacheStore(s.cacheStore, "memory_cache", tp)
+	ts := setupTestServerWithTracing(t, s, tp)
+
+	short := ts.createShortURL(t, "https://example.com/long")
+	s.cacheStore.Delete(context.Background(), short)
+	exporter.Reset()
+
+	req := httptest.NewRequest(http.MethodGet, "/"+short, nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, req)
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected 302, got %d", w.Code)
+	}
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+	server, ok := spans["GET /:url"]
+	if !ok {
+		t.Fatalf("server span not found in %v", spans)
+	}
+	if server.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || server.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("server span does not continue the incoming trace, got %v parent %v", server.SpanContext.TraceID(), server.Parent.SpanID())
+	}
+
+	parents := map[string]string{
+		"Service.GetOriginalURL":                  "GET /:url",
+		"ShortURLRepository.FindByShortURL":       "Service.GetOriginalURL",
+		"CacheStore.Get":                          "ShortURLRepository.FindByShortURL",
+		"PersistentStore.FindUnexpiredByShortURL": "ShortURLRepository.FindByShortURL",
+		"CacheStore.Set":                          "ShortURLRepository.FindByShortURL",
+	}
+	for name, parent := range parents {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("span %s not found", name)
+			continue
+		}
+		if span.Parent.SpanID() != spans[parent].SpanContext.SpanID() {
+			t.Errorf("expected parent of %s to be %s", name, parent)
+		}
+		if span.SpanContext.TraceID() != server.SpanContext.TraceID() {
+			t.Errorf("span %s is not in the trace of the request", name)
+		}
+	}
+}
+
+func TestErrorResponseHasRequestID(t *testing.T) {
+	ts := setupTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/notfound", nil)
+	req.Header.Set("X-API-Key", ts.apiKey)
+	req.Header.Set("X-Request-ID", "abc-123")
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, req)
+
+	var body map[string]any
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusNotFound || body["requestId"] != "abc-123" || body["code"] != myerror.CODE_NOT_FOUND ||
+		w.Header().Get("X-Request-ID") != "abc-123" {
+		t.Errorf("unexpected response %d %v %s", w.Code, w.Header(), w.Body.String())
+	}
+}
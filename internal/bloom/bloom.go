@@ -0,0 +1,82 @@
+package bloom
+
+import (
+	"hash/fnv"
+	"math"
+	"sync/atomic"
+)
+
+// Filter is a Bloom filter which is safe for concurrent use.
+// Test never returns false for an added key, and returns true for a key which was not added
+// with the false positive rate the filter was sized for.
+type Filter struct {
+	bits []uint64
+	m    uint64
+	k    uint64
+}
+
+// New sizes the filter to keep the false positive rate when capacity keys are added.
+func New(capacity uint, fpRate float64) *Filter {
+	m, k := Size(capacity, fpRate)
+	return NewWithSize(m, k)
+}
+
+// NewWithSize creates a filter of m bits using k hash functions.
+func NewWithSize(m, k uint64) *Filter {
+	return &Filter{make([]uint64, (m+63)/64), m, k}
+}
+
+// Size returns the optimal number of bits and hash functions for the capacity and false positive rate.
+func Size(capacity uint, fpRate float64) (m, k uint64) {
+	n := math.Max(float64(capacity), 1)
+	bits := math.Ceil(-n * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	hashes := math.Round(bits / n * math.Ln2)
+	return uint64(math.Max(bits, 64)), uint64(math.Max(hashes, 1))
+}
+
+func (f *Filter) M() uint64 {
+	return f.m
+}
+
+func (f *Filter) K() uint64 {
+	return f.k
+}
+
+func (f *Filter) Add(key string) {
+	for _, location := range Locations(key, f.m, f.k) {
+		addr := &f.bits[location/64]
+		mask := uint64(1) << (location % 64)
+		for {
+			old := atomic.LoadUint64(addr)
+			if old&mask != 0 || atomic.CompareAndSwapUint64(addr, old, old|mask) {
+				break
+			}
+		}
+	}
+}
+
+func (f *Filter) Test(key string) bool {
+	for _, location := range Locations(key, f.m, f.k) {
+		if atomic.LoadUint64(&f.bits[location/64])&(uint64(1)<<(location%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Locations returns the k bit positions of the key in a filter of m bits,
+// using double hashing of two FNV hashes.
+func Locations(key string, m, k uint64) []uint64 {
+	h1 := fnv.New64a()
+	h1.Write([]byte(key))
+	a := h1.Sum64()
+	h2 := fnv.New64()
+	h2.Write([]byte(key))
+	b := h2.Sum64() | 1
+
+	locations := make([]uint64, k)
+	for i := uint64(0); i < k; i++ {
+		locations[i] = (a + i*b) % m
+	}
+	return locations
+}
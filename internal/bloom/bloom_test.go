@@ -0,0 +1,56 @@
+package bloom_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/WeiAnAn/url-shortener/internal/bloom"
+)
+
+func TestSize(t *testing.T) {
+	m, k := bloom.Size(1000, 0.01)
+
+	if m != 9586 || k != 7 {
+		t.Errorf("expected 9586 bits and 7 hashes, got %d %d", m, k)
+	}
+}
+
+func TestFilterHasNoFalseNegative(t *testing.T) {
+	f := bloom.New(10000, 0.01)
+	for i := 0; i < 10000; i++ {
+		f.Add("key" + strconv.Itoa(i))
+	}
+
+	for i := 0; i < 10000; i++ {
+		if !f.Test("key" + strconv.Itoa(i)) {
+			t.Fatalf("added key%d is not found", i)
+		}
+	}
+}
+
+func TestFilterFalsePositiveRate(t *testing.T) {
+	f := bloom.New(10000, 0.01)
+	for i := 0; i < 10000; i++ {
+		f.Add("key" + strconv.Itoa(i))
+	}
+
+	falsePositives := 0
+	for i := 0; i < 100000; i++ {
+		if f.Test("missing" + strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+
+	rate := float64(falsePositives) / 100000
+	if rate > 0.02 {
+		t.Errorf("false positive rate %f is much higher than 0.01", rate)
+	}
+}
+
+func TestLocationsAreWithinFilter(t *testing.T) {
+	for _, location := range bloom.Locations("key", 100, 10) {
+		if location >= 100 {
+			t.Errorf("location %d is out of range", location)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package bloom
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/rueidis"
+)
+
+const KEY_PREFIX = "bloom:"
+
+// testScript returns -1 if the filter is not ready, otherwise 1 if all the bits are set and 0 if not.
+// The ready flag is the bit after the filter bits, so it is evicted together with the filter.
+var testScript = rueidis.NewLuaScript(`
+if redis.call("GETBIT", KEYS[1], ARGV[1]) == 0 then
+	return -1
+end
+for i = 2, #ARGV do
+	if redis.call("GETBIT", KEYS[1], ARGV[i]) == 0 then
+		return 0
+	end
+end
+return 1
+`)
+
+var addScript = rueidis.NewLuaScript(`
+for i = 1, #ARGV do
+	redis.call("SETBIT", KEYS[1], ARGV[i], 1)
+end
+return 0
+`)
+
+// RedisFilter keeps the bits of a Bloom filter in a redis string, so it is shared by replicas.
+// Bits are never cleared, so it only grows until the key is removed.
+type RedisFilter struct {
+	client rueidis.Client
+	key    string
+	m      uint64
+	k      uint64
+}
+
+// NewRedisFilter uses a key derived from the name and the size, so filters of different sizes do not mix.
+func NewRedisFilter(client rueidis.Client, name string, m, k uint64) *RedisFilter {
+	return &RedisFilter{client, fmt.Sprintf("%s%s:%d:%d", KEY_PREFIX, name, m, k), m, k}
+}
+
+func (r *RedisFilter) Add(c context.Context, key string) error {
+	return r.AddMany(c, []string{key})
+}
+
+func (r *RedisFilter) AddMany(c context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	args := make([]string, 0, len(keys)*int(r.k))
+	for _, key := range keys {
+		for _, location := range Locations(key, r.m, r.k) {
+			args = append(args, strconv.FormatUint(location, 10))
+		}
+	}
+	return addScript.Exec(c, r.client, []string{r.key}, args).Error()
+}
+
+// Test returns ready false if the filter is not populated yet.
+func (r *RedisFilter) Test(c context.Context, key string) (found bool, ready bool, err error) {
+	locations := Locations(key, r.m, r.k)
+	args := make([]string, 0, len(locations)+1)
+	args = append(args, strconv.FormatUint(r.m, 10))
+	for _, location := range locations {
+		args = append(args, strconv.FormatUint(location, 10))
+	}
+
+	result, err := testScript.Exec(c, r.client, []string{r.key}, args).AsInt64()
+	if err != nil {
+		return false, false, err
+	}
+	return result == 1, result != -1, nil
+}
+
+func (r *RedisFilter) Ready(c context.Context) (bool, error) {
+	bit, err := r.client.Do(c, r.client.B().Getbit().Key(r.key).Offset(int64(r.m)).Build()).AsInt64()
+	return bit == 1, err
+}
+
+// MarkReady is called after all the keys are added.
+func (r *RedisFilter) MarkReady(c context.Context) error {
+	return r.client.Do(c, r.client.B().Setbit().Key(r.key).Offset(int64(r.m)).Value(1).Build()).Error()
+}
@@ -1,13 +1,461 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
+	"github.com/WeiAnAn/url-shortener/internal/generator"
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+	"github.com/WeiAnAn/url-shortener/internal/tracing"
+	"github.com/WeiAnAn/url-shortener/internal/urlnorm"
 	"github.com/spf13/viper"
 )
 
-func init() {
-	viper.SetDefault("MONGODB_URI", "mongodb://short_url@localhost:27017")
-	viper.SetDefault("REDIS_HOST", "localhost:6379")
-	viper.SetDefault("BASE_URL", "http://localhost:8080")
-	viper.AllowEmptyEnv(true)
-	viper.AutomaticEnv()
+// MIN_SHORT_URL_LENGTH keeps generated short urls from running out quickly, 62^4 is about 14 million.
+const MIN_SHORT_URL_LENGTH = 4
+
+// REDACTED replaces secrets when the config is printed.
+const REDACTED = "REDACTED"
+
+// Config is the whole configuration of the app. Every key is named as its environment variable,
+// which is also the key in the config file.
+type Config struct {
+	Server      ServerConfig      `mapstructure:",squash"`
+	Tracing     TracingConfig     `mapstructure:",squash"`
+	Store       StoreConfig       `mapstructure:",squash"`
+	Cache       CacheConfig       `mapstructure:",squash"`
+	BloomFilter BloomFilterConfig `mapstructure:",squash"`
+	ShortURL    ShortURLConfig    `mapstructure:",squash"`
+	URL         URLConfig         `mapstructure:",squash"`
+	RateLimit   RateLimitConfig   `mapstructure:",squash"`
+	Analytics   AnalyticsConfig   `mapstructure:",squash"`
+}
+
+type ServerConfig struct {
+	Port     int    `mapstructure:"PORT"`
+	LogLevel string `mapstructure:"LOG_LEVEL"`
+	// ErrorFormat is myerror.ERROR_FORMAT_PROBLEM or myerror.ERROR_FORMAT_LEGACY.
+	ErrorFormat     string        `mapstructure:"ERROR_FORMAT"`
+	ReadTimeout     time.Duration `mapstructure:"SERVER_READ_TIMEOUT"`
+	WriteTimeout    time.Duration `mapstructure:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout     time.Duration `mapstructure:"SERVER_IDLE_TIMEOUT"`
+	ShutdownTimeout time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	// ShutdownDelay is how long the readiness probe fails before the server stops accepting connections.
+	ShutdownDelay      time.Duration `mapstructure:"SHUTDOWN_DELAY"`
+	HealthCheckTimeout time.Duration `mapstructure:"HEALTH_CHECK_TIMEOUT"`
+	MetricsEnabled     bool          `mapstructure:"METRICS_ENABLED"`
+	// BaseURL prefixes the short url ids in responses.
+	BaseURL           string `mapstructure:"BASE_URL"`
+	APIKeyAuthEnabled bool   `mapstructure:"API_KEY_AUTH_ENABLED"`
+	// TrustedProxies are the IPs and CIDRs whose X-Forwarded-For header tells the client IP, empty trusts none.
+	TrustedProxies []string `mapstructure:"SERVER_TRUSTED_PROXIES"`
+}
+
+type TracingConfig struct {
+	Exporter     string  `mapstructure:"TRACING_EXPORTER"`
+	OTLPEndpoint string  `mapstructure:"TRACING_OTLP_ENDPOINT"`
+	OTLPInsecure bool    `mapstructure:"TRACING_OTLP_INSECURE"`
+	SampleRatio  float64 `mapstructure:"TRACING_SAMPLE_RATIO"`
+	ServiceName  string  `mapstructure:"TRACING_SERVICE_NAME"`
+}
+
+type StoreConfig struct {
+	// PersistentStore is "mongo", "sqlite", "postgres" or "memory".
+	PersistentStore string `mapstructure:"PERSISTENT_STORE"`
+	MongoDBURI      string `mapstructure:"MONGODB_URI"`
+	SQLitePath      string `mapstructure:"SQLITE_PATH"`
+	PostgresURI     string `mapstructure:"POSTGRES_URI"`
+	RedisHost       string `mapstructure:"REDIS_HOST"`
+}
+
+type CacheConfig struct {
+	// Store is "redis" or "memory".
+	Store          string        `mapstructure:"CACHE_STORE"`
+	ClientSideTTL  time.Duration `mapstructure:"CACHE_CLIENT_SIDE_TTL"`
+	LocalEnabled   bool          `mapstructure:"CACHE_LOCAL_ENABLED"`
+	LocalSize      int           `mapstructure:"CACHE_LOCAL_SIZE"`
+	LocalTTL       time.Duration `mapstructure:"CACHE_LOCAL_TTL"`
+	LockEnabled    bool          `mapstructure:"CACHE_LOCK_ENABLED"`
+	PopulateOnSave bool          `mapstructure:"CACHE_POPULATE_ON_SAVE"`
+	// WarmupSize is how many of the most clicked short urls are cached on start, 0 disables the warm-up.
+	WarmupSize   int           `mapstructure:"CACHE_WARMUP_SIZE"`
+	WarmupWindow time.Duration `mapstructure:"CACHE_WARMUP_WINDOW"`
+}
+
+type BloomFilterConfig struct {
+	Enabled         bool          `mapstructure:"BLOOM_FILTER_ENABLED"`
+	Capacity        uint          `mapstructure:"BLOOM_FILTER_CAPACITY"`
+	FPRate          float64       `mapstructure:"BLOOM_FILTER_FP_RATE"`
+	RebuildInterval time.Duration `mapstructure:"BLOOM_FILTER_REBUILD_INTERVAL"`
+	Redis           bool          `mapstructure:"BLOOM_FILTER_REDIS"`
+}
+
+type ShortURLConfig struct {
+	Length     int  `mapstructure:"SHORT_URL_LENGTH"`
+	MaxRetry   int  `mapstructure:"SHORT_URL_MAX_RETRY"`
+	GrowLength bool `mapstructure:"SHORT_URL_GROW_LENGTH"`
+	MaxLength  int  `mapstructure:"SHORT_URL_MAX_LENGTH"`
+	// Generator is one of generator.Names().
+	Generator string `mapstructure:"SHORT_URL_GENERATOR"`
+	// Alphabet overrides the default alphabet of the generator if it is not empty.
+	Alphabet string `mapstructure:"SHORT_URL_ALPHABET"`
+	NodeID   int64  `mapstructure:"SHORT_URL_NODE_ID"`
+	// CounterStore is "redis", "mongo" or "memory".
+	CounterStore  string `mapstructure:"SHORT_URL_COUNTER_STORE"`
+	CounterSecret string `mapstructure:"SHORT_URL_COUNTER_SECRET"`
+	Deduplicate   bool   `mapstructure:"SHORT_URL_DEDUPLICATE"`
+}
+
+type URLConfig struct {
+	// NormalizeRules are the urlnorm rules applied to original urls before they are saved, empty disables normalization.
+	NormalizeRules []string `mapstructure:"URL_NORMALIZE_RULES"`
+	TrackingParams []string `mapstructure:"URL_TRACKING_PARAMS"`
+	// BlocklistFile and AllowlistFile are screener list files, empty disables them.
+	BlocklistFile      string        `mapstructure:"URL_BLOCKLIST_FILE"`
+	AllowlistFile      string        `mapstructure:"URL_ALLOWLIST_FILE"`
+	ListReloadInterval time.Duration `mapstructure:"URL_LIST_RELOAD_INTERVAL"`
+	BlockPrivateHosts  bool          `mapstructure:"URL_BLOCK_PRIVATE_HOSTS"`
+	// ResolveHosts resolves domain names to block the ones with private addresses, adding a DNS lookup
+	// to every creation and redirect.
+	ResolveHosts bool `mapstructure:"URL_RESOLVE_HOSTS"`
+}
+
+type RateLimitConfig struct {
+	// Store is "redis" or "memory".
+	Store string `mapstructure:"RATE_LIMIT_STORE"`
+	// A zero limit disables the rate limit.
+	CreateLimit    int           `mapstructure:"RATE_LIMIT_CREATE_LIMIT"`
+	CreateWindow   time.Duration `mapstructure:"RATE_LIMIT_CREATE_WINDOW"`
+	RedirectLimit  int           `mapstructure:"RATE_LIMIT_REDIRECT_LIMIT"`
+	RedirectWindow time.Duration `mapstructure:"RATE_LIMIT_REDIRECT_WINDOW"`
+}
+
+type AnalyticsConfig struct {
+	BufferSize    int           `mapstructure:"ANALYTICS_BUFFER_SIZE"`
+	BatchSize     int           `mapstructure:"ANALYTICS_BATCH_SIZE"`
+	FlushInterval time.Duration `mapstructure:"ANALYTICS_FLUSH_INTERVAL"`
+	IPSalt        string        `mapstructure:"ANALYTICS_IP_SALT"`
+}
+
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("PORT", 8080)
+	v.SetDefault("LOG_LEVEL", "info")
+	v.SetDefault("ERROR_FORMAT", "problem")
+	v.SetDefault("SERVER_READ_TIMEOUT", "10s")
+	v.SetDefault("SERVER_WRITE_TIMEOUT", "10s")
+	v.SetDefault("SERVER_IDLE_TIMEOUT", "60s")
+	v.SetDefault("SHUTDOWN_TIMEOUT", "30s")
+	v.SetDefault("SHUTDOWN_DELAY", "0s")
+	v.SetDefault("HEALTH_CHECK_TIMEOUT", "1s")
+	v.SetDefault("METRICS_ENABLED", true)
+	v.SetDefault("BASE_URL", "http://localhost:8080")
+	v.SetDefault("API_KEY_AUTH_ENABLED", true)
+	v.SetDefault("SERVER_TRUSTED_PROXIES", []string{})
+	v.SetDefault("TRACING_EXPORTER", "none")
+	v.SetDefault("TRACING_OTLP_ENDPOINT", "localhost:4318")
+	v.SetDefault("TRACING_OTLP_INSECURE", false)
+	v.SetDefault("TRACING_SAMPLE_RATIO", 1.0)
+	v.SetDefault("TRACING_SERVICE_NAME", "url-shortener")
+	v.SetDefault("PERSISTENT_STORE", "mongo")
+	v.SetDefault("MONGODB_URI", "mongodb://short_url@localhost:27017")
+	v.SetDefault("SQLITE_PATH", "url_shortener.db")
+	v.SetDefault("POSTGRES_URI", "postgres://short_url@localhost:5432/short_url?sslmode=disable")
+	v.SetDefault("REDIS_HOST", "localhost:6379")
+	v.SetDefault("CACHE_STORE", "redis")
+	v.SetDefault("CACHE_CLIENT_SIDE_TTL", "0s")
+	v.SetDefault("CACHE_LOCAL_ENABLED", false)
+	v.SetDefault("CACHE_LOCAL_SIZE", 10000)
+	v.SetDefault("CACHE_LOCAL_TTL", "10s")
+	v.SetDefault("CACHE_LOCK_ENABLED", false)
+	v.SetDefault("CACHE_POPULATE_ON_SAVE", true)
+	v.SetDefault("CACHE_WARMUP_SIZE", 1000)
+	v.SetDefault("CACHE_WARMUP_WINDOW", "24h")
+	v.SetDefault("BLOOM_FILTER_ENABLED", false)
+	v.SetDefault("BLOOM_FILTER_CAPACITY", 1000000)
+	v.SetDefault("BLOOM_FILTER_FP_RATE", 0.01)
+	v.SetDefault("BLOOM_FILTER_REBUILD_INTERVAL", "1h")
+	v.SetDefault("BLOOM_FILTER_REDIS", false)
+	v.SetDefault("SHORT_URL_LENGTH", 7)
+	v.SetDefault("SHORT_URL_MAX_RETRY", 3)
+	v.SetDefault("SHORT_URL_GROW_LENGTH", false)
+	v.SetDefault("SHORT_URL_MAX_LENGTH", 10)
+	v.SetDefault("SHORT_URL_GENERATOR", generator.BASE62)
+	v.SetDefault("SHORT_URL_ALPHABET", "")
+	v.SetDefault("SHORT_URL_NODE_ID", 0)
+	v.SetDefault("SHORT_URL_COUNTER_STORE", "redis")
+	v.SetDefault("SHORT_URL_COUNTER_SECRET", "")
+	v.SetDefault("SHORT_URL_DEDUPLICATE", false)
+	v.SetDefault("URL_NORMALIZE_RULES", urlnorm.DEFAULT_RULES)
+	v.SetDefault("URL_TRACKING_PARAMS", urlnorm.DEFAULT_TRACKING_PARAMS)
+	v.SetDefault("URL_BLOCKLIST_FILE", "")
+	v.SetDefault("URL_ALLOWLIST_FILE", "")
+	v.SetDefault("URL_LIST_RELOAD_INTERVAL", "30s")
+	v.SetDefault("URL_BLOCK_PRIVATE_HOSTS", true)
+	v.SetDefault("URL_RESOLVE_HOSTS", false)
+	v.SetDefault("RATE_LIMIT_STORE", "redis")
+	v.SetDefault("RATE_LIMIT_CREATE_LIMIT", 60)
+	v.SetDefault("RATE_LIMIT_CREATE_WINDOW", "1m")
+	v.SetDefault("RATE_LIMIT_REDIRECT_LIMIT", 600)
+	v.SetDefault("RATE_LIMIT_REDIRECT_WINDOW", "1m")
+	v.SetDefault("ANALYTICS_BUFFER_SIZE", 10000)
+	v.SetDefault("ANALYTICS_BATCH_SIZE", 500)
+	v.SetDefault("ANALYTICS_FLUSH_INTERVAL", "5s")
+	v.SetDefault("ANALYTICS_IP_SALT", "")
+}
+
+// Load reads the config file at path if it is not empty, the format is told by the extension, e.g. .yaml or .toml.
+// Environment variables override the file, which overrides the defaults. Unknown keys in the file are
+// an error, but the config is not validated.
+func Load(path string) (*Config, error) {
+	v := viper.New()
+	setDefaults(v)
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("read config file %s: %w", path, err)
+		}
+	}
+	v.AllowEmptyEnv(true)
+	v.AutomaticEnv()
+
+	// unknown keys of the file fail the load, so a misspelled key does not silently fall back to its default
+	var c Config
+	if err := v.UnmarshalExact(&c); err != nil {
+		return nil, fmt.Errorf("decode config: %w", err)
+	}
+	return &c, nil
+}
+
+// Validate reports every invalid key at once, so a deployment does not fail on them one by one.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key string, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+	positive := func(d time.Duration, key string) {
+		check(d > 0, key, "must be positive, got %s", d)
+	}
+	oneOf := func(value, key string, values ...string) {
+		for _, v := range values {
+			if value == v {
+				return
+			}
+		}
+		check(false, key, "must be one of %v, got %q", values, value)
+	}
+
+	s := c.Server
+	check(s.Port > 0 && s.Port <= 65535, "PORT", "must be between 1 and 65535, got %d", s.Port)
+	var level slog.Level
+	check(level.UnmarshalText([]byte(s.LogLevel)) == nil, "LOG_LEVEL", "must be debug, info, warn or error, got %q", s.LogLevel)
+	oneOf(s.ErrorFormat, "ERROR_FORMAT", string(myerror.ERROR_FORMAT_PROBLEM), string(myerror.ERROR_FORMAT_LEGACY))
+	positive(s.ReadTimeout, "SERVER_READ_TIMEOUT")
+	positive(s.WriteTimeout, "SERVER_WRITE_TIMEOUT")
+	positive(s.IdleTimeout, "SERVER_IDLE_TIMEOUT")
+	positive(s.ShutdownTimeout, "SHUTDOWN_TIMEOUT")
+	check(s.ShutdownDelay >= 0, "SHUTDOWN_DELAY", "must not be negative, got %s", s.ShutdownDelay)
+	positive(s.HealthCheckTimeout, "HEALTH_CHECK_TIMEOUT")
+	if err := validateURL(s.BaseURL, "http", "https"); err != nil {
+		check(false, "BASE_URL", "%v", err)
+	}
+	for _, proxy := range s.TrustedProxies {
+		_, prefixErr := netip.ParsePrefix(proxy)
+		_, addrErr := netip.ParseAddr(proxy)
+		check(prefixErr == nil || addrErr == nil, "SERVER_TRUSTED_PROXIES", "must be IPs or CIDRs, got %q", proxy)
+	}
+
+	t := c.Tracing
+	oneOf(t.Exporter, "TRACING_EXPORTER", tracing.EXPORTER_NONE, tracing.EXPORTER_OTLP)
+	if t.Exporter == tracing.EXPORTER_OTLP {
+		check(validateHostPort(t.OTLPEndpoint) == nil, "TRACING_OTLP_ENDPOINT", "must be host:port, got %q", t.OTLPEndpoint)
+		check(t.ServiceName != "", "TRACING_SERVICE_NAME", "is required")
+	}
+	check(t.SampleRatio >= 0 && t.SampleRatio <= 1, "TRACING_SAMPLE_RATIO", "must be between 0 and 1, got %v", t.SampleRatio)
+
+	st := c.Store
+	oneOf(st.PersistentStore, "PERSISTENT_STORE", "mongo", "sqlite", "postgres", "memory")
+	switch st.PersistentStore {
+	case "mongo":
+		if err := validateURL(st.MongoDBURI, "mongodb", "mongodb+srv"); err != nil {
+			check(false, "MONGODB_URI", "%v", err)
+		}
+	case "sqlite":
+		check(st.SQLitePath != "", "SQLITE_PATH", "is required")
+	case "postgres":
+		if err := validateURL(st.PostgresURI, "postgres", "postgresql"); err != nil {
+			check(false, "POSTGRES_URI", "%v", err)
+		}
+	}
+	if c.UsesRedis() {
+		check(validateHostPort(st.RedisHost) == nil, "REDIS_HOST", "must be host:port, got %q", st.RedisHost)
+	}
+
+	ca := c.Cache
+	oneOf(ca.Store, "CACHE_STORE", "redis", "memory")
+	check(ca.ClientSideTTL >= 0, "CACHE_CLIENT_SIDE_TTL", "must not be negative, got %s", ca.ClientSideTTL)
+	if ca.LocalEnabled {
+		check(ca.LocalSize > 0, "CACHE_LOCAL_SIZE", "must be positive, got %d", ca.LocalSize)
+		positive(ca.LocalTTL, "CACHE_LOCAL_TTL")
+	}
+	check(ca.WarmupSize >= 0, "CACHE_WARMUP_SIZE", "must not be negative, got %d", ca.WarmupSize)
+	if ca.WarmupSize > 0 {
+		positive(ca.WarmupWindow, "CACHE_WARMUP_WINDOW")
+	}
+
+	b := c.BloomFilter
+	if b.Enabled {
+		check(b.Capacity > 0, "BLOOM_FILTER_CAPACITY", "must be positive, got %d", b.Capacity)
+		check(b.FPRate > 0 && b.FPRate < 1, "BLOOM_FILTER_FP_RATE", "must be between 0 and 1 exclusive, got %v", b.FPRate)
+		positive(b.RebuildInterval, "BLOOM_FILTER_REBUILD_INTERVAL")
+	}
+
+	u := c.ShortURL
+	check(u.Length >= MIN_SHORT_URL_LENGTH && u.Length <= shorturl.MAX_ALIAS_LENGTH, "SHORT_URL_LENGTH",
+		"must be between %d and %d, got %d", MIN_SHORT_URL_LENGTH, shorturl.MAX_ALIAS_LENGTH, u.Length)
+	check(u.MaxRetry >= 0, "SHORT_URL_MAX_RETRY", "must not be negative, got %d", u.MaxRetry)
+	if u.GrowLength {
+		check(u.MaxLength >= u.Length && u.MaxLength <= shorturl.MAX_ALIAS_LENGTH, "SHORT_URL_MAX_LENGTH",
+			"must be between SHORT_URL_LENGTH and %d, got %d", shorturl.MAX_ALIAS_LENGTH, u.MaxLength)
+	}
+	oneOf(u.Generator, "SHORT_URL_GENERATOR", generator.Names()...)
+	alphabet := u.Alphabet
+	if alphabet != "" {
+		if err := generator.ValidateAlphabet(alphabet); err != nil {
+			check(false, "SHORT_URL_ALPHABET", "%v", err)
+		}
+	} else {
+		alphabet = generator.BASE62_ALPHABET
+	}
+	switch u.Generator {
+	case generator.SNOWFLAKE:
+		check(u.NodeID >= 0 && u.NodeID <= generator.MAX_SNOWFLAKE_NODE_ID, "SHORT_URL_NODE_ID",
+			"must be between 0 and %d, got %d", generator.MAX_SNOWFLAKE_NODE_ID, u.NodeID)
+		minLength := generator.SnowflakeLength(alphabet)
+		check(u.Length >= minLength, "SHORT_URL_LENGTH", "must be at least %d for the snowflake generator, got %d", minLength, u.Length)
+	case generator.COUNTER:
+		oneOf(u.CounterStore, "SHORT_URL_COUNTER_STORE", "redis", "mongo", "memory")
+		check(u.CounterStore != "mongo" || c.Store.PersistentStore == "mongo", "SHORT_URL_COUNTER_STORE", "mongo requires PERSISTENT_STORE mongo")
+		check(u.CounterStore != "memory" || c.Store.PersistentStore == "memory", "SHORT_URL_COUNTER_STORE", "memory requires PERSISTENT_STORE memory")
+		check(u.CounterSecret != "", "SHORT_URL_COUNTER_SECRET", "is required by the counter generator")
+	}
+
+	for _, rule := range c.URL.NormalizeRules {
+		oneOf(rule, "URL_NORMALIZE_RULES", urlnorm.Rules()...)
+	}
+	if c.URL.BlocklistFile != "" || c.URL.AllowlistFile != "" {
+		positive(c.URL.ListReloadInterval, "URL_LIST_RELOAD_INTERVAL")
+	}
+	check(!c.URL.ResolveHosts || c.URL.BlockPrivateHosts, "URL_RESOLVE_HOSTS", "requires URL_BLOCK_PRIVATE_HOSTS")
+
+	r := c.RateLimit
+	oneOf(r.Store, "RATE_LIMIT_STORE", "redis", "memory")
+	check(r.CreateLimit >= 0, "RATE_LIMIT_CREATE_LIMIT", "must not be negative, got %d", r.CreateLimit)
+	if r.CreateLimit > 0 {
+		positive(r.CreateWindow, "RATE_LIMIT_CREATE_WINDOW")
+	}
+	check(r.RedirectLimit >= 0, "RATE_LIMIT_REDIRECT_LIMIT", "must not be negative, got %d", r.RedirectLimit)
+	if r.RedirectLimit > 0 {
+		positive(r.RedirectWindow, "RATE_LIMIT_REDIRECT_WINDOW")
+	}
+
+	a := c.Analytics
+	check(a.BufferSize > 0, "ANALYTICS_BUFFER_SIZE", "must be positive, got %d", a.BufferSize)
+	check(a.BatchSize > 0 && a.BatchSize <= a.BufferSize, "ANALYTICS_BATCH_SIZE",
+		"must be between 1 and ANALYTICS_BUFFER_SIZE, got %d", a.BatchSize)
+	positive(a.FlushInterval, "ANALYTICS_FLUSH_INTERVAL")
+
+	return errors.Join(errs...)
+}
+
+// UsesRedis tells whether any feature is configured to connect to REDIS_HOST.
+func (c *Config) UsesRedis() bool {
+	return c.Cache.Store == "redis" || c.Cache.LockEnabled || c.RateLimit.Store == "redis" ||
+		(c.BloomFilter.Enabled && c.BloomFilter.Redis) ||
+		(c.ShortURL.Generator == generator.COUNTER && c.ShortURL.CounterStore == "redis")
+}
+
+// Redacted returns a copy without the passwords of the database URIs and the secrets.
+func (c *Config) Redacted() *Config {
+	r := *c
+	r.Store.MongoDBURI = redactURL(r.Store.MongoDBURI)
+	r.Store.PostgresURI = redactURL(r.Store.PostgresURI)
+	if r.Analytics.IPSalt != "" {
+		r.Analytics.IPSalt = REDACTED
+	}
+	if r.ShortURL.CounterSecret != "" {
+		r.ShortURL.CounterSecret = REDACTED
+	}
+	return &r
+}
+
+// Print writes a KEY=value line per key, in the format of an env file. Redact the config before printing it.
+func (c *Config) Print(w io.Writer) error {
+	groups := reflect.ValueOf(c).Elem()
+	for i := 0; i < groups.NumField(); i++ {
+		group := groups.Field(i)
+		for j := 0; j < group.NumField(); j++ {
+			key := group.Type().Field(j).Tag.Get("mapstructure")
+			value := group.Field(j).Interface()
+			if list, ok := value.([]string); ok {
+				value = strings.Join(list, ",")
+			}
+			if _, err := fmt.Fprintf(w, "%s=%v\n", key, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateURL(rawURL string, schemes ...string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("must be a URL: %w", err)
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme && u.Host != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be a URL with a host and the scheme %v, got %q", schemes, u.Redacted())
+}
+
+func validateHostPort(hostPort string) error {
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return err
+	}
+	if host == "" {
+		return errors.New("missing host")
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return fmt.Errorf("invalid port %s", port)
+	}
+	return nil
+}
+
+// redactURL hides the password of the user info and the password query param of a postgres URI.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return REDACTED
+	}
+	query := u.Query()
+	if query.Has("password") {
+		query.Set("password", REDACTED)
+		u.RawQuery = query.Encode()
+	}
+	return u.Redacted()
 }
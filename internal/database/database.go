@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type Dialect string
+
+const (
+	SQLITE   Dialect = "sqlite"
+	POSTGRES Dialect = "postgres"
+)
+
+// Open connects to the database and applies the pending migrations.
+func Open(c context.Context, dialect Dialect, dsn string) (*sql.DB, error) {
+	var db *sql.DB
+	var err error
+	switch dialect {
+	case SQLITE:
+		db, err = sql.Open("sqlite", sqliteDSN(dsn))
+	case POSTGRES:
+		db, err = sql.Open("pgx", dsn)
+	default:
+		return nil, fmt.Errorf("unknown sql dialect %s", dialect)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dialect == SQLITE {
+		// SQLite allows a single writer, serializing the connections avoids "database is locked" errors.
+		db.SetMaxOpenConns(1)
+	}
+
+	err = Migrate(c, db, dialect)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// sqliteDSN makes the driver write times in a format which is sortable and understood by SQLite date functions.
+func sqliteDSN(dsn string) string {
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + "_time_format=sqlite&_pragma=busy_timeout(5000)"
+}
+
+// Rebind converts the ? placeholders of the query to the placeholders of the dialect.
+func (d Dialect) Rebind(query string) string {
+	if d != POSTGRES {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func IsUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
+	return false
+}
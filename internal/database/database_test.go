@@ -0,0 +1,55 @@
+package database_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/WeiAnAn/url-shortener/internal/database"
+)
+
+func TestRebindPostgresPlaceholders(t *testing.T) {
+	query := database.POSTGRES.Rebind("SELECT * FROM short_urls WHERE short_url = ? AND expire_at > ?")
+
+	if query != "SELECT * FROM short_urls WHERE short_url = $1 AND expire_at > $2" {
+		t.Errorf("unexpected query %s", query)
+	}
+}
+
+func TestRebindSQLiteKeepPlaceholders(t *testing.T) {
+	query := database.SQLITE.Rebind("SELECT * FROM short_urls WHERE short_url = ?")
+
+	if query != "SELECT * FROM short_urls WHERE short_url = ?" {
+		t.Errorf("unexpected query %s", query)
+	}
+}
+
+func TestOpenApplyMigrationsOnce(t *testing.T) {
+	c := context.Background()
+	dsn := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := database.Open(c, database.SQLITE, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	db, err = database.Open(c, database.SQLITE, dsn)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer db.Close()
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&count)
+	if err != nil || count != 2 {
+		t.Errorf("expected 2 applied migrations, got %d %v", count, err)
+	}
+}
+
+func TestOpenUnknownDialect(t *testing.T) {
+	_, err := database.Open(context.Background(), database.Dialect("oracle"), "")
+	if err == nil {
+		t.Error("expected error for unknown dialect")
+	}
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"path"
+	"sort"
+	"time"
+)
+
+// MIGRATION_LOCK_ID is the Postgres advisory lock held while migrating,
+// so replicas starting at the same time do not apply a migration twice.
+const MIGRATION_LOCK_ID = 7245190311
+
+//go:embed migrations
+var migrations embed.FS
+
+// Migrate applies the migrations of the dialect which are not recorded in schema_migrations, in file name order.
+func Migrate(c context.Context, db *sql.DB, dialect Dialect) error {
+	conn, err := db.Conn(c)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if dialect == POSTGRES {
+		_, err = conn.ExecContext(c, "SELECT pg_advisory_lock($1)", MIGRATION_LOCK_ID)
+		if err != nil {
+			return err
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", MIGRATION_LOCK_ID)
+	}
+
+	_, err = conn.ExecContext(c, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version TEXT PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	dir := path.Join("migrations", string(dialect))
+	entries, err := migrations.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	for _, entry := range entries {
+		err = applyMigration(c, conn, dialect, dir, entry.Name())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyMigration(c context.Context, conn *sql.Conn, dialect Dialect, dir, version string) error {
+	var applied int
+	err := conn.QueryRowContext(c, dialect.Rebind("SELECT COUNT(*) FROM schema_migrations WHERE version = ?"), version).Scan(&applied)
+	if err != nil || applied > 0 {
+		return err
+	}
+
+	script, err := migrations.ReadFile(path.Join(dir, version))
+	if err != nil {
+		return err
+	}
+
+	tx, err := conn.BeginTx(c, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(c, string(script))
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(c, dialect.Rebind("INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)"), version, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
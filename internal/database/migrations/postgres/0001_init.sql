@@ -0,0 +1,24 @@
+CREATE TABLE short_urls (
+	short_url TEXT PRIMARY KEY,
+	original_url TEXT NOT NULL,
+	expire_at TIMESTAMPTZ NOT NULL,
+	owner_id TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE clicks (
+	id BIGSERIAL PRIMARY KEY,
+	short_url TEXT NOT NULL,
+	timestamp TIMESTAMPTZ NOT NULL,
+	referrer TEXT NOT NULL DEFAULT '',
+	user_agent TEXT NOT NULL DEFAULT '',
+	ip_hash TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX clicks_short_url_timestamp ON clicks (short_url, timestamp);
+
+CREATE TABLE api_keys (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	hash TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,3 @@
+ALTER TABLE short_urls ADD COLUMN url_hash TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX short_urls_owner_id_url_hash ON short_urls (owner_id, url_hash, expire_at);
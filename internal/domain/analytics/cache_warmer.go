@@ -0,0 +1,46 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+)
+
+// CacheWarmer loads the most clicked short urls into the cache, so they do not all miss the cache
+// at once after the cache is flushed or a new cache is deployed.
+type CacheWarmer struct {
+	store           Store
+	shortURLService shorturl.Service
+}
+
+func NewCacheWarmer(store Store, shortURLService shorturl.Service) *CacheWarmer {
+	return &CacheWarmer{store, shortURLService}
+}
+
+// WarmUp loads at most limit short urls which are most clicked since the time. It returns the number
+// of loaded short urls, expired, deleted and blocked short urls are skipped.
+func (w *CacheWarmer) WarmUp(c context.Context, since time.Time, limit int) (int, error) {
+	counts, err := w.store.TopShortURLs(c, since, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	warmed := 0
+	for _, count := range counts {
+		url, err := w.shortURLService.GetOriginalURL(c, count.ShortURL)
+		var blockedErr *myerror.BlockedURLError
+		if errors.As(err, &blockedErr) {
+			continue
+		}
+		if err != nil {
+			return warmed, err
+		}
+		if url != nil {
+			warmed++
+		}
+	}
+	return warmed, nil
+}
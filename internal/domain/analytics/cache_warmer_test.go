@@ -0,0 +1,65 @@
+package analytics_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/domain/analytics"
+	mock_analytics "github.com/WeiAnAn/url-shortener/internal/domain/analytics/mocks"
+	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
+	mock_shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url/mocks"
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+	"github.com/golang/mock/gomock"
+)
+
+func TestWarmUpLoadMostClickedShortURLs(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	store := mock_analytics.NewMockStore(mockCtrl)
+	ss := mock_shorturl.NewMockService(mockCtrl)
+	warmer := analytics.NewCacheWarmer(store, ss)
+
+	c := context.Background()
+	since := time.Now().Add(-24 * time.Hour)
+	store.EXPECT().TopShortURLs(c, since, 3).Return([]*analytics.ShortURLCount{
+		{ShortURL: "hot", Count: 10},
+		{ShortURL: "blocked", Count: 8},
+		{ShortURL: "expired", Count: 5},
+	}, nil)
+	gomock.InOrder(
+		ss.EXPECT().GetOriginalURL(c, "hot").Return(&shorturl.ShortURL{ShortURL: "hot", OriginalURL: "https://example.com/"}, nil),
+		ss.EXPECT().GetOriginalURL(c, "blocked").Return(nil, myerror.NewBlockedURLError("https://phishing.example/", "phishing.example is in the blocklist")),
+		ss.EXPECT().GetOriginalURL(c, "expired").Return(nil, nil),
+	)
+
+	warmed, err := warmer.WarmUp(c, since, 3)
+	if err != nil || warmed != 1 {
+		t.Errorf("expected 1 warmed short url, got %d %v", warmed, err)
+	}
+}
+
+func TestWarmUpStopOnError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	store := mock_analytics.NewMockStore(mockCtrl)
+	ss := mock_shorturl.NewMockService(mockCtrl)
+	warmer := analytics.NewCacheWarmer(store, ss)
+
+	c := context.Background()
+	since := time.Now()
+	mockErr := errors.New("error")
+	store.EXPECT().TopShortURLs(c, since, 10).Return([]*analytics.ShortURLCount{
+		{ShortURL: "first", Count: 10},
+		{ShortURL: "second", Count: 5},
+	}, nil)
+	ss.EXPECT().GetOriginalURL(c, "first").Return(nil, mockErr)
+
+	_, err := warmer.WarmUp(c, since, 10)
+	if err != mockErr {
+		t.Errorf("expected error, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package analytics
+
+import (
+	"net/http"
+	"time"
+
+	apikey "github.com/WeiAnAn/url-shortener/internal/domain/api_key"
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+	"github.com/gin-gonic/gin"
+)
+
+// MAX_BUCKETS limits how many time buckets a single stats request can span.
+const MAX_BUCKETS = 24 * 31
+
+type Controller struct {
+	service Service
+}
+
+func NewController(service Service) *Controller {
+	return &Controller{service}
+}
+
+type StatsParams struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+type StatsQuery struct {
+	Interval Interval  `form:"interval" binding:"omitempty,oneof=hour day"`
+	From     time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+func (c *Controller) GetStats(ctx *gin.Context) {
+	var params StatsParams
+	err := ctx.ShouldBindUri(&params)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var query StatsQuery
+	err = ctx.ShouldBindQuery(&query)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if query.Interval == "" {
+		query.Interval = DAY
+	}
+	if query.To.IsZero() {
+		query.To = time.Now()
+	}
+	if query.From.IsZero() {
+		if query.Interval == HOUR {
+			query.From = query.To.Add(-24 * time.Hour)
+		} else {
+			query.From = query.To.AddDate(0, 0, -30)
+		}
+	}
+	if !query.From.Before(query.To) {
+		ctx.Error(myerror.NewValidationError("from", query.From.Format(time.RFC3339), "from must be before to"))
+		return
+	}
+	if query.To.Sub(query.From) > MAX_BUCKETS*query.Interval.Duration() {
+		ctx.Error(myerror.NewValidationError("from", query.From.Format(time.RFC3339), "time range is too large"))
+		return
+	}
+
+	stats, err := c.service.GetStats(ctx, apikey.OwnerID(ctx), params.ID, query.Interval, query.From, query.To)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,112 @@
+package analytics_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/domain/analytics"
+	mock_analytics "github.com/WeiAnAn/url-shortener/internal/domain/analytics/mocks"
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang/mock/gomock"
+)
+
+func TestGetStatsResponseStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockService := mock_analytics.NewMockService(ctrl)
+	controller := analytics.NewController(mockService)
+	w := httptest.NewRecorder()
+	from, _ := time.Parse(time.RFC3339, "2023-05-01T00:00:00Z")
+	to, _ := time.Parse(time.RFC3339, "2023-05-02T00:00:00Z")
+	ctx := createStatsContext(w, "aaaaaaa", url.Values{
+		"interval": {"hour"},
+		"from":     {from.Format(time.RFC3339)},
+		"to":       {to.Format(time.RFC3339)},
+	})
+
+	mockService.EXPECT().
+		GetStats(ctx, "", "aaaaaaa", analytics.HOUR, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ any, _, _ string, _ analytics.Interval, f, t2 time.Time) (*analytics.Stats, error) {
+			if !f.Equal(from) || !t2.Equal(to) {
+				t.Errorf("unexpected range %v - %v", f, t2)
+			}
+			return &analytics.Stats{TotalClicks: 5, Interval: analytics.HOUR}, nil
+		})
+
+	controller.GetStats(ctx)
+
+	var resBody struct {
+		TotalClicks int64  `json:"totalClicks"`
+		Interval    string `json:"interval"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resBody)
+	if w.Code != http.StatusOK || resBody.TotalClicks != 5 || resBody.Interval != "hour" {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetStatsUseDayIntervalByDefault(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockService := mock_analytics.NewMockService(ctrl)
+	controller := analytics.NewController(mockService)
+	ctx := createStatsContext(httptest.NewRecorder(), "aaaaaaa", url.Values{})
+
+	mockService.EXPECT().
+		GetStats(ctx, "", "aaaaaaa", analytics.DAY, gomock.Any(), gomock.Any()).
+		Return(&analytics.Stats{}, nil)
+
+	controller.GetStats(ctx)
+}
+
+func TestGetStatsResponseBadRequestIfIntervalIsInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	controller := analytics.NewController(mock_analytics.NewMockService(ctrl))
+	ctx := createStatsContext(httptest.NewRecorder(), "aaaaaaa", url.Values{"interval": {"week"}})
+
+	controller.GetStats(ctx)
+
+	if len(ctx.Errors) != 1 {
+		t.Fatal("context errors size is not equal to one")
+	}
+	if _, ok := ctx.Errors[0].Err.(validator.ValidationErrors); !ok {
+		t.Error("context error is not ValidationErrors")
+	}
+}
+
+func TestGetStatsResponseBadRequestIfRangeIsTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	controller := analytics.NewController(mock_analytics.NewMockService(ctrl))
+	ctx := createStatsContext(httptest.NewRecorder(), "aaaaaaa", url.Values{
+		"interval": {"hour"},
+		"from":     {"2023-01-01T00:00:00Z"},
+		"to":       {"2023-05-01T00:00:00Z"},
+	})
+
+	controller.GetStats(ctx)
+
+	if len(ctx.Errors) != 1 {
+		t.Fatal("context errors size is not equal to one")
+	}
+	if _, ok := ctx.Errors[0].Err.(*myerror.ValidationError); !ok {
+		t.Error("context error is not ValidationError")
+	}
+}
+
+func createStatsContext(w *httptest.ResponseRecorder, id string, query url.Values) *gin.Context {
+	gin.SetMode(gin.TestMode)
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/v1/urls/"+id+"/stats?"+query.Encode(), nil)
+	ctx.Params = []gin.Param{{Key: "id", Value: id}}
+
+	return ctx
+}
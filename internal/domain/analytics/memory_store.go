@@ -0,0 +1,112 @@
+package analytics
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps click events in process, it is meant for local development and tests.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	events map[string][]ClickEvent
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{events: make(map[string][]ClickEvent)}
+}
+
+func (m *MemoryStore) SaveMany(c context.Context, events []*ClickEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range events {
+		m.events[e.ShortURL] = append(m.events[e.ShortURL], *e)
+	}
+	return nil
+}
+
+func (m *MemoryStore) CountByShortURL(c context.Context, shortURL string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return int64(len(m.events[shortURL])), nil
+}
+
+func (m *MemoryStore) CountByInterval(c context.Context, shortURL string, interval Interval, from, to time.Time) ([]*BucketCount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	buckets := make(map[time.Time]int64)
+	for _, e := range m.events[shortURL] {
+		if e.Timestamp.Before(from) || !e.Timestamp.Before(to) {
+			continue
+		}
+		buckets[e.Timestamp.UTC().Truncate(interval.Duration())]++
+	}
+
+	counts := make([]*BucketCount, 0, len(buckets))
+	for t, count := range buckets {
+		counts = append(counts, &BucketCount{t, count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Time.Before(counts[j].Time)
+	})
+	return counts, nil
+}
+
+func (m *MemoryStore) TopReferrers(c context.Context, shortURL string, limit int) ([]*ReferrerCount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	referrers := make(map[string]int64)
+	for _, e := range m.events[shortURL] {
+		if e.Referrer != "" {
+			referrers[e.Referrer]++
+		}
+	}
+
+	counts := make([]*ReferrerCount, 0, len(referrers))
+	for referrer, count := range referrers {
+		counts = append(counts, &ReferrerCount{referrer, count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Referrer < counts[j].Referrer
+	})
+	if len(counts) > limit {
+		counts = counts[:limit]
+	}
+	return counts, nil
+}
+
+func (m *MemoryStore) TopShortURLs(c context.Context, since time.Time, limit int) ([]*ShortURLCount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := make([]*ShortURLCount, 0, len(m.events))
+	for shortURL, events := range m.events {
+		var count int64
+		for _, e := range events {
+			if !e.Timestamp.Before(since) {
+				count++
+			}
+		}
+		if count > 0 {
+			counts = append(counts, &ShortURLCount{shortURL, count})
+		}
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].ShortURL < counts[j].ShortURL
+	})
+	if len(counts) > limit {
+		counts = counts[:limit]
+	}
+	return counts, nil
+}
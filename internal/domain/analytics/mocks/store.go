@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/domain/analytics/store.go
+
+// Package mock_analytics is a generated GoMock package.
+package mock_analytics
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	analytics "github.com/WeiAnAn/url-shortener/internal/domain/analytics"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockStore is a mock of Store interface.
+type MockStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreMockRecorder
+}
+
+// MockStoreMockRecorder is the mock recorder for MockStore.
+type MockStoreMockRecorder struct {
+	mock *MockStore
+}
+
+// NewMockStore creates a new mock instance.
+func NewMockStore(ctrl *gomock.Controller) *MockStore {
+	mock := &MockStore{ctrl: ctrl}
+	mock.recorder = &MockStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStore) EXPECT() *MockStoreMockRecorder {
+	return m.recorder
+}
+
+// CountByInterval mocks base method.
+func (m *MockStore) CountByInterval(c context.Context, shortURL string, interval analytics.Interval, from, to time.Time) ([]*analytics.BucketCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByInterval", c, shortURL, interval, from, to)
+	ret0, _ := ret[0].([]*analytics.BucketCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByInterval indicates an expected call of CountByInterval.
+func (mr *MockStoreMockRecorder) CountByInterval(c, shortURL, interval, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByInterval", reflect.TypeOf((*MockStore)(nil).CountByInterval), c, shortURL, interval, from, to)
+}
+
+// CountByShortURL mocks base method.
+func (m *MockStore) CountByShortURL(c context.Context, shortURL string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByShortURL", c, shortURL)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByShortURL indicates an expected call of CountByShortURL.
+func (mr *MockStoreMockRecorder) CountByShortURL(c, shortURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByShortURL", reflect.TypeOf((*MockStore)(nil).CountByShortURL), c, shortURL)
+}
+
+// SaveMany mocks base method.
+func (m *MockStore) SaveMany(c context.Context, events []*analytics.ClickEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveMany", c, events)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveMany indicates an expected call of SaveMany.
+func (mr *MockStoreMockRecorder) SaveMany(c, events interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMany", reflect.TypeOf((*MockStore)(nil).SaveMany), c, events)
+}
+
+// TopReferrers mocks base method.
+func (m *MockStore) TopReferrers(c context.Context, shortURL string, limit int) ([]*analytics.ReferrerCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopReferrers", c, shortURL, limit)
+	ret0, _ := ret[0].([]*analytics.ReferrerCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TopReferrers indicates an expected call of TopReferrers.
+func (mr *MockStoreMockRecorder) TopReferrers(c, shortURL, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopReferrers", reflect.TypeOf((*MockStore)(nil).TopReferrers), c, shortURL, limit)
+}
+
+// TopShortURLs mocks base method.
+func (m *MockStore) TopShortURLs(c context.Context, since time.Time, limit int) ([]*analytics.ShortURLCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopShortURLs", c, since, limit)
+	ret0, _ := ret[0].([]*analytics.ShortURLCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TopShortURLs indicates an expected call of TopShortURLs.
+func (mr *MockStoreMockRecorder) TopShortURLs(c, since, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopShortURLs", reflect.TypeOf((*MockStore)(nil).TopShortURLs), c, since, limit)
+}
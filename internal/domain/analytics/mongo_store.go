@@ -0,0 +1,152 @@
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const COLLECTION_NAME = "clicks"
+
+type MongoStore struct {
+	client   *mongo.Client
+	database string
+}
+
+type ClickEventDocument struct {
+	ShortURL  string    `bson:"short_url"`
+	Timestamp time.Time `bson:"timestamp"`
+	Referrer  string    `bson:"referrer"`
+	UserAgent string    `bson:"user_agent"`
+	IPHash    string    `bson:"ip_hash"`
+}
+
+func NewMongoStore(c *mongo.Client, d string) *MongoStore {
+	index := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "short_url", Value: 1},
+			bson.E{Key: "timestamp", Value: 1},
+		},
+	}
+	c.Database(d).Collection(COLLECTION_NAME).Indexes().CreateOne(context.Background(), index)
+	return &MongoStore{c, d}
+}
+
+func (m *MongoStore) SaveMany(c context.Context, events []*ClickEvent) error {
+	docs := make([]interface{}, len(events))
+	for i, e := range events {
+		docs[i] = ClickEventDocument{e.ShortURL, e.Timestamp, e.Referrer, e.UserAgent, e.IPHash}
+	}
+
+	_, err := m.client.Database(m.database).Collection(COLLECTION_NAME).InsertMany(c, docs, options.InsertMany().SetOrdered(false))
+
+	return err
+}
+
+func (m *MongoStore) CountByShortURL(c context.Context, shortURL string) (int64, error) {
+	return m.client.Database(m.database).Collection(COLLECTION_NAME).CountDocuments(c, bson.M{
+		"short_url": shortURL,
+	})
+}
+
+func (m *MongoStore) CountByInterval(c context.Context, shortURL string, interval Interval, from, to time.Time) ([]*BucketCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"short_url": shortURL,
+			"timestamp": bson.M{"$gte": from, "$lt": to},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"$dateTrunc": bson.M{
+				"date": "$timestamp",
+				"unit": string(interval),
+			}},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	var docs []struct {
+		Time  time.Time `bson:"_id"`
+		Count int64     `bson:"count"`
+	}
+	err := m.aggregate(c, pipeline, &docs)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]*BucketCount, len(docs))
+	for i, doc := range docs {
+		counts[i] = &BucketCount{doc.Time, doc.Count}
+	}
+	return counts, nil
+}
+
+func (m *MongoStore) TopReferrers(c context.Context, shortURL string, limit int) ([]*ReferrerCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"short_url": shortURL,
+			"referrer":  bson.M{"$ne": ""},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$referrer",
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	var docs []struct {
+		Referrer string `bson:"_id"`
+		Count    int64  `bson:"count"`
+	}
+	err := m.aggregate(c, pipeline, &docs)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]*ReferrerCount, len(docs))
+	for i, doc := range docs {
+		counts[i] = &ReferrerCount{doc.Referrer, doc.Count}
+	}
+	return counts, nil
+}
+
+func (m *MongoStore) TopShortURLs(c context.Context, since time.Time, limit int) ([]*ShortURLCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"timestamp": bson.M{"$gte": since},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$short_url",
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	var docs []struct {
+		ShortURL string `bson:"_id"`
+		Count    int64  `bson:"count"`
+	}
+	err := m.aggregate(c, pipeline, &docs)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]*ShortURLCount, len(docs))
+	for i, doc := range docs {
+		counts[i] = &ShortURLCount{doc.ShortURL, doc.Count}
+	}
+	return counts, nil
+}
+
+func (m *MongoStore) aggregate(c context.Context, pipeline mongo.Pipeline, result interface{}) error {
+	cursor, err := m.client.Database(m.database).Collection(COLLECTION_NAME).Aggregate(c, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.All(c, result)
+}
@@ -0,0 +1,119 @@
+package analytics
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const FLUSH_TIMEOUT = 10 * time.Second
+
+type Recorder interface {
+	Record(event *ClickEvent)
+}
+
+// BufferedRecorder buffers click events in memory and saves them to the store in batches,
+// so recording a click never waits for the store.
+type BufferedRecorder struct {
+	store         Store
+	events        chan *ClickEvent
+	batchSize     int
+	flushInterval time.Duration
+	done          chan struct{}
+
+	// mu guards closed, so events recorded by requests outliving the shutdown are dropped
+	// instead of sent on the closed channel.
+	mu     sync.RWMutex
+	closed bool
+}
+
+func NewBufferedRecorder(store Store, bufferSize, batchSize int, flushInterval time.Duration) *BufferedRecorder {
+	return &BufferedRecorder{
+		store:         store,
+		events:        make(chan *ClickEvent, bufferSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		done:          make(chan struct{}),
+	}
+}
+
+func (r *BufferedRecorder) Start() {
+	go r.run()
+}
+
+// Record drops the event if the buffer is full or the recorder is closed.
+func (r *BufferedRecorder) Record(event *ClickEvent) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		slog.Warn("click recorder is closed, dropping click event", slog.String("short_url", event.ShortURL))
+		return
+	}
+
+	select {
+	case r.events <- event:
+	default:
+		slog.Warn("click event buffer is full, dropping click event", slog.String("short_url", event.ShortURL))
+	}
+}
+
+// Close flushes the buffered events and stops the recorder.
+// Events recorded after Close are dropped.
+func (r *BufferedRecorder) Close(c context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.events)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-c.Done():
+		return c.Err()
+	}
+}
+
+func (r *BufferedRecorder) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*ClickEvent, 0, r.batchSize)
+	for {
+		select {
+		case event, ok := <-r.events:
+			if !ok {
+				r.flush(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= r.batchSize {
+				r.flush(batch)
+				batch = make([]*ClickEvent, 0, r.batchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				r.flush(batch)
+				batch = make([]*ClickEvent, 0, r.batchSize)
+			}
+		}
+	}
+}
+
+func (r *BufferedRecorder) flush(batch []*ClickEvent) {
+	if len(batch) == 0 {
+		return
+	}
+
+	c, cancel := context.WithTimeout(context.Background(), FLUSH_TIMEOUT)
+	defer cancel()
+
+	err := r.store.SaveMany(c, batch)
+	if err != nil {
+		slog.Error("save click events failed", slog.Int("click_events", len(batch)), slog.Any("error", err))
+	}
+}
@@ -0,0 +1,111 @@
+package analytics_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/domain/analytics"
+	mock_analytics "github.com/WeiAnAn/url-shortener/internal/domain/analytics/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+func TestBufferedRecorderFlushWhenBatchIsFull(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mock_analytics.NewMockStore(ctrl)
+	recorder := analytics.NewBufferedRecorder(store, 10, 2, time.Hour)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	store.EXPECT().SaveMany(gomock.Any(), gomock.Len(2)).Do(func(context.Context, []*analytics.ClickEvent) {
+		wg.Done()
+	}).Return(nil)
+
+	recorder.Start()
+	recorder.Record(&analytics.ClickEvent{ShortURL: "aaaaaaa"})
+	recorder.Record(&analytics.ClickEvent{ShortURL: "bbbbbbb"})
+	wg.Wait()
+
+	if err := recorder.Close(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBufferedRecorderFlushOnInterval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mock_analytics.NewMockStore(ctrl)
+	recorder := analytics.NewBufferedRecorder(store, 10, 100, 10*time.Millisecond)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	store.EXPECT().SaveMany(gomock.Any(), gomock.Len(1)).Do(func(context.Context, []*analytics.ClickEvent) {
+		wg.Done()
+	}).Return(nil)
+
+	recorder.Start()
+	recorder.Record(&analytics.ClickEvent{ShortURL: "aaaaaaa"})
+	wg.Wait()
+
+	if err := recorder.Close(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBufferedRecorderFlushRemainingEventsOnClose(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mock_analytics.NewMockStore(ctrl)
+	recorder := analytics.NewBufferedRecorder(store, 10, 100, time.Hour)
+
+	store.EXPECT().SaveMany(gomock.Any(), gomock.Len(3)).Return(nil)
+
+	recorder.Start()
+	for i := 0; i < 3; i++ {
+		recorder.Record(&analytics.ClickEvent{ShortURL: "aaaaaaa"})
+	}
+
+	if err := recorder.Close(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBufferedRecorderDropEventsWhenBufferIsFull(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mock_analytics.NewMockStore(ctrl)
+	recorder := analytics.NewBufferedRecorder(store, 2, 100, time.Hour)
+
+	for i := 0; i < 5; i++ {
+		recorder.Record(&analytics.ClickEvent{ShortURL: "aaaaaaa"})
+	}
+
+	store.EXPECT().SaveMany(gomock.Any(), gomock.Len(2)).Return(nil)
+	recorder.Start()
+
+	if err := recorder.Close(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBufferedRecorderDropEventsRecordedAfterClose(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mock_analytics.NewMockStore(ctrl)
+	recorder := analytics.NewBufferedRecorder(store, 10, 100, time.Hour)
+
+	store.EXPECT().SaveMany(gomock.Any(), gomock.Len(1)).Return(nil)
+
+	recorder.Start()
+	recorder.Record(&analytics.ClickEvent{ShortURL: "aaaaaaa"})
+	if err := recorder.Close(context.Background()); err != nil {
+		t.Error(err)
+	}
+
+	recorder.Record(&analytics.ClickEvent{ShortURL: "bbbbbbb"})
+	if err := recorder.Close(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,59 @@
+package analytics
+
+import (
+	"context"
+	"time"
+
+	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
+)
+
+const TOP_REFERRERS_LIMIT = 10
+
+type Stats struct {
+	TotalClicks  int64            `json:"totalClicks"`
+	Interval     Interval         `json:"interval"`
+	Clicks       []*BucketCount   `json:"clicks"`
+	TopReferrers []*ReferrerCount `json:"topReferrers"`
+}
+
+type Service interface {
+	GetStats(c context.Context, ownerID, shortURL string, interval Interval, from, to time.Time) (*Stats, error)
+}
+
+type service struct {
+	store           Store
+	shortURLService shorturl.Service
+}
+
+func NewService(store Store, shortURLService shorturl.Service) *service {
+	return &service{store, shortURLService}
+}
+
+func (s *service) GetStats(c context.Context, ownerID, shortURL string, interval Interval, from, to time.Time) (*Stats, error) {
+	_, err := s.shortURLService.GetShortURL(c, ownerID, shortURL)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.store.CountByShortURL(c, shortURL)
+	if err != nil {
+		return nil, err
+	}
+
+	clicks, err := s.store.CountByInterval(c, shortURL, interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	referrers, err := s.store.TopReferrers(c, shortURL, TOP_REFERRERS_LIMIT)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stats{
+		TotalClicks:  total,
+		Interval:     interval,
+		Clicks:       clicks,
+		TopReferrers: referrers,
+	}, nil
+}
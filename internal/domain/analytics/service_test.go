@@ -0,0 +1,77 @@
+package analytics_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/domain/analytics"
+	mock_analytics "github.com/WeiAnAn/url-shortener/internal/domain/analytics/mocks"
+	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
+	mock_shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url/mocks"
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+	"github.com/golang/mock/gomock"
+)
+
+func TestGetStatsReturnStatsFromStore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store, shortURLService, service := createService(ctrl)
+
+	c := context.Background()
+	to := time.Now()
+	from := to.Add(-24 * time.Hour)
+	clicks := []*analytics.BucketCount{{Time: from, Count: 2}}
+	referrers := []*analytics.ReferrerCount{{Referrer: "https://example.com/", Count: 2}}
+	shortURLService.EXPECT().GetShortURL(c, "owner", "aaaaaaa").Return(&shorturl.ShortURLWithExpireTime{}, nil)
+	store.EXPECT().CountByShortURL(c, "aaaaaaa").Return(int64(3), nil)
+	store.EXPECT().CountByInterval(c, "aaaaaaa", analytics.HOUR, from, to).Return(clicks, nil)
+	store.EXPECT().TopReferrers(c, "aaaaaaa", analytics.TOP_REFERRERS_LIMIT).Return(referrers, nil)
+
+	stats, err := service.GetStats(c, "owner", "aaaaaaa", analytics.HOUR, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalClicks != 3 || stats.Interval != analytics.HOUR || len(stats.Clicks) != 1 || len(stats.TopReferrers) != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestGetStatsReturnErrorIfShortURLNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	_, shortURLService, service := createService(ctrl)
+
+	c := context.Background()
+	mockErr := myerror.NewNotFoundError("short url", "aaaaaaa")
+	shortURLService.EXPECT().GetShortURL(c, "owner", "aaaaaaa").Return(nil, mockErr)
+
+	_, err := service.GetStats(c, "owner", "aaaaaaa", analytics.DAY, time.Now(), time.Now())
+	if err != mockErr {
+		t.Errorf("expect NotFoundError, got %v", err)
+	}
+}
+
+func TestGetStatsReturnErrorIfStoreReturnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store, shortURLService, service := createService(ctrl)
+
+	c := context.Background()
+	mockErr := errors.New("error")
+	shortURLService.EXPECT().GetShortURL(c, "owner", "aaaaaaa").Return(&shorturl.ShortURLWithExpireTime{}, nil)
+	store.EXPECT().CountByShortURL(c, "aaaaaaa").Return(int64(0), mockErr)
+
+	_, err := service.GetStats(c, "owner", "aaaaaaa", analytics.DAY, time.Now(), time.Now())
+	if err != mockErr {
+		t.Fail()
+	}
+}
+
+func createService(ctrl *gomock.Controller) (*mock_analytics.MockStore, *mock_shorturl.MockService, analytics.Service) {
+	store := mock_analytics.NewMockStore(ctrl)
+	shortURLService := mock_shorturl.NewMockService(ctrl)
+	service := analytics.NewService(store, shortURLService)
+	return store, shortURLService, service
+}
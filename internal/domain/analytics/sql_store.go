@@ -0,0 +1,133 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/database"
+)
+
+// SQLStore stores click events in the clicks table created by the database migrations.
+type SQLStore struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+func NewSQLStore(db *sql.DB, dialect database.Dialect) *SQLStore {
+	return &SQLStore{db, dialect}
+}
+
+func (s *SQLStore) SaveMany(c context.Context, events []*ClickEvent) error {
+	tx, err := s.db.BeginTx(c, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(c, s.dialect.Rebind("INSERT INTO clicks (short_url, timestamp, referrer, user_agent, ip_hash) VALUES (?, ?, ?, ?, ?)"))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range events {
+		_, err = stmt.ExecContext(c, e.ShortURL, e.Timestamp.UTC(), e.Referrer, e.UserAgent, e.IPHash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) CountByShortURL(c context.Context, shortURL string) (int64, error) {
+	var count int64
+	err := s.db.QueryRowContext(c, s.dialect.Rebind("SELECT COUNT(*) FROM clicks WHERE short_url = ?"), shortURL).Scan(&count)
+	return count, err
+}
+
+func (s *SQLStore) CountByInterval(c context.Context, shortURL string, interval Interval, from, to time.Time) ([]*BucketCount, error) {
+	bucket, err := s.truncateTimestamp(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + bucket + " AS bucket, COUNT(*) FROM clicks WHERE short_url = ? AND timestamp >= ? AND timestamp < ? GROUP BY bucket ORDER BY bucket"
+	rows, err := s.db.QueryContext(c, s.dialect.Rebind(query), shortURL, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []*BucketCount{}
+	for rows.Next() {
+		var bucket string
+		var count int64
+		err = rows.Scan(&bucket, &count)
+		if err != nil {
+			return nil, err
+		}
+		t, err := time.Parse(time.RFC3339, bucket)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, &BucketCount{t, count})
+	}
+	return counts, rows.Err()
+}
+
+func (s *SQLStore) TopReferrers(c context.Context, shortURL string, limit int) ([]*ReferrerCount, error) {
+	query := "SELECT referrer, COUNT(*) AS count FROM clicks WHERE short_url = ? AND referrer <> '' GROUP BY referrer ORDER BY count DESC, referrer LIMIT ?"
+	rows, err := s.db.QueryContext(c, s.dialect.Rebind(query), shortURL, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []*ReferrerCount{}
+	for rows.Next() {
+		var count ReferrerCount
+		err = rows.Scan(&count.Referrer, &count.Count)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, &count)
+	}
+	return counts, rows.Err()
+}
+
+func (s *SQLStore) TopShortURLs(c context.Context, since time.Time, limit int) ([]*ShortURLCount, error) {
+	query := "SELECT short_url, COUNT(*) AS count FROM clicks WHERE timestamp >= ? GROUP BY short_url ORDER BY count DESC, short_url LIMIT ?"
+	rows, err := s.db.QueryContext(c, s.dialect.Rebind(query), since.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []*ShortURLCount{}
+	for rows.Next() {
+		var count ShortURLCount
+		err = rows.Scan(&count.ShortURL, &count.Count)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, &count)
+	}
+	return counts, rows.Err()
+}
+
+// truncateTimestamp returns the expression which truncates the timestamp to the interval in RFC3339 format.
+func (s *SQLStore) truncateTimestamp(interval Interval) (string, error) {
+	switch s.dialect {
+	case database.SQLITE:
+		if interval == HOUR {
+			return "strftime('%Y-%m-%dT%H:00:00Z', timestamp)", nil
+		}
+		return "strftime('%Y-%m-%dT00:00:00Z', timestamp)", nil
+	case database.POSTGRES:
+		return fmt.Sprintf(`to_char(date_trunc('%s', timestamp AT TIME ZONE 'UTC'), 'YYYY-MM-DD"T"HH24:MI:SS"Z"')`, interval), nil
+	}
+	return "", fmt.Errorf("unknown sql dialect %s", s.dialect)
+}
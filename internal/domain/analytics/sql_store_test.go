@@ -0,0 +1,99 @@
+package analytics_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/database"
+	"github.com/WeiAnAn/url-shortener/internal/domain/analytics"
+)
+
+func createSQLStore(t *testing.T) *analytics.SQLStore {
+	db, err := database.Open(context.Background(), database.SQLITE, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return analytics.NewSQLStore(db, database.SQLITE)
+}
+
+func TestSQLStoreCountByInterval(t *testing.T) {
+	store := createSQLStore(t)
+	c := context.Background()
+	base := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	store.SaveMany(c, []*analytics.ClickEvent{
+		{ShortURL: "short", Timestamp: base.Add(5 * time.Minute)},
+		{ShortURL: "short", Timestamp: base.Add(30 * time.Minute)},
+		{ShortURL: "short", Timestamp: base.Add(90 * time.Minute)},
+		{ShortURL: "short", Timestamp: base.Add(5 * time.Hour)},
+		{ShortURL: "other", Timestamp: base.Add(5 * time.Minute)},
+	})
+
+	counts, err := store.CountByInterval(c, "short", analytics.HOUR, base, base.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 2 || !counts[0].Time.Equal(base) || counts[0].Count != 2 ||
+		!counts[1].Time.Equal(base.Add(time.Hour)) || counts[1].Count != 1 {
+		t.Errorf("unexpected counts %v %v", counts[0], counts[1])
+	}
+
+	counts, err = store.CountByInterval(c, "short", analytics.DAY, base.Add(-24*time.Hour), base.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 1 || !counts[0].Time.Equal(base.Truncate(24*time.Hour)) || counts[0].Count != 4 {
+		t.Errorf("unexpected day counts %v", counts)
+	}
+
+	total, err := store.CountByShortURL(c, "short")
+	if err != nil || total != 4 {
+		t.Errorf("expected 4 clicks, got %d %v", total, err)
+	}
+}
+
+func TestSQLStoreTopReferrers(t *testing.T) {
+	store := createSQLStore(t)
+	c := context.Background()
+	now := time.Now()
+	store.SaveMany(c, []*analytics.ClickEvent{
+		{ShortURL: "short", Timestamp: now, Referrer: "https://b.com/"},
+		{ShortURL: "short", Timestamp: now, Referrer: "https://a.com/"},
+		{ShortURL: "short", Timestamp: now, Referrer: "https://b.com/"},
+		{ShortURL: "short", Timestamp: now, Referrer: "https://c.com/"},
+		{ShortURL: "short", Timestamp: now},
+	})
+
+	referrers, err := store.TopReferrers(c, "short", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(referrers) != 2 || referrers[0].Referrer != "https://b.com/" || referrers[0].Count != 2 ||
+		referrers[1].Referrer != "https://a.com/" {
+		t.Errorf("unexpected referrers %v", referrers)
+	}
+}
+
+func TestSQLStoreTopShortURLs(t *testing.T) {
+	store := createSQLStore(t)
+	c := context.Background()
+	now := time.Now()
+	store.SaveMany(c, []*analytics.ClickEvent{
+		{ShortURL: "hot", Timestamp: now},
+		{ShortURL: "hot", Timestamp: now},
+		{ShortURL: "warm", Timestamp: now},
+		{ShortURL: "old", Timestamp: now.Add(-48 * time.Hour)},
+		{ShortURL: "old", Timestamp: now.Add(-48 * time.Hour)},
+		{ShortURL: "old", Timestamp: now.Add(-48 * time.Hour)},
+	})
+
+	counts, err := store.TopShortURLs(c, now.Add(-24*time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 2 || counts[0].ShortURL != "hot" || counts[0].Count != 2 || counts[1].ShortURL != "warm" {
+		t.Errorf("unexpected counts %v", counts)
+	}
+}
@@ -0,0 +1,52 @@
+package analytics
+
+import (
+	"context"
+	"time"
+)
+
+type Store interface {
+	SaveMany(c context.Context, events []*ClickEvent) error
+	CountByShortURL(c context.Context, shortURL string) (int64, error)
+	CountByInterval(c context.Context, shortURL string, interval Interval, from, to time.Time) ([]*BucketCount, error)
+	TopReferrers(c context.Context, shortURL string, limit int) ([]*ReferrerCount, error)
+	// TopShortURLs returns the most clicked short urls since the time.
+	TopShortURLs(c context.Context, since time.Time, limit int) ([]*ShortURLCount, error)
+}
+
+type ClickEvent struct {
+	ShortURL  string
+	Timestamp time.Time
+	Referrer  string
+	UserAgent string
+	IPHash    string
+}
+
+type Interval string
+
+const (
+	HOUR Interval = "hour"
+	DAY  Interval = "day"
+)
+
+func (i Interval) Duration() time.Duration {
+	if i == HOUR {
+		return time.Hour
+	}
+	return 24 * time.Hour
+}
+
+type BucketCount struct {
+	Time  time.Time `json:"time"`
+	Count int64     `json:"count"`
+}
+
+type ReferrerCount struct {
+	Referrer string `json:"referrer"`
+	Count    int64  `json:"count"`
+}
+
+type ShortURLCount struct {
+	ShortURL string
+	Count    int64
+}
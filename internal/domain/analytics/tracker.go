@@ -0,0 +1,34 @@
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tracker turns redirect requests into click events.
+type Tracker struct {
+	recorder Recorder
+	ipSalt   string
+}
+
+func NewTracker(recorder Recorder, ipSalt string) *Tracker {
+	return &Tracker{recorder, ipSalt}
+}
+
+func (t *Tracker) RecordClick(ctx *gin.Context, shortURL string) {
+	t.recorder.Record(&ClickEvent{
+		ShortURL:  shortURL,
+		Timestamp: time.Now(),
+		Referrer:  ctx.Request.Referer(),
+		UserAgent: ctx.Request.UserAgent(),
+		IPHash:    t.hashIP(ctx.ClientIP()),
+	})
+}
+
+func (t *Tracker) hashIP(ip string) string {
+	sum := sha256.Sum256([]byte(t.ipSalt + ip))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,43 @@
+package analytics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/WeiAnAn/url-shortener/internal/domain/analytics"
+	mock_analytics "github.com/WeiAnAn/url-shortener/internal/domain/analytics/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+)
+
+func TestTrackerRecordClickEventWithHashedIP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	recorder := mock_analytics.NewMockRecorder(ctrl)
+	tracker := analytics.NewTracker(recorder, "salt")
+
+	gin.SetMode(gin.TestMode)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/aaaaaaa", nil)
+	ctx.Request.RemoteAddr = "192.0.2.1:1234"
+	ctx.Request.Header.Set("Referer", "https://example.com/")
+	ctx.Request.Header.Set("User-Agent", "test-agent")
+
+	var event *analytics.ClickEvent
+	recorder.EXPECT().Record(gomock.Any()).Do(func(e *analytics.ClickEvent) {
+		event = e
+	})
+
+	tracker.RecordClick(ctx, "aaaaaaa")
+
+	if event.ShortURL != "aaaaaaa" || event.Referrer != "https://example.com/" || event.UserAgent != "test-agent" {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if event.IPHash == "" || event.IPHash == "192.0.2.1" {
+		t.Errorf("ip is not hashed: %s", event.IPHash)
+	}
+	if event.Timestamp.IsZero() {
+		t.Error("timestamp is not set")
+	}
+}
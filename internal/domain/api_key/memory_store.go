@@ -0,0 +1,35 @@
+package apikey
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore keeps API keys in process, it is meant for local development and tests.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	keys map[string]APIKey
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: make(map[string]APIKey)}
+}
+
+func (m *MemoryStore) Save(c context.Context, key *APIKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.keys[key.Hash] = *key
+	return nil
+}
+
+func (m *MemoryStore) FindByHash(c context.Context, hash string) (*APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key, ok := m.keys[hash]
+	if !ok {
+		return nil, nil
+	}
+	return &key, nil
+}
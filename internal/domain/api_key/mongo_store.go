@@ -0,0 +1,60 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const COLLECTION_NAME = "api_keys"
+
+type MongoStore struct {
+	client   *mongo.Client
+	database string
+}
+
+type APIKeyDocument struct {
+	ID        string    `bson:"id"`
+	Name      string    `bson:"name"`
+	Hash      string    `bson:"hash"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+func NewMongoStore(c *mongo.Client, d string) *MongoStore {
+	unique := true
+	index := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "hash", Value: 1},
+		},
+		Options: &options.IndexOptions{Unique: &unique},
+	}
+	c.Database(d).Collection(COLLECTION_NAME).Indexes().CreateOne(context.Background(), index)
+	return &MongoStore{c, d}
+}
+
+func (m *MongoStore) Save(c context.Context, key *APIKey) error {
+	doc := APIKeyDocument{key.ID, key.Name, key.Hash, key.CreatedAt}
+
+	_, err := m.client.Database(m.database).Collection(COLLECTION_NAME).InsertOne(c, doc)
+
+	return err
+}
+
+func (m *MongoStore) FindByHash(c context.Context, hash string) (*APIKey, error) {
+	var doc APIKeyDocument
+	err := m.client.Database(m.database).Collection(COLLECTION_NAME).FindOne(c, bson.M{
+		"hash": hash,
+	}).Decode(&doc)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &APIKey{doc.ID, doc.Name, doc.Hash, doc.CreatedAt}, nil
+}
@@ -0,0 +1,91 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	KEY_BYTES    = 32
+	ID_BYTES     = 12
+	OWNER_ID_KEY = "ownerID"
+)
+
+// APIKey only keeps the hash of the key, the plain key is shown once when it is created.
+type APIKey struct {
+	ID        string
+	Name      string
+	Hash      string
+	CreatedAt time.Time
+}
+
+type Service interface {
+	Create(c context.Context, name string) (string, *APIKey, error)
+	// Authenticate returns nil if the key is invalid.
+	Authenticate(c context.Context, key string) (*APIKey, error)
+}
+
+type service struct {
+	store Store
+}
+
+func NewService(store Store) *service {
+	return &service{store}
+}
+
+func (s *service) Create(c context.Context, name string) (string, *APIKey, error) {
+	key, err := randomString(KEY_BYTES)
+	if err != nil {
+		return "", nil, err
+	}
+	id, err := randomString(ID_BYTES)
+	if err != nil {
+		return "", nil, err
+	}
+
+	apiKey := &APIKey{
+		ID:        id,
+		Name:      name,
+		Hash:      Hash(key),
+		CreatedAt: time.Now(),
+	}
+	err = s.store.Save(c, apiKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return key, apiKey, nil
+}
+
+func (s *service) Authenticate(c context.Context, key string) (*APIKey, error) {
+	if key == "" {
+		return nil, nil
+	}
+	return s.store.FindByHash(c, Hash(key))
+}
+
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// OwnerID returns the ID of the API key which authenticated the request,
+// or empty string if the request is not authenticated.
+func OwnerID(ctx *gin.Context) string {
+	return ctx.GetString(OWNER_ID_KEY)
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
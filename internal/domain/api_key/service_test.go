@@ -0,0 +1,63 @@
+package apikey_test
+
+import (
+	"context"
+	"testing"
+
+	apikey "github.com/WeiAnAn/url-shortener/internal/domain/api_key"
+	mock_apikey "github.com/WeiAnAn/url-shortener/internal/domain/api_key/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+func TestCreateSaveHashedKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mock_apikey.NewMockStore(ctrl)
+	service := apikey.NewService(store)
+
+	var saved *apikey.APIKey
+	store.EXPECT().Save(gomock.Any(), gomock.Any()).Do(func(_ context.Context, key *apikey.APIKey) {
+		saved = key
+	}).Return(nil)
+
+	key, apiKey, err := service.Create(context.Background(), "marketing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key == "" || apiKey.ID == "" || apiKey.Name != "marketing" {
+		t.Errorf("unexpected api key %+v", apiKey)
+	}
+	if saved.Hash == key || saved.Hash != apikey.Hash(key) {
+		t.Error("key is not hashed")
+	}
+}
+
+func TestAuthenticateFindKeyByHash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mock_apikey.NewMockStore(ctrl)
+	service := apikey.NewService(store)
+
+	c := context.Background()
+	apiKey := &apikey.APIKey{ID: "owner"}
+	store.EXPECT().FindByHash(c, apikey.Hash("key")).Return(apiKey, nil)
+
+	result, err := service.Authenticate(c, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != apiKey {
+		t.Fail()
+	}
+}
+
+func TestAuthenticateReturnNilIfKeyIsEmpty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	service := apikey.NewService(mock_apikey.NewMockStore(ctrl))
+
+	result, err := service.Authenticate(context.Background(), "")
+	if err != nil || result != nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,40 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/WeiAnAn/url-shortener/internal/database"
+)
+
+// SQLStore stores API keys in the api_keys table created by the database migrations.
+type SQLStore struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+func NewSQLStore(db *sql.DB, dialect database.Dialect) *SQLStore {
+	return &SQLStore{db, dialect}
+}
+
+func (s *SQLStore) Save(c context.Context, key *APIKey) error {
+	_, err := s.db.ExecContext(c, s.dialect.Rebind("INSERT INTO api_keys (id, name, hash, created_at) VALUES (?, ?, ?, ?)"),
+		key.ID, key.Name, key.Hash, key.CreatedAt.UTC())
+
+	return err
+}
+
+func (s *SQLStore) FindByHash(c context.Context, hash string) (*APIKey, error) {
+	var key APIKey
+	err := s.db.QueryRowContext(c, s.dialect.Rebind("SELECT id, name, hash, created_at FROM api_keys WHERE hash = ?"), hash).
+		Scan(&key.ID, &key.Name, &key.Hash, &key.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
@@ -0,0 +1,9 @@
+package apikey
+
+import "context"
+
+type Store interface {
+	Save(c context.Context, key *APIKey) error
+	// FindByHash returns nil if the key does not exist.
+	FindByHash(c context.Context, hash string) (*APIKey, error)
+}
@@ -0,0 +1,194 @@
+package shorturl
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/bloom"
+)
+
+// BLOOM_FILTER_REDIS_BATCH_SIZE is the number of short urls added to the redis filter at once while rebuilding.
+const BLOOM_FILTER_REDIS_BATCH_SIZE = 1000
+
+// ShortURLFilter is checked before the cache, so short urls which were never created are not found
+// without touching the cache or the persistent store.
+type ShortURLFilter interface {
+	Add(c context.Context, shortURL string) error
+	// MightContain may return true for a short url which does not exist, but never false for an existing one.
+	// It returns true together with the error if the filter is unavailable.
+	MightContain(c context.Context, shortURL string) (bool, error)
+}
+
+// BloomFilter keeps the short urls of the persistent store in an in-process Bloom filter.
+// The filter is rebuilt periodically to drop deleted short urls. With multiple replicas,
+// the redis filter shares the short urls created by other replicas since the last rebuild.
+type BloomFilter struct {
+	persistentStore PersistentStore
+	capacity        uint
+	fpRate          float64
+	redisFilter     *bloom.RedisFilter
+
+	mu sync.RWMutex
+	// current is nil until the first rebuild is done, all short urls might exist before that.
+	current *bloom.Filter
+	// next is the filter being rebuilt, it receives the short urls created during the rebuild.
+	next *bloom.Filter
+
+	rebuildMu sync.Mutex
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewBloomFilter creates the filter sized for capacity short urls, the redis filter is optional.
+func NewBloomFilter(ps PersistentStore, capacity uint, fpRate float64, redisFilter *bloom.RedisFilter) *BloomFilter {
+	return &BloomFilter{
+		persistentStore: ps,
+		capacity:        capacity,
+		fpRate:          fpRate,
+		redisFilter:     redisFilter,
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+}
+
+func (f *BloomFilter) Add(c context.Context, shortURL string) error {
+	f.mu.RLock()
+	if f.current != nil {
+		f.current.Add(shortURL)
+	}
+	if f.next != nil {
+		f.next.Add(shortURL)
+	}
+	f.mu.RUnlock()
+
+	if f.redisFilter != nil {
+		return f.redisFilter.Add(c, shortURL)
+	}
+	return nil
+}
+
+func (f *BloomFilter) MightContain(c context.Context, shortURL string) (bool, error) {
+	f.mu.RLock()
+	current := f.current
+	f.mu.RUnlock()
+
+	if current == nil || current.Test(shortURL) {
+		return true, nil
+	}
+	if f.redisFilter == nil {
+		return false, nil
+	}
+
+	found, ready, err := f.redisFilter.Test(c, shortURL)
+	if err != nil {
+		return true, err
+	}
+	if found {
+		current.Add(shortURL)
+	}
+	return found || !ready, nil
+}
+
+// Rebuild replaces the filter with a new one built from the persistent store. The redis filter
+// is only populated if it is not ready, e.g. on the first start or after it is evicted.
+func (f *BloomFilter) Rebuild(c context.Context) error {
+	f.rebuildMu.Lock()
+	defer f.rebuildMu.Unlock()
+
+	next := bloom.New(f.capacity, f.fpRate)
+	f.mu.Lock()
+	f.next = next
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.next = nil
+		f.mu.Unlock()
+	}()
+
+	populateRedis := false
+	if f.redisFilter != nil {
+		ready, err := f.redisFilter.Ready(c)
+		if err != nil {
+			slog.WarnContext(c, "check redis bloom filter failed", slog.Any("error", err))
+		}
+		populateRedis = err == nil && !ready
+	}
+
+	var batch []string
+	count := 0
+	err := f.persistentStore.ForEachShortURL(c, func(shortURL string) error {
+		next.Add(shortURL)
+		count++
+		if !populateRedis {
+			return nil
+		}
+
+		batch = append(batch, shortURL)
+		if len(batch) < BLOOM_FILTER_REDIS_BATCH_SIZE {
+			return nil
+		}
+		err := f.redisFilter.AddMany(c, batch)
+		batch = batch[:0]
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if populateRedis {
+		err = f.redisFilter.AddMany(c, batch)
+		if err != nil {
+			return err
+		}
+		err = f.redisFilter.MarkReady(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	if count > int(f.capacity) {
+		slog.WarnContext(c, "bloom filter exceeds its capacity, the false positive rate increases", slog.Int("short_urls", count), slog.Uint64("capacity", uint64(f.capacity)))
+	}
+
+	f.mu.Lock()
+	f.current = next
+	f.mu.Unlock()
+	return nil
+}
+
+// Start rebuilds the filter in the background now and then every interval.
+func (f *BloomFilter) Start(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer close(f.done)
+		defer cancel()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		go func() {
+			<-f.stop
+			cancel()
+		}()
+
+		for {
+			err := f.Rebuild(ctx)
+			if err != nil {
+				slog.Warn("rebuild bloom filter failed", slog.Any("error", err))
+			}
+
+			select {
+			case <-f.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close stops the periodic rebuild started by Start, a running rebuild is canceled.
+func (f *BloomFilter) Close() {
+	close(f.stop)
+	<-f.done
+}
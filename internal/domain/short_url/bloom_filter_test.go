@@ -0,0 +1,101 @@
+package shorturl_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
+	mock_shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+func TestBloomFilterMightContainAllBeforeRebuild(t *testing.T) {
+	filter := shorturl.NewBloomFilter(shorturl.NewMemoryPersistentStore(), 1000, 0.01, nil)
+
+	exists, err := filter.MightContain(context.Background(), "short")
+	if err != nil || !exists {
+		t.Errorf("expected true before rebuild, got %v %v", exists, err)
+	}
+}
+
+func TestBloomFilterRebuildFromPersistentStore(t *testing.T) {
+	c := context.Background()
+	store := shorturl.NewMemoryPersistentStore()
+	store.Save(c, newMemoryShortURL("short", time.Now().Add(time.Hour)))
+	store.Save(c, newMemoryShortURL("expired", time.Now().Add(-time.Hour)))
+	filter := shorturl.NewBloomFilter(store, 1000, 0.01, nil)
+
+	err := filter.Rebuild(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, short := range []string{"short", "expired"} {
+		if exists, _ := filter.MightContain(c, short); !exists {
+			t.Errorf("%s is not in the filter", short)
+		}
+	}
+	if exists, _ := filter.MightContain(c, "missing"); exists {
+		t.Error("missing short url is in the filter")
+	}
+}
+
+func TestBloomFilterAdd(t *testing.T) {
+	c := context.Background()
+	filter := shorturl.NewBloomFilter(shorturl.NewMemoryPersistentStore(), 1000, 0.01, nil)
+	filter.Rebuild(c)
+
+	filter.Add(c, "short")
+
+	if exists, _ := filter.MightContain(c, "short"); !exists {
+		t.Error("added short url is not in the filter")
+	}
+}
+
+func TestBloomFilterKeepShortURLAddedDuringRebuild(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	c := context.Background()
+	ps := mock_shorturl.NewMockPersistentStore(mockCtrl)
+	filter := shorturl.NewBloomFilter(ps, 1000, 0.01, nil)
+	ps.EXPECT().ForEachShortURL(c, gomock.Any()).DoAndReturn(func(c context.Context, fn func(string) error) error {
+		filter.Add(c, "created")
+		return fn("existing")
+	})
+
+	filter.Rebuild(c)
+
+	for _, short := range []string{"created", "existing"} {
+		if exists, _ := filter.MightContain(c, short); !exists {
+			t.Errorf("%s is not in the filter", short)
+		}
+	}
+}
+
+func TestBloomFilterKeepPreviousFilterIfRebuildFails(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	c := context.Background()
+	ps := mock_shorturl.NewMockPersistentStore(mockCtrl)
+	filter := shorturl.NewBloomFilter(ps, 1000, 0.01, nil)
+	gomock.InOrder(
+		ps.EXPECT().ForEachShortURL(c, gomock.Any()).DoAndReturn(func(c context.Context, fn func(string) error) error {
+			return fn("existing")
+		}),
+		ps.EXPECT().ForEachShortURL(c, gomock.Any()).Return(errors.New("error")),
+	)
+
+	filter.Rebuild(c)
+	err := filter.Rebuild(c)
+
+	if err == nil {
+		t.Error("rebuild error is not returned")
+	}
+	if exists, _ := filter.MightContain(c, "existing"); !exists {
+		t.Error("previous filter is dropped")
+	}
+}
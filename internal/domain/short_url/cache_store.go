@@ -5,4 +5,5 @@ import "context"
 type CacheStore interface {
 	Get(c context.Context, key string) (*string, error)
 	Set(c context.Context, key, value string, expireSecond uint) error
+	Delete(c context.Context, key string) error
 }
@@ -1,61 +1,332 @@
 package shorturl
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	apikey "github.com/WeiAnAn/url-shortener/internal/domain/api_key"
 	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
+const (
+	MIN_ALIAS_LENGTH = 3
+	MAX_ALIAS_LENGTH = 32
+	MAX_BATCH_SIZE   = 1000
+	// MAX_BATCH_ITEM_SIZE is the average size in bytes an item of a batch may take.
+	MAX_BATCH_ITEM_SIZE = 4 << 10
+	MAX_BATCH_BODY_SIZE = MAX_BATCH_SIZE * MAX_BATCH_ITEM_SIZE
+)
+
+var shortURLPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// reservedAliases can not be used as alias since they collide with other routes.
+var reservedAliases = map[string]bool{
+	"admin":   true,
+	"api":     true,
+	"health":  true,
+	"healthz": true,
+	"metrics": true,
+	"readyz":  true,
+	"static":  true,
+}
+
+type ClickRecorder interface {
+	// RecordClick must not block the redirect.
+	RecordClick(ctx *gin.Context, shortURL string)
+}
+
 type Controller struct {
-	service Service
-	baseURL string
+	service       Service
+	baseURL       string
+	clickRecorder ClickRecorder
 }
 
-func NewController(service Service, baseURL string) *Controller {
-	return &Controller{service, baseURL}
+func NewController(service Service, baseURL string, clickRecorder ClickRecorder) *Controller {
+	return &Controller{service, baseURL, clickRecorder}
 }
 
 type CreateShortURLPayload struct {
 	URL      string    `json:"url" binding:"required,url"`
 	ExpireAt time.Time `json:"expireAt" binding:"required,gt"`
+	Alias    string    `json:"alias"`
 }
 
 func (c *Controller) CreateShortURL(ctx *gin.Context) {
 	var body CreateShortURLPayload
-	err := ctx.ShouldBindJSON(&body)
+	err := bindJSON(ctx, &body)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	err = validateCreateShortURLPayload(&body)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var shortUrl *ShortURLWithExpireTime
+	var reused bool
+	if body.Alias != "" {
+		shortUrl, err = c.service.CreateShortURLWithAlias(ctx, apikey.OwnerID(ctx), body.Alias, body.URL, body.ExpireAt)
+	} else {
+		shortUrl, reused, err = c.service.CreateShortURL(ctx, apikey.OwnerID(ctx), body.URL, body.ExpireAt)
+	}
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"shortUrl": fmt.Sprintf("%s/%s", c.baseURL, shortUrl.ShortUrl.ShortURL),
+		"id":       shortUrl.ShortUrl.ShortURL,
+		"expireAt": shortUrl.ExpireAt.Format(time.RFC3339),
+		"reused":   reused,
+	})
+}
+
+type BatchCreateShortURLResult struct {
+	Status   int    `json:"status"`
+	ID       string `json:"id,omitempty"`
+	ShortURL string `json:"shortUrl,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Code     string `json:"code,omitempty"`
+	Reused   bool   `json:"reused,omitempty"`
+}
+
+// BatchCreateShortURLs handles POST /api/v1/urls:batch. gin can not register a static path
+// containing ':', so the route is registered as /api/v1/urls:method and other methods are rejected here.
+func (c *Controller) BatchCreateShortURLs(ctx *gin.Context) {
+	RequireBatchMethod(ctx)
+	if ctx.IsAborted() {
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, MAX_BATCH_BODY_SIZE)
+	var items []json.RawMessage
+	err := ctx.ShouldBindJSON(&items)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		ctx.Error(myerror.NewPayloadTooLargeError(maxBytesErr.Limit))
+		return
+	}
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if len(items) == 0 || len(items) > MAX_BATCH_SIZE {
+		ctx.Error(myerror.NewValidationError("body", strconv.Itoa(len(items)), fmt.Sprintf("batch size must be between 1 and %d", MAX_BATCH_SIZE)))
+		return
+	}
+
+	results := make([]*BatchCreateShortURLResult, len(items))
+	inputs := make([]*CreateShortURLInput, 0, len(items))
+	indexes := make([]int, 0, len(items))
+	for i, item := range items {
+		body, err := parseBatchItem(item)
+		if err != nil {
+			results[i] = toBatchErrorResult(err)
+			continue
+		}
+		inputs = append(inputs, &CreateShortURLInput{
+			OriginalURL: body.URL,
+			ExpireAt:    body.ExpireAt,
+			Alias:       body.Alias,
+		})
+		indexes = append(indexes, i)
+	}
+
+	if len(inputs) > 0 {
+		created, err := c.service.CreateShortURLs(ctx, apikey.OwnerID(ctx), inputs)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		for j, result := range created {
+			if result.Err != nil {
+				results[indexes[j]] = toBatchErrorResult(result.Err)
+				continue
+			}
+			results[indexes[j]] = &BatchCreateShortURLResult{
+				Status:   http.StatusOK,
+				ID:       result.ShortURL.ShortUrl.ShortURL,
+				ShortURL: fmt.Sprintf("%s/%s", c.baseURL, result.ShortURL.ShortUrl.ShortURL),
+				Reused:   result.Reused,
+			}
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"results": results,
+	})
+}
+
+// RequireBatchMethod responds 404 to /api/v1/urls:method with any method other than :batch. It runs
+// before the rate limit of the route, so requests to unknown methods are not charged.
+func RequireBatchMethod(ctx *gin.Context) {
+	if ctx.Param("method") != ":batch" {
+		ctx.AbortWithStatus(http.StatusNotFound)
+	}
+}
+
+// BatchSize counts the items of a batch request, so they are charged to the rate limit before the batch
+// is handled. At most MAX_BATCH_BODY_SIZE bytes are read, and the body is restored for the handler with
+// the read error, if any. Invalid batches count as one since they create nothing.
+func BatchSize(ctx *gin.Context) int {
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, MAX_BATCH_BODY_SIZE))
+	if err != nil {
+		ctx.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errorReader{err}))
+		return 1
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var items []json.RawMessage
+	if json.Unmarshal(body, &items) != nil || len(items) == 0 || len(items) > MAX_BATCH_SIZE {
+		return 1
+	}
+	return len(items)
+}
+
+// errorReader fails every read with err.
+type errorReader struct {
+	err error
+}
+
+func (r errorReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
+
+func parseBatchItem(item json.RawMessage) (*CreateShortURLPayload, error) {
+	var body CreateShortURLPayload
+	err := json.Unmarshal(item, &body)
 	if err != nil {
 		timeErr, isTimeParseErr := err.(*time.ParseError)
 		if isTimeParseErr {
-			err = myerror.NewValidationError("expireAt", timeErr.Value, "Invalid time format")
+			return nil, myerror.NewValidationError("expireAt", timeErr.Value, "Invalid time format")
 		}
+		return nil, myerror.NewValidationError("body", string(item), "item must be an object")
+	}
+	err = binding.Validator.ValidateStruct(&body)
+	if err != nil {
+		return nil, err
+	}
+	err = validateCreateShortURLPayload(&body)
+	if err != nil {
+		return nil, err
+	}
+	return &body, nil
+}
+
+func toBatchErrorResult(err error) *BatchCreateShortURLResult {
+	status, msg := myerror.StatusAndMessage(err)
+	return &BatchCreateShortURLResult{Status: status, Message: msg, Code: myerror.NewProblem(err).Code}
+}
+
+type ShortURLParams struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+func (c *Controller) GetShortURL(ctx *gin.Context) {
+	var params ShortURLParams
+	err := ctx.ShouldBindUri(&params)
+	if err != nil {
 		ctx.Error(err)
 		return
 	}
-	if !strings.HasPrefix(body.URL, "http://") && !strings.HasPrefix(body.URL, "https://") {
-		err = myerror.NewValidationError("url", body.URL, "url must be http or https URL")
+
+	shortURL, err := c.service.GetShortURL(ctx, apikey.OwnerID(ctx), params.ID)
+	if err != nil {
 		ctx.Error(err)
 		return
 	}
-	if time.Now().AddDate(1, 0, 0).Before(body.ExpireAt) {
-		err = myerror.NewValidationError("expireAt", body.ExpireAt.Format(time.RFC3339), "expireAt must be within one year")
+
+	ctx.JSON(http.StatusOK, c.toShortURLResponse(shortURL))
+}
+
+type UpdateShortURLPayload struct {
+	URL      *string    `json:"url" binding:"omitempty,url"`
+	ExpireAt *time.Time `json:"expireAt" binding:"omitempty,gt"`
+}
+
+func (c *Controller) UpdateShortURL(ctx *gin.Context) {
+	var params ShortURLParams
+	err := ctx.ShouldBindUri(&params)
+	if err != nil {
 		ctx.Error(err)
 		return
 	}
 
-	shortUrl, err := c.service.CreateShortURL(ctx, body.URL, body.ExpireAt)
+	var body UpdateShortURLPayload
+	err = bindJSON(ctx, &body)
 	if err != nil {
 		ctx.Error(err)
 		return
 	}
+	if body.URL == nil && body.ExpireAt == nil {
+		ctx.Error(myerror.NewValidationError("body", "{}", "url or expireAt is required"))
+		return
+	}
+	if body.URL != nil {
+		err = validateURL(*body.URL)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+	if body.ExpireAt != nil {
+		err = validateExpireAt(*body.ExpireAt)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"shortUrl": fmt.Sprintf("%s/%s", c.baseURL, shortUrl.ShortUrl.ShortURL),
-		"id":       shortUrl.ShortUrl.ShortURL,
+	shortURL, err := c.service.UpdateShortURL(ctx, apikey.OwnerID(ctx), params.ID, &ShortURLUpdate{
+		OriginalURL: body.URL,
+		ExpireAt:    body.ExpireAt,
 	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, c.toShortURLResponse(shortURL))
+}
+
+func (c *Controller) DeleteShortURL(ctx *gin.Context) {
+	var params ShortURLParams
+	err := ctx.ShouldBindUri(&params)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = c.service.DeleteShortURL(ctx, apikey.OwnerID(ctx), params.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *Controller) toShortURLResponse(shortURL *ShortURLWithExpireTime) gin.H {
+	return gin.H{
+		"id":       shortURL.ShortUrl.ShortURL,
+		"shortUrl": fmt.Sprintf("%s/%s", c.baseURL, shortURL.ShortUrl.ShortURL),
+		"url":      shortURL.ShortUrl.OriginalURL,
+		"expireAt": shortURL.ExpireAt.Format(time.RFC3339),
+	}
 }
 
 type RedirectParams struct {
@@ -70,12 +341,17 @@ func (c *Controller) Redirect(ctx *gin.Context) {
 		return
 	}
 
-	if len(params.URL) != 7 {
+	if !isValidShortURL(params.URL) {
 		ctx.AbortWithStatus(http.StatusNotFound)
 		return
 	}
 
 	shortURL, err := c.service.GetOriginalURL(ctx, params.URL)
+	var blockedErr *myerror.BlockedURLError
+	if errors.As(err, &blockedErr) {
+		renderWarningPage(ctx, params.URL, blockedErr)
+		return
+	}
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -86,5 +362,66 @@ func (c *Controller) Redirect(ctx *gin.Context) {
 		return
 	}
 
+	c.clickRecorder.RecordClick(ctx, shortURL.ShortURL)
 	ctx.Redirect(http.StatusFound, shortURL.OriginalURL)
 }
+
+func bindJSON(ctx *gin.Context, body any) error {
+	err := ctx.ShouldBindJSON(body)
+	if err != nil {
+		timeErr, isTimeParseErr := err.(*time.ParseError)
+		if isTimeParseErr {
+			return myerror.NewValidationError("expireAt", timeErr.Value, "Invalid time format")
+		}
+		return err
+	}
+	return nil
+}
+
+func validateCreateShortURLPayload(body *CreateShortURLPayload) error {
+	err := validateURL(body.URL)
+	if err != nil {
+		return err
+	}
+	err = validateExpireAt(body.ExpireAt)
+	if err != nil {
+		return err
+	}
+	if body.Alias != "" {
+		return validateAlias(body.Alias)
+	}
+	return nil
+}
+
+// validateURL accepts the scheme in any case, since the url is normalized by the service.
+func validateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (!strings.EqualFold(u.Scheme, "http") && !strings.EqualFold(u.Scheme, "https")) || u.Host == "" {
+		return myerror.NewValidationError("url", rawURL, "url must be http or https URL")
+	}
+	return nil
+}
+
+func validateExpireAt(expireAt time.Time) error {
+	if time.Now().AddDate(1, 0, 0).Before(expireAt) {
+		return myerror.NewValidationError("expireAt", expireAt.Format(time.RFC3339), "expireAt must be within one year")
+	}
+	return nil
+}
+
+func validateAlias(alias string) error {
+	if len(alias) < MIN_ALIAS_LENGTH || len(alias) > MAX_ALIAS_LENGTH {
+		return myerror.NewValidationError("alias", alias, fmt.Sprintf("alias length must be between %d and %d", MIN_ALIAS_LENGTH, MAX_ALIAS_LENGTH))
+	}
+	if !shortURLPattern.MatchString(alias) {
+		return myerror.NewValidationError("alias", alias, "alias can only contain letters, digits, '-' and '_'")
+	}
+	if reservedAliases[strings.ToLower(alias)] {
+		return myerror.NewValidationError("alias", alias, "alias is reserved")
+	}
+	return nil
+}
+
+func isValidShortURL(shortURL string) bool {
+	return len(shortURL) <= MAX_ALIAS_LENGTH && shortURLPattern.MatchString(shortURL)
+}
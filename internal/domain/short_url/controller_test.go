@@ -8,9 +8,11 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	apikey "github.com/WeiAnAn/url-shortener/internal/domain/api_key"
 	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
 	mock_shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url/mocks"
 	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
@@ -22,6 +24,8 @@ import (
 type CreateShortURLResponse struct {
 	ShortURL string `json:"shortUrl"`
 	ID       string `json:"id"`
+	ExpireAt string `json:"expireAt"`
+	Reused   bool   `json:"reused"`
 }
 
 const BASE_URL = "http://localhost"
@@ -52,8 +56,8 @@ func TestCreateShortURLResponseCreatedData(t *testing.T) {
 		ExpireAt: expireAt,
 	}
 	mockService.EXPECT().
-		CreateShortURL(ctx, url, expireAt).
-		Return(shortURL, nil)
+		CreateShortURL(ctx, "", url, expireAt).
+		Return(shortURL, false, nil)
 
 	controller.CreateShortURL(ctx)
 
@@ -63,6 +67,9 @@ func TestCreateShortURLResponseCreatedData(t *testing.T) {
 	if resBody.ID != shortURL.ShortUrl.ShortURL || resBody.ShortURL != fmt.Sprintf("%s/%s", BASE_URL, shortURL.ShortUrl.ShortURL) {
 		t.Fail()
 	}
+	if resBody.ExpireAt != expireAt.Format(time.RFC3339) || resBody.Reused {
+		t.Errorf("unexpected expireAt %s or reused %v", resBody.ExpireAt, resBody.Reused)
+	}
 }
 
 func TestCreateShortURLResponseBadRequestIfBodyIsEmpty(t *testing.T) {
@@ -195,6 +202,35 @@ func TestCreateShortURLResponseBadRequestIfURLFormatIsInvalid(t *testing.T) {
 	}
 }
 
+func TestCreateShortURLAcceptUppercaseScheme(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockService, controller := createController(ctrl)
+	w := httptest.NewRecorder()
+	ctx := createGinContext(w)
+
+	url := "HTTPS://pkg.go.dev"
+	expireAt, err := time.Parse(time.RFC3339, time.Now().AddDate(0, 0, 1).Format(time.RFC3339))
+	if err != nil {
+		panic(err)
+	}
+	body := struct {
+		URL      string `json:"url"`
+		ExpireAt string `json:"expireAt"`
+	}{url, expireAt.Format(time.RFC3339)}
+	setPostRequest(ctx, body)
+
+	mockService.EXPECT().
+		CreateShortURL(ctx, "", url, expireAt).
+		Return(&shorturl.ShortURLWithExpireTime{ShortUrl: &shorturl.ShortURL{OriginalURL: "https://pkg.go.dev", ShortURL: "aaaaaaa"}, ExpireAt: expireAt}, false, nil)
+
+	controller.CreateShortURL(ctx)
+
+	if len(ctx.Errors) != 0 {
+		t.Errorf("unexpected errors %v", ctx.Errors)
+	}
+}
+
 func TestCreateShortURLResponseBadRequestIfExpireAtIsAfterOneYear(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -234,6 +270,238 @@ func TestCreateShortURLResponseBadRequestIfExpireAtIsAfterOneYear(t *testing.T)
 	}
 }
 
+func TestCreateShortURLWithAliasResponseCreatedData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockService, controller := createController(ctrl)
+	w := httptest.NewRecorder()
+	ctx := createGinContext(w)
+
+	url := "https://pkg.go.dev"
+	alias := "spring-sale"
+	expireAt, err := time.Parse(time.RFC3339, time.Now().AddDate(0, 0, 1).Format(time.RFC3339))
+	if err != nil {
+		panic(err)
+	}
+	body := struct {
+		URL      string `json:"url"`
+		ExpireAt string `json:"expireAt"`
+		Alias    string `json:"alias"`
+	}{url, expireAt.Format(time.RFC3339), alias}
+	setPostRequest(ctx, body)
+
+	shortURL := &shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{
+			OriginalURL: url,
+			ShortURL:    alias,
+		},
+		ExpireAt: expireAt,
+	}
+	mockService.EXPECT().
+		CreateShortURLWithAlias(ctx, "", alias, url, expireAt).
+		Return(shortURL, nil)
+
+	controller.CreateShortURL(ctx)
+
+	var resBody CreateShortURLResponse
+	json.Unmarshal(w.Body.Bytes(), &resBody)
+
+	if resBody.ID != alias || resBody.ShortURL != fmt.Sprintf("%s/%s", BASE_URL, alias) {
+		t.Fail()
+	}
+}
+
+func TestCreateShortURLResponseBadRequestIfAliasIsInvalid(t *testing.T) {
+	cases := map[string]string{
+		"ab":           "Validation failed on alias with value ab. alias length must be between 3 and 32",
+		"spring sale":  "Validation failed on alias with value spring sale. alias can only contain letters, digits, '-' and '_'",
+		"API":          "Validation failed on alias with value API. alias is reserved",
+		"health":       "Validation failed on alias with value health. alias is reserved",
+		"spring/sale/": "Validation failed on alias with value spring/sale/. alias can only contain letters, digits, '-' and '_'",
+	}
+	for alias, expectErrorMessage := range cases {
+		ctrl := gomock.NewController(t)
+		_, controller := createController(ctrl)
+		w := httptest.NewRecorder()
+		ctx := createGinContext(w)
+
+		body := struct {
+			URL      string `json:"url"`
+			ExpireAt string `json:"expireAt"`
+			Alias    string `json:"alias"`
+		}{"https://pkg.go.dev", time.Now().AddDate(0, 0, 1).Format(time.RFC3339), alias}
+		setPostRequest(ctx, body)
+
+		controller.CreateShortURL(ctx)
+
+		if len(ctx.Errors) != 1 {
+			t.Fatal("context errors size is not equal to one")
+		}
+
+		validationErr, ok := ctx.Errors[0].Err.(*myerror.ValidationError)
+		if !ok {
+			t.Fatal("context error is not ValidationError")
+		}
+
+		if validationErr.Error() != expectErrorMessage {
+			t.Errorf("unexpected Error: %s", validationErr.Error())
+		}
+		ctrl.Finish()
+	}
+}
+
+func TestBatchCreateShortURLsResponseResultsInRequestOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockService, controller := createController(ctrl)
+	w := httptest.NewRecorder()
+	ctx := createGinContext(w)
+	ctx.Params = []gin.Param{{Key: "method", Value: ":batch"}}
+
+	expireAt, err := time.Parse(time.RFC3339, time.Now().AddDate(0, 0, 1).Format(time.RFC3339))
+	if err != nil {
+		panic(err)
+	}
+	type item struct {
+		URL      string `json:"url"`
+		ExpireAt string `json:"expireAt"`
+		Alias    string `json:"alias,omitempty"`
+	}
+	setPostRequest(ctx, []item{
+		{"https://pkg.go.dev/a", expireAt.Format(time.RFC3339), ""},
+		{"ftp://pkg.go.dev/b", expireAt.Format(time.RFC3339), ""},
+		{"https://pkg.go.dev/c", expireAt.Format(time.RFC3339), "spring-sale"},
+		{"https://pkg.go.dev/d", "2023", ""},
+	})
+
+	mockService.EXPECT().
+		CreateShortURLs(ctx, "", []*shorturl.CreateShortURLInput{
+			{OriginalURL: "https://pkg.go.dev/a", ExpireAt: expireAt},
+			{OriginalURL: "https://pkg.go.dev/c", ExpireAt: expireAt, Alias: "spring-sale"},
+		}).
+		Return([]*shorturl.CreateShortURLResult{
+			{ShortURL: &shorturl.ShortURLWithExpireTime{ShortUrl: &shorturl.ShortURL{ShortURL: "aaaaaaa"}}},
+			{Err: myerror.NewConflictError("alias", "spring-sale", "alias is already taken")},
+		}, nil)
+
+	controller.BatchCreateShortURLs(ctx)
+
+	var resBody struct {
+		Results []shorturl.BatchCreateShortURLResult `json:"results"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resBody)
+
+	if len(resBody.Results) != 4 {
+		t.Fatalf("unexpected results %s", w.Body.String())
+	}
+	if resBody.Results[0].Status != http.StatusOK || resBody.Results[0].ShortURL != fmt.Sprintf("%s/aaaaaaa", BASE_URL) {
+		t.Errorf("unexpected result[0] %+v", resBody.Results[0])
+	}
+	if resBody.Results[1].Status != http.StatusBadRequest || resBody.Results[3].Status != http.StatusBadRequest {
+		t.Errorf("unexpected invalid results %+v %+v", resBody.Results[1], resBody.Results[3])
+	}
+	if resBody.Results[2].Status != http.StatusConflict {
+		t.Errorf("unexpected result[2] %+v", resBody.Results[2])
+	}
+}
+
+func TestBatchCreateShortURLsResponseBadRequestIfBatchIsEmpty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	_, controller := createController(ctrl)
+	w := httptest.NewRecorder()
+	ctx := createGinContext(w)
+	ctx.Params = []gin.Param{{Key: "method", Value: ":batch"}}
+	setPostRequest(ctx, []struct{}{})
+
+	controller.BatchCreateShortURLs(ctx)
+
+	if len(ctx.Errors) != 1 {
+		t.Fatal("context errors size is not equal to one")
+	}
+	if _, ok := ctx.Errors[0].Err.(*myerror.ValidationError); !ok {
+		t.Error("context error is not ValidationError")
+	}
+}
+
+func TestBatchSizeCountItemsAndRestoreBody(t *testing.T) {
+	for _, test := range []struct {
+		body string
+		size int
+	}{
+		{`[{"url": "https://pkg.go.dev/a"}, {"url": "https://pkg.go.dev/b"}, 1]`, 3},
+		{`[]`, 1},
+		{`{"url": "https://pkg.go.dev/a"}`, 1},
+		{`[{`, 1},
+		{"[" + strings.Repeat("{},", shorturl.MAX_BATCH_SIZE) + "{}]", 1},
+	} {
+		w := httptest.NewRecorder()
+		ctx := createGinContext(w)
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/api/v1/urls:batch", strings.NewReader(test.body))
+
+		if size := shorturl.BatchSize(ctx); size != test.size {
+			t.Errorf("expect size %d of %.20s, got %d", test.size, test.body, size)
+		}
+		body, _ := io.ReadAll(ctx.Request.Body)
+		if string(body) != test.body {
+			t.Errorf("expect the body to be restored, got %.20s", body)
+		}
+	}
+}
+
+func TestBatchSizeLimitBodySize(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := createGinContext(w)
+	body := "[" + strings.Repeat(" ", shorturl.MAX_BATCH_BODY_SIZE) + "]"
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/api/v1/urls:batch", strings.NewReader(body))
+
+	if size := shorturl.BatchSize(ctx); size != 1 {
+		t.Errorf("expect too large body to count as one, got %d", size)
+	}
+	var maxBytesErr *http.MaxBytesError
+	if _, err := io.ReadAll(ctx.Request.Body); !errors.As(err, &maxBytesErr) {
+		t.Errorf("expect the restored body to fail with MaxBytesError, got %v", err)
+	}
+}
+
+func TestBatchCreateShortURLsResponsePayloadTooLargeIfBodyIsTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	_, controller := createController(ctrl)
+	w := httptest.NewRecorder()
+	ctx := createGinContext(w)
+	ctx.Params = []gin.Param{{Key: "method", Value: ":batch"}}
+	body := "[" + strings.Repeat(" ", shorturl.MAX_BATCH_BODY_SIZE) + "]"
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/api/v1/urls:batch", strings.NewReader(body))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+
+	controller.BatchCreateShortURLs(ctx)
+
+	if len(ctx.Errors) != 1 {
+		t.Fatal("context errors size is not equal to one")
+	}
+	var tooLargeErr *myerror.PayloadTooLargeError
+	if !errors.As(ctx.Errors[0].Err, &tooLargeErr) || tooLargeErr.Limit != shorturl.MAX_BATCH_BODY_SIZE {
+		t.Errorf("context error is not PayloadTooLargeError, got %v", ctx.Errors[0].Err)
+	}
+}
+
+func TestBatchCreateShortURLsResponseNotFoundIfMethodIsUnknown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	_, controller := createController(ctrl)
+	w := httptest.NewRecorder()
+	ctx := createGinContext(w)
+	ctx.Params = []gin.Param{{Key: "method", Value: ":unknown"}}
+	setPostRequest(ctx, []struct{}{})
+
+	controller.BatchCreateShortURLs(ctx)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unexpected status code %d", w.Code)
+	}
+}
+
 func TestShouldSetContextErrorIfServiceReturnAnError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -261,8 +529,8 @@ func TestShouldSetContextErrorIfServiceReturnAnError(t *testing.T) {
 	}
 	mockErr := errors.New("error")
 	mockService.EXPECT().
-		CreateShortURL(ctx, url, expireAt).
-		Return(shortURL, mockErr)
+		CreateShortURL(ctx, "", url, expireAt).
+		Return(shortURL, false, mockErr)
 
 	controller.CreateShortURL(ctx)
 
@@ -301,19 +569,76 @@ func TestRedirectRedirectExpectedURL(t *testing.T) {
 	}
 }
 
-func TestRedirectResponseNotFoundIfURLLengthIsNotEqual7(t *testing.T) {
+func TestRedirectRecordClick(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	_, controller := createController(ctrl)
+	mockService := mock_shorturl.NewMockService(ctrl)
+	mockClickRecorder := mock_shorturl.NewMockClickRecorder(ctrl)
+	controller := shorturl.NewController(mockService, BASE_URL, mockClickRecorder)
 	w := httptest.NewRecorder()
 	ctx := createGinContext(w)
-	url := "aaaaaa"
+	url := "aaaaaaa"
 	setRedirectRequest(ctx, url)
+	mockService.EXPECT().GetOriginalURL(ctx, url).Return(&shorturl.ShortURL{
+		ShortURL:    url,
+		OriginalURL: "https://pkg.go.dev",
+	}, nil)
+	mockClickRecorder.EXPECT().RecordClick(ctx, url)
 
 	controller.Redirect(ctx)
+}
 
-	if w.Code != http.StatusNotFound {
-		t.Fail()
+func TestRedirectNotRecordClickIfShortURLNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockService := mock_shorturl.NewMockService(ctrl)
+	mockClickRecorder := mock_shorturl.NewMockClickRecorder(ctrl)
+	controller := shorturl.NewController(mockService, BASE_URL, mockClickRecorder)
+	w := httptest.NewRecorder()
+	ctx := createGinContext(w)
+	url := "aaaaaaa"
+	setRedirectRequest(ctx, url)
+	mockService.EXPECT().GetOriginalURL(ctx, url).Return(nil, nil)
+	mockClickRecorder.EXPECT().RecordClick(gomock.Any(), gomock.Any()).Times(0)
+
+	controller.Redirect(ctx)
+}
+
+func TestRedirectResponseNotFoundIfURLIsInvalid(t *testing.T) {
+	for _, url := range []string{"aaa.aaa", "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"} {
+		ctrl := gomock.NewController(t)
+		_, controller := createController(ctrl)
+		w := httptest.NewRecorder()
+		ctx := createGinContext(w)
+		setRedirectRequest(ctx, url)
+
+		controller.Redirect(ctx)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expect status 404 for %s, got %d", url, w.Code)
+		}
+		ctrl.Finish()
+	}
+}
+
+func TestRedirectRedirectAliasURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockService, controller := createController(ctrl)
+	w := httptest.NewRecorder()
+	ctx := createGinContext(w)
+	url := "spring-sale"
+	setRedirectRequest(ctx, url)
+	originalURL := "https://pkg.go.dev"
+	mockService.EXPECT().GetOriginalURL(ctx, url).Return(&shorturl.ShortURL{
+		ShortURL:    url,
+		OriginalURL: originalURL,
+	}, nil)
+
+	controller.Redirect(ctx)
+
+	if w.Code != http.StatusFound {
+		t.Error("Unexpected status code")
 	}
 }
 
@@ -356,9 +681,204 @@ func TestRedirectSetContextErrorIfServiceReturnError(t *testing.T) {
 	}
 }
 
+func TestRedirectResponseWarningPageIfURLIsBlocked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockService := mock_shorturl.NewMockService(ctrl)
+	mockClickRecorder := mock_shorturl.NewMockClickRecorder(ctrl)
+	controller := shorturl.NewController(mockService, BASE_URL, mockClickRecorder)
+	w := httptest.NewRecorder()
+	ctx := createGinContext(w)
+	url := "aaaaaaa"
+	setRedirectRequest(ctx, url)
+	blockedErr := myerror.NewBlockedURLError("https://phishing.example/?a=<b>", "phishing.example is in the blocklist")
+	mockService.EXPECT().GetOriginalURL(ctx, url).Return(nil, blockedErr)
+	mockClickRecorder.EXPECT().RecordClick(gomock.Any(), gomock.Any()).Times(0)
+
+	controller.Redirect(ctx)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expect status 403, got %d", w.Code)
+	}
+	if w.Header().Get("location") != "" {
+		t.Error("expect no redirect")
+	}
+	if w.Header().Get("content-type") != "text/html; charset=utf-8" {
+		t.Errorf("unexpected content type %s", w.Header().Get("content-type"))
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "https://phishing.example/?a=&lt;b&gt;") || strings.Contains(body, "<b>") {
+		t.Errorf("expect the escaped url in the page, got %s", body)
+	}
+	if strings.Contains(body, "href=") {
+		t.Error("expect the url not to be linked")
+	}
+	if len(ctx.Errors) != 0 {
+		t.Error("expect no context error")
+	}
+}
+
+func TestGetShortURLResponseShortURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockService, controller := createController(ctrl)
+	w := httptest.NewRecorder()
+	ctx := createGinContext(w)
+	ctx.Request.Method = http.MethodGet
+	ctx.Params = []gin.Param{{Key: "id", Value: "aaaaaaa"}}
+
+	expireAt, _ := time.Parse(time.RFC3339, "2023-05-31T00:00:00Z")
+	mockService.EXPECT().GetShortURL(ctx, "", "aaaaaaa").Return(&shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{
+			ShortURL:    "aaaaaaa",
+			OriginalURL: "https://pkg.go.dev",
+		},
+		ExpireAt: expireAt,
+	}, nil)
+
+	controller.GetShortURL(ctx)
+
+	var resBody map[string]string
+	json.Unmarshal(w.Body.Bytes(), &resBody)
+
+	if resBody["id"] != "aaaaaaa" || resBody["url"] != "https://pkg.go.dev" || resBody["expireAt"] != "2023-05-31T00:00:00Z" {
+		t.Errorf("unexpected response %v", resBody)
+	}
+}
+
+func TestGetShortURLPassOwnerIDToService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockService, controller := createController(ctrl)
+	w := httptest.NewRecorder()
+	ctx := createGinContext(w)
+	ctx.Request.Method = http.MethodGet
+	ctx.Params = []gin.Param{{Key: "id", Value: "aaaaaaa"}}
+	ctx.Set(apikey.OWNER_ID_KEY, "owner")
+
+	mockErr := myerror.NewNotFoundError("short url", "aaaaaaa")
+	mockService.EXPECT().GetShortURL(ctx, "owner", "aaaaaaa").Return(nil, mockErr)
+
+	controller.GetShortURL(ctx)
+
+	if len(ctx.Errors) != 1 || ctx.Errors[0].Err != mockErr {
+		t.Error("unexpected error")
+	}
+}
+
+func TestUpdateShortURLCallServiceUpdate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockService, controller := createController(ctrl)
+	w := httptest.NewRecorder()
+	ctx := createGinContext(w)
+	url := "https://pkg.go.dev/new"
+	setPostRequest(ctx, struct {
+		URL string `json:"url"`
+	}{url})
+	ctx.Request.Method = http.MethodPatch
+	ctx.Params = []gin.Param{{Key: "id", Value: "aaaaaaa"}}
+
+	mockService.EXPECT().
+		UpdateShortURL(ctx, "", "aaaaaaa", &shorturl.ShortURLUpdate{OriginalURL: &url}).
+		Return(&shorturl.ShortURLWithExpireTime{
+			ShortUrl: &shorturl.ShortURL{
+				ShortURL:    "aaaaaaa",
+				OriginalURL: url,
+			},
+			ExpireAt: time.Now(),
+		}, nil)
+
+	controller.UpdateShortURL(ctx)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("unexpected status code %d", w.Code)
+	}
+}
+
+func TestUpdateShortURLResponseBadRequestIfBodyIsEmpty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	_, controller := createController(ctrl)
+	w := httptest.NewRecorder()
+	ctx := createGinContext(w)
+	setPostRequest(ctx, struct{}{})
+	ctx.Request.Method = http.MethodPatch
+	ctx.Params = []gin.Param{{Key: "id", Value: "aaaaaaa"}}
+
+	controller.UpdateShortURL(ctx)
+
+	if len(ctx.Errors) != 1 {
+		t.Fatal("context errors size is not equal to one")
+	}
+	if _, ok := ctx.Errors[0].Err.(*myerror.ValidationError); !ok {
+		t.Error("context error is not ValidationError")
+	}
+}
+
+func TestUpdateShortURLResponseBadRequestIfURLFormatIsInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	_, controller := createController(ctrl)
+	w := httptest.NewRecorder()
+	ctx := createGinContext(w)
+	setPostRequest(ctx, struct {
+		URL string `json:"url"`
+	}{"ftp://pkg.go.dev"})
+	ctx.Request.Method = http.MethodPatch
+	ctx.Params = []gin.Param{{Key: "id", Value: "aaaaaaa"}}
+
+	controller.UpdateShortURL(ctx)
+
+	if len(ctx.Errors) != 1 {
+		t.Fatal("context errors size is not equal to one")
+	}
+	if _, ok := ctx.Errors[0].Err.(*myerror.ValidationError); !ok {
+		t.Error("context error is not ValidationError")
+	}
+}
+
+func TestDeleteShortURLResponseNoContent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockService, controller := createController(ctrl)
+	w := httptest.NewRecorder()
+	ctx := createGinContext(w)
+	ctx.Request.Method = http.MethodDelete
+	ctx.Params = []gin.Param{{Key: "id", Value: "aaaaaaa"}}
+	mockService.EXPECT().DeleteShortURL(ctx, "", "aaaaaaa").Return(nil)
+
+	controller.DeleteShortURL(ctx)
+	ctx.Writer.WriteHeaderNow()
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("unexpected status code %d", w.Code)
+	}
+}
+
+func TestDeleteShortURLSetContextErrorIfServiceReturnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockService, controller := createController(ctrl)
+	w := httptest.NewRecorder()
+	ctx := createGinContext(w)
+	ctx.Request.Method = http.MethodDelete
+	ctx.Params = []gin.Param{{Key: "id", Value: "aaaaaaa"}}
+	mockErr := myerror.NewNotFoundError("short url", "aaaaaaa")
+	mockService.EXPECT().DeleteShortURL(ctx, "", "aaaaaaa").Return(mockErr)
+
+	controller.DeleteShortURL(ctx)
+
+	if len(ctx.Errors) != 1 || ctx.Errors[0].Err != mockErr {
+		t.Error("unexpected error")
+	}
+}
+
 func createController(ctrl *gomock.Controller) (*mock_shorturl.MockService, shorturl.Controller) {
 	mockService := mock_shorturl.NewMockService(ctrl)
-	controller := shorturl.NewController(mockService, BASE_URL)
+	mockClickRecorder := mock_shorturl.NewMockClickRecorder(ctrl)
+	mockClickRecorder.EXPECT().RecordClick(gomock.Any(), gomock.Any()).AnyTimes()
+	controller := shorturl.NewController(mockService, BASE_URL, mockClickRecorder)
 
 	return mockService, *controller
 }
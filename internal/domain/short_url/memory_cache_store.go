@@ -0,0 +1,73 @@
+package shorturl
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/utils"
+)
+
+// MEMORY_CACHE_SWEEP_SIZE is the number of keys which triggers removing expired entries.
+const MEMORY_CACHE_SWEEP_SIZE = 10000
+
+type memoryCacheEntry struct {
+	value    string
+	expireAt time.Time
+}
+
+type MemoryCacheStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+	time    utils.TimeUtil
+}
+
+func NewMemoryCacheStore(t utils.TimeUtil) *MemoryCacheStore {
+	return &MemoryCacheStore{entries: make(map[string]memoryCacheEntry), time: t}
+}
+
+func (m *MemoryCacheStore) Get(c context.Context, key string) (*string, error) {
+	value, _, err := m.GetWithTTL(c, key)
+	return value, err
+}
+
+func (m *MemoryCacheStore) GetWithTTL(c context.Context, key string) (*string, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, 0, nil
+	}
+	ttl := entry.expireAt.Sub(m.time.Now())
+	if ttl <= 0 {
+		delete(m.entries, key)
+		return nil, 0, nil
+	}
+	return &entry.value, ttl, nil
+}
+
+func (m *MemoryCacheStore) Set(c context.Context, key, value string, expireSecond uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.time.Now()
+	if len(m.entries) >= MEMORY_CACHE_SWEEP_SIZE {
+		for k, entry := range m.entries {
+			if !now.Before(entry.expireAt) {
+				delete(m.entries, k)
+			}
+		}
+	}
+
+	m.entries[key] = memoryCacheEntry{value, now.Add(time.Duration(expireSecond) * time.Second)}
+	return nil
+}
+
+func (m *MemoryCacheStore) Delete(c context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
@@ -0,0 +1,60 @@
+package shorturl_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
+	"github.com/WeiAnAn/url-shortener/internal/utils"
+	mock_utils "github.com/WeiAnAn/url-shortener/internal/utils/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+func TestMemoryCacheStoreGetReturnValueBeforeExpired(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	now := time.Now()
+	tu := mock_utils.NewMockTimeUtil(mockCtrl)
+	tu.EXPECT().Now().Return(now)
+	tu.EXPECT().Now().Return(now.Add(9 * time.Second))
+	tu.EXPECT().Now().Return(now.Add(10 * time.Second))
+
+	store := shorturl.NewMemoryCacheStore(tu)
+	c := context.Background()
+	store.Set(c, "short", "https://example.com/long", 10)
+
+	value, _ := store.Get(c, "short")
+	if value == nil || *value != "https://example.com/long" {
+		t.Errorf("expected cached value, got %v", value)
+	}
+
+	value, _ = store.Get(c, "short")
+	if value != nil {
+		t.Errorf("expected expired value to be nil, got %v", *value)
+	}
+}
+
+func TestMemoryCacheStoreKeepNegativeCache(t *testing.T) {
+	store := shorturl.NewMemoryCacheStore(&utils.RealTime{})
+	c := context.Background()
+	store.Set(c, "short", "", 10)
+
+	value, _ := store.Get(c, "short")
+	if value == nil || *value != "" {
+		t.Errorf("expected empty value, got %v", value)
+	}
+}
+
+func TestMemoryCacheStoreDelete(t *testing.T) {
+	store := shorturl.NewMemoryCacheStore(&utils.RealTime{})
+	c := context.Background()
+	store.Set(c, "short", "https://example.com/long", 10)
+	store.Delete(c, "short")
+
+	value, _ := store.Get(c, "short")
+	if value != nil {
+		t.Errorf("expected deleted value to be nil, got %v", *value)
+	}
+}
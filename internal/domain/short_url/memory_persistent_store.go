@@ -0,0 +1,136 @@
+package shorturl
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryPersistentStore keeps short urls in process. Data is lost when the process exits,
+// it is meant for local development and tests.
+type MemoryPersistentStore struct {
+	mu        sync.RWMutex
+	shortURLs map[string]ShortURLWithExpireTime
+}
+
+func NewMemoryPersistentStore() *MemoryPersistentStore {
+	return &MemoryPersistentStore{shortURLs: make(map[string]ShortURLWithExpireTime)}
+}
+
+func (m *MemoryPersistentStore) Save(c context.Context, shortUrl *ShortURLWithExpireTime) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.save(shortUrl)
+}
+
+func (m *MemoryPersistentStore) SaveMany(c context.Context, shortUrls []*ShortURLWithExpireTime) ([]error, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	errs := make([]error, len(shortUrls))
+	for i, shortUrl := range shortUrls {
+		errs[i] = m.save(shortUrl)
+	}
+	return errs, nil
+}
+
+func (m *MemoryPersistentStore) FindByShortURL(c context.Context, shortURL string) (*ShortURLWithExpireTime, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	url, ok := m.shortURLs[shortURL]
+	if !ok {
+		return nil, nil
+	}
+	return copyShortURL(&url), nil
+}
+
+func (m *MemoryPersistentStore) FindUnexpiredByShortURL(c context.Context, shortURL string) (*ShortURLWithExpireTime, bool, error) {
+	url, err := m.FindByShortURL(c, shortURL)
+	if err != nil {
+		return nil, false, err
+	}
+	url, expired := unexpired(url, time.Now())
+	return url, expired, nil
+}
+
+func (m *MemoryPersistentStore) FindUnexpiredByURLHash(c context.Context, ownerID, urlHash string) (*ShortURLWithExpireTime, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var found *ShortURLWithExpireTime
+	now := time.Now()
+	for _, url := range m.shortURLs {
+		if url.OwnerID != ownerID || !url.ExpireAt.After(now) || URLHash(url.ShortUrl.OriginalURL) != urlHash {
+			continue
+		}
+		if found == nil || url.ExpireAt.After(found.ExpireAt) {
+			found = copyShortURL(&url)
+		}
+	}
+	return found, nil
+}
+
+func (m *MemoryPersistentStore) Update(c context.Context, shortURL string, update *ShortURLUpdate) (*ShortURLWithExpireTime, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	url, ok := m.shortURLs[shortURL]
+	if !ok {
+		return nil, nil
+	}
+	if update.OriginalURL != nil {
+		url.ShortUrl.OriginalURL = *update.OriginalURL
+	}
+	if update.ExpireAt != nil {
+		url.ExpireAt = *update.ExpireAt
+	}
+	m.shortURLs[shortURL] = url
+
+	return copyShortURL(&url), nil
+}
+
+func (m *MemoryPersistentStore) Delete(c context.Context, shortURL string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.shortURLs[shortURL]
+	delete(m.shortURLs, shortURL)
+	return ok, nil
+}
+
+func (m *MemoryPersistentStore) ForEachShortURL(c context.Context, fn func(shortURL string) error) error {
+	m.mu.RLock()
+	shortURLs := make([]string, 0, len(m.shortURLs))
+	for shortURL := range m.shortURLs {
+		shortURLs = append(shortURLs, shortURL)
+	}
+	m.mu.RUnlock()
+
+	for _, shortURL := range shortURLs {
+		err := fn(shortURL)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MemoryPersistentStore) save(shortUrl *ShortURLWithExpireTime) error {
+	if _, ok := m.shortURLs[shortUrl.ShortUrl.ShortURL]; ok {
+		return NewDuplicateShortURLError(shortUrl.ShortUrl.ShortURL)
+	}
+	m.shortURLs[shortUrl.ShortUrl.ShortURL] = *copyShortURL(shortUrl)
+	return nil
+}
+
+// copyShortURL prevents callers from modifying the stored short urls.
+func copyShortURL(url *ShortURLWithExpireTime) *ShortURLWithExpireTime {
+	shortURL := *url.ShortUrl
+	return &ShortURLWithExpireTime{
+		ShortUrl: &shortURL,
+		ExpireAt: url.ExpireAt,
+		OwnerID:  url.OwnerID,
+	}
+}
@@ -0,0 +1,152 @@
+package shorturl_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
+)
+
+func newMemoryShortURL(short string, expireAt time.Time) *shorturl.ShortURLWithExpireTime {
+	return &shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{
+			ShortURL:    short,
+			OriginalURL: "https://example.com/long",
+		},
+		ExpireAt: expireAt,
+		OwnerID:  "owner",
+	}
+}
+
+func TestMemoryPersistentStoreSaveReturnDuplicateError(t *testing.T) {
+	store := shorturl.NewMemoryPersistentStore()
+	c := context.Background()
+
+	err := store.Save(c, newMemoryShortURL("short", time.Now().Add(time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.Save(c, newMemoryShortURL("short", time.Now().Add(time.Hour)))
+	var duplicateErr *shorturl.DuplicateShortURLError
+	if !errors.As(err, &duplicateErr) {
+		t.Errorf("expected DuplicateShortURLError, got %v", err)
+	}
+}
+
+func TestMemoryPersistentStoreSaveManyReturnErrorPerItem(t *testing.T) {
+	store := shorturl.NewMemoryPersistentStore()
+	c := context.Background()
+	expireAt := time.Now().Add(time.Hour)
+	store.Save(c, newMemoryShortURL("taken", expireAt))
+
+	errs, err := store.SaveMany(c, []*shorturl.ShortURLWithExpireTime{
+		newMemoryShortURL("first", expireAt),
+		newMemoryShortURL("taken", expireAt),
+		newMemoryShortURL("first", expireAt),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var duplicateErr *shorturl.DuplicateShortURLError
+	if errs[0] != nil || !errors.As(errs[1], &duplicateErr) || !errors.As(errs[2], &duplicateErr) {
+		t.Errorf("unexpected errors %v", errs)
+	}
+}
+
+func TestMemoryPersistentStoreFindUnexpiredByShortURLIgnoreExpired(t *testing.T) {
+	store := shorturl.NewMemoryPersistentStore()
+	c := context.Background()
+	store.Save(c, newMemoryShortURL("expired", time.Now().Add(-time.Hour)))
+
+	url, expired, err := store.FindUnexpiredByShortURL(c, "expired")
+	if err != nil || url != nil || !expired {
+		t.Errorf("expected nil and expired, got %v %v %v", url, expired, err)
+	}
+
+	_, expired, _ = store.FindUnexpiredByShortURL(c, "missing")
+	if expired {
+		t.Error("expected missing short url not to be expired")
+	}
+
+	url, err = store.FindByShortURL(c, "expired")
+	if err != nil || url == nil {
+		t.Errorf("expected expired short url, got %v %v", url, err)
+	}
+}
+
+func TestMemoryPersistentStoreFindUnexpiredByURLHash(t *testing.T) {
+	store := shorturl.NewMemoryPersistentStore()
+	c := context.Background()
+	expireAt := time.Now().Add(2 * time.Hour)
+	other := newMemoryShortURL("other", time.Now().Add(3*time.Hour))
+	other.OwnerID = "other"
+	store.SaveMany(c, []*shorturl.ShortURLWithExpireTime{
+		newMemoryShortURL("expired", time.Now().Add(-time.Second)),
+		newMemoryShortURL("short", time.Now().Add(time.Hour)),
+		newMemoryShortURL("long", expireAt),
+		other,
+	})
+
+	url, err := store.FindUnexpiredByURLHash(c, "owner", shorturl.URLHash("HTTPS://example.com/long"))
+	if err != nil || url == nil || url.ShortUrl.ShortURL != "long" {
+		t.Errorf("expected the short url expiring last, got %v %v", url, err)
+	}
+
+	url, err = store.FindUnexpiredByURLHash(c, "owner", shorturl.URLHash("https://example.com/LONG"))
+	if err != nil || url != nil {
+		t.Errorf("expected the path to be case sensitive, got %v %v", url, err)
+	}
+}
+
+func TestMemoryPersistentStoreReturnCopy(t *testing.T) {
+	store := shorturl.NewMemoryPersistentStore()
+	c := context.Background()
+	saved := newMemoryShortURL("short", time.Now().Add(time.Hour))
+	store.Save(c, saved)
+
+	saved.ShortUrl.OriginalURL = "https://example.com/changed"
+	url, _ := store.FindByShortURL(c, "short")
+	url.ShortUrl.OriginalURL = "https://example.com/changed"
+
+	url, _ = store.FindByShortURL(c, "short")
+	if url.ShortUrl.OriginalURL != "https://example.com/long" {
+		t.Error("stored short url is modified from outside")
+	}
+}
+
+func TestMemoryPersistentStoreUpdate(t *testing.T) {
+	store := shorturl.NewMemoryPersistentStore()
+	c := context.Background()
+	store.Save(c, newMemoryShortURL("short", time.Now().Add(time.Hour)))
+
+	originalURL := "https://example.com/new"
+	url, err := store.Update(c, "short", &shorturl.ShortURLUpdate{OriginalURL: &originalURL})
+	if err != nil || url.ShortUrl.OriginalURL != originalURL {
+		t.Errorf("update failed, got %v %v", url, err)
+	}
+
+	url, err = store.Update(c, "missing", &shorturl.ShortURLUpdate{OriginalURL: &originalURL})
+	if err != nil || url != nil {
+		t.Errorf("expected nil for missing short url, got %v %v", url, err)
+	}
+}
+
+func TestMemoryPersistentStoreDelete(t *testing.T) {
+	store := shorturl.NewMemoryPersistentStore()
+	c := context.Background()
+	store.Save(c, newMemoryShortURL("short", time.Now().Add(time.Hour)))
+
+	deleted, err := store.Delete(c, "short")
+	if err != nil || !deleted {
+		t.Errorf("expected deleted, got %v %v", deleted, err)
+	}
+
+	deleted, err = store.Delete(c, "short")
+	if err != nil || deleted {
+		t.Errorf("expected not deleted, got %v %v", deleted, err)
+	}
+}
@@ -0,0 +1,72 @@
+package shorturl
+
+import (
+	"context"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/metrics"
+)
+
+// MetricsCacheStore records the latency of every operation of the wrapped store, and whether reads hit.
+// An empty value cached for a missing short url is a hit, since it saves the persistent store lookup.
+type MetricsCacheStore struct {
+	cacheStore CacheStore
+	name       string
+	metrics    *metrics.Metrics
+}
+
+// NewMetricsCacheStore labels the metrics with name, e.g. "redis_cache".
+func NewMetricsCacheStore(cs CacheStore, name string, m *metrics.Metrics) *MetricsCacheStore {
+	return &MetricsCacheStore{cacheStore: cs, name: name, metrics: m}
+}
+
+func (s *MetricsCacheStore) Get(c context.Context, key string) (*string, error) {
+	start := time.Now()
+	value, err := s.cacheStore.Get(c, key)
+	s.metrics.ObserveStoreOperation(s.name, "Get", start, err)
+	s.observeLookup(value, err)
+	return value, err
+}
+
+func (s *MetricsCacheStore) Set(c context.Context, key, value string, expireSecond uint) error {
+	start := time.Now()
+	err := s.cacheStore.Set(c, key, value, expireSecond)
+	s.metrics.ObserveStoreOperation(s.name, "Set", start, err)
+	return err
+}
+
+func (s *MetricsCacheStore) Delete(c context.Context, key string) error {
+	start := time.Now()
+	err := s.cacheStore.Delete(c, key)
+	s.metrics.ObserveStoreOperation(s.name, "Delete", start, err)
+	return err
+}
+
+func (s *MetricsCacheStore) observeLookup(value *string, err error) {
+	if err != nil {
+		return
+	}
+	result := metrics.RESULT_HIT
+	if value == nil {
+		result = metrics.RESULT_MISS
+	}
+	s.metrics.CacheLookups.WithLabelValues(s.name, result).Inc()
+}
+
+// MetricsTTLCacheStore is the MetricsCacheStore of a TTLCacheStore, so it can be put behind a TwoTierCacheStore.
+type MetricsTTLCacheStore struct {
+	*MetricsCacheStore
+	ttlCacheStore TTLCacheStore
+}
+
+func NewMetricsTTLCacheStore(cs TTLCacheStore, name string, m *metrics.Metrics) *MetricsTTLCacheStore {
+	return &MetricsTTLCacheStore{NewMetricsCacheStore(cs, name, m), cs}
+}
+
+func (s *MetricsTTLCacheStore) GetWithTTL(c context.Context, key string) (*string, time.Duration, error) {
+	start := time.Now()
+	value, ttl, err := s.ttlCacheStore.GetWithTTL(c, key)
+	s.metrics.ObserveStoreOperation(s.name, "GetWithTTL", start, err)
+	s.observeLookup(value, err)
+	return value, ttl, err
+}
@@ -0,0 +1,36 @@
+package shorturl_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
+	"github.com/WeiAnAn/url-shortener/internal/metrics"
+	"github.com/WeiAnAn/url-shortener/internal/utils"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsCacheStoreCountHitsAndMisses(t *testing.T) {
+	m := metrics.New()
+	store := shorturl.NewMetricsTTLCacheStore(shorturl.NewMemoryCacheStore(&utils.RealTime{}), "memory", m)
+	c := context.Background()
+
+	store.Set(c, "short", "https://example.com/long", 10)
+	store.Set(c, "missing", "", 10)
+
+	store.Get(c, "short")
+	store.Get(c, "missing")
+	store.Get(c, "unknown")
+	value, ttl, _ := store.GetWithTTL(c, "short")
+	if value == nil || ttl <= 0 || ttl > 10*time.Second {
+		t.Errorf("unexpected value %v with ttl %v", value, ttl)
+	}
+
+	if hits := testutil.ToFloat64(m.CacheLookups.WithLabelValues("memory", metrics.RESULT_HIT)); hits != 3 {
+		t.Errorf("expected 3 hits including the cached empty value, got %v", hits)
+	}
+	if misses := testutil.ToFloat64(m.CacheLookups.WithLabelValues("memory", metrics.RESULT_MISS)); misses != 1 {
+		t.Errorf("expected 1 miss, got %v", misses)
+	}
+}
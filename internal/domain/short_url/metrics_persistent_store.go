@@ -0,0 +1,76 @@
+package shorturl
+
+import (
+	"context"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/metrics"
+)
+
+// MetricsPersistentStore records the latency of every operation of the wrapped store.
+type MetricsPersistentStore struct {
+	persistentStore PersistentStore
+	name            string
+	metrics         *metrics.Metrics
+}
+
+// NewMetricsPersistentStore labels the latencies with name, e.g. "mongo".
+func NewMetricsPersistentStore(ps PersistentStore, name string, m *metrics.Metrics) *MetricsPersistentStore {
+	return &MetricsPersistentStore{persistentStore: ps, name: name, metrics: m}
+}
+
+func (s *MetricsPersistentStore) Save(c context.Context, shortUrl *ShortURLWithExpireTime) error {
+	start := time.Now()
+	err := s.persistentStore.Save(c, shortUrl)
+	s.metrics.ObserveStoreOperation(s.name, "Save", start, err)
+	return err
+}
+
+func (s *MetricsPersistentStore) SaveMany(c context.Context, shortUrls []*ShortURLWithExpireTime) ([]error, error) {
+	start := time.Now()
+	errs, err := s.persistentStore.SaveMany(c, shortUrls)
+	s.metrics.ObserveStoreOperation(s.name, "SaveMany", start, err)
+	return errs, err
+}
+
+func (s *MetricsPersistentStore) FindUnexpiredByShortURL(c context.Context, shortURL string) (*ShortURLWithExpireTime, bool, error) {
+	start := time.Now()
+	url, expired, err := s.persistentStore.FindUnexpiredByShortURL(c, shortURL)
+	s.metrics.ObserveStoreOperation(s.name, "FindUnexpiredByShortURL", start, err)
+	return url, expired, err
+}
+
+func (s *MetricsPersistentStore) FindByShortURL(c context.Context, shortURL string) (*ShortURLWithExpireTime, error) {
+	start := time.Now()
+	url, err := s.persistentStore.FindByShortURL(c, shortURL)
+	s.metrics.ObserveStoreOperation(s.name, "FindByShortURL", start, err)
+	return url, err
+}
+
+func (s *MetricsPersistentStore) FindUnexpiredByURLHash(c context.Context, ownerID, urlHash string) (*ShortURLWithExpireTime, error) {
+	start := time.Now()
+	url, err := s.persistentStore.FindUnexpiredByURLHash(c, ownerID, urlHash)
+	s.metrics.ObserveStoreOperation(s.name, "FindUnexpiredByURLHash", start, err)
+	return url, err
+}
+
+func (s *MetricsPersistentStore) Update(c context.Context, shortURL string, update *ShortURLUpdate) (*ShortURLWithExpireTime, error) {
+	start := time.Now()
+	url, err := s.persistentStore.Update(c, shortURL, update)
+	s.metrics.ObserveStoreOperation(s.name, "Update", start, err)
+	return url, err
+}
+
+func (s *MetricsPersistentStore) Delete(c context.Context, shortURL string) (bool, error) {
+	start := time.Now()
+	deleted, err := s.persistentStore.Delete(c, shortURL)
+	s.metrics.ObserveStoreOperation(s.name, "Delete", start, err)
+	return deleted, err
+}
+
+func (s *MetricsPersistentStore) ForEachShortURL(c context.Context, fn func(shortURL string) error) error {
+	start := time.Now()
+	err := s.persistentStore.ForEachShortURL(c, fn)
+	s.metrics.ObserveStoreOperation(s.name, "ForEachShortURL", start, err)
+	return err
+}
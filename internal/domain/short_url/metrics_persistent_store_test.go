@@ -0,0 +1,61 @@
+package shorturl_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
+	mock_shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url/mocks"
+	"github.com/WeiAnAn/url-shortener/internal/metrics"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsPersistentStoreFindUnexpiredDelegateToStore(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	m := metrics.New()
+	ps := mock_shorturl.NewMockPersistentStore(mockCtrl)
+	store := shorturl.NewMetricsPersistentStore(ps, "mongo", m)
+	c := context.Background()
+
+	url := &shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{ShortURL: "short", OriginalURL: "https://example.com/long"},
+		ExpireAt: time.Now().Add(time.Minute),
+	}
+	ps.EXPECT().FindUnexpiredByShortURL(c, "short").Return(url, false, nil)
+
+	result, expired, err := store.FindUnexpiredByShortURL(c, "short")
+	if err != nil || result != url || expired {
+		t.Errorf("expected the short url of the wrapped store, got %v %v", result, err)
+	}
+	if count := testutil.CollectAndCount(m.StoreOperationDuration); count != 1 {
+		t.Errorf("expected 1 series, got %d", count)
+	}
+}
+
+func TestMetricsPersistentStoreObserveOperationResult(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	m := metrics.New()
+	ps := mock_shorturl.NewMockPersistentStore(mockCtrl)
+	store := shorturl.NewMetricsPersistentStore(ps, "mongo", m)
+	c := context.Background()
+
+	ps.EXPECT().Delete(c, "short").Return(true, nil)
+	ps.EXPECT().Delete(c, "short").Return(false, errors.New("connection refused"))
+
+	store.Delete(c, "short")
+	_, err := store.Delete(c, "short")
+	if err == nil {
+		t.Error("expected error to be returned")
+	}
+
+	if count := testutil.CollectAndCount(m.StoreOperationDuration); count != 2 {
+		t.Errorf("expected ok and error series, got %d", count)
+	}
+}
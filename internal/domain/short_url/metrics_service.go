@@ -0,0 +1,68 @@
+package shorturl
+
+import (
+	"context"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/metrics"
+)
+
+// MetricsService counts redirect lookups, created and reused short urls of the wrapped service.
+type MetricsService struct {
+	Service
+	metrics *metrics.Metrics
+}
+
+func NewMetricsService(s Service, m *metrics.Metrics) *MetricsService {
+	return &MetricsService{s, m}
+}
+
+func (s *MetricsService) CreateShortURL(c context.Context, ownerID, originalURL string, expireAt time.Time) (*ShortURLWithExpireTime, bool, error) {
+	shortURL, reused, err := s.Service.CreateShortURL(c, ownerID, originalURL, expireAt)
+	if err == nil {
+		s.countCreated(reused)
+	}
+	return shortURL, reused, err
+}
+
+func (s *MetricsService) CreateShortURLWithAlias(c context.Context, ownerID, alias, originalURL string, expireAt time.Time) (*ShortURLWithExpireTime, error) {
+	shortURL, err := s.Service.CreateShortURLWithAlias(c, ownerID, alias, originalURL, expireAt)
+	if err == nil {
+		s.metrics.ShortURLsCreated.Inc()
+	}
+	return shortURL, err
+}
+
+func (s *MetricsService) CreateShortURLs(c context.Context, ownerID string, inputs []*CreateShortURLInput) ([]*CreateShortURLResult, error) {
+	results, err := s.Service.CreateShortURLs(c, ownerID, inputs)
+	if err != nil {
+		return results, err
+	}
+	for _, result := range results {
+		if result.Err == nil {
+			s.countCreated(result.Reused)
+		}
+	}
+	return results, nil
+}
+
+func (s *MetricsService) countCreated(reused bool) {
+	if reused {
+		s.metrics.ShortURLsReused.Inc()
+		return
+	}
+	s.metrics.ShortURLsCreated.Inc()
+}
+
+func (s *MetricsService) GetOriginalURL(c context.Context, short string) (*ShortURL, error) {
+	shortURL, err := s.Service.GetOriginalURL(c, short)
+	if err != nil {
+		return nil, err
+	}
+	result := metrics.RESULT_HIT
+	if shortURL == nil {
+		result = metrics.RESULT_MISS
+	}
+	s.metrics.Redirects.WithLabelValues(result).Inc()
+	return shortURL, nil
+}
@@ -0,0 +1,72 @@
+package shorturl_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
+	mock_shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url/mocks"
+	"github.com/WeiAnAn/url-shortener/internal/metrics"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsServiceCountRedirects(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	m := metrics.New()
+	s := mock_shorturl.NewMockService(mockCtrl)
+	service := shorturl.NewMetricsService(s, m)
+	c := context.Background()
+
+	s.EXPECT().GetOriginalURL(c, "found").Return(&shorturl.ShortURL{ShortURL: "found", OriginalURL: "https://example.com"}, nil)
+	s.EXPECT().GetOriginalURL(c, "missing").Return(nil, nil)
+	s.EXPECT().GetOriginalURL(c, "broken").Return(nil, errors.New("connection refused"))
+
+	service.GetOriginalURL(c, "found")
+	service.GetOriginalURL(c, "missing")
+	service.GetOriginalURL(c, "broken")
+
+	if hits := testutil.ToFloat64(m.Redirects.WithLabelValues(metrics.RESULT_HIT)); hits != 1 {
+		t.Errorf("expected 1 hit, got %v", hits)
+	}
+	if misses := testutil.ToFloat64(m.Redirects.WithLabelValues(metrics.RESULT_MISS)); misses != 1 {
+		t.Errorf("expected 1 miss, got %v", misses)
+	}
+}
+
+func TestMetricsServiceCountCreatedShortURLs(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	m := metrics.New()
+	s := mock_shorturl.NewMockService(mockCtrl)
+	service := shorturl.NewMetricsService(s, m)
+	c := context.Background()
+	expireAt := time.Now().Add(time.Hour)
+
+	s.EXPECT().CreateShortURL(c, "owner", "https://example.com", expireAt).Return(&shorturl.ShortURLWithExpireTime{}, false, nil)
+	s.EXPECT().CreateShortURL(c, "owner", "https://example.org", expireAt).Return(&shorturl.ShortURLWithExpireTime{}, true, nil)
+	s.EXPECT().CreateShortURLWithAlias(c, "owner", "alias", "https://example.com", expireAt).Return(nil, errors.New("alias is already taken"))
+	s.EXPECT().CreateShortURLs(c, "owner", gomock.Any()).Return([]*shorturl.CreateShortURLResult{
+		{ShortURL: &shorturl.ShortURLWithExpireTime{}},
+		{Err: errors.New("alias is already taken")},
+		{ShortURL: &shorturl.ShortURLWithExpireTime{}},
+		{ShortURL: &shorturl.ShortURLWithExpireTime{}, Reused: true},
+	}, nil)
+
+	service.CreateShortURL(c, "owner", "https://example.com", expireAt)
+	service.CreateShortURL(c, "owner", "https://example.org", expireAt)
+	service.CreateShortURLWithAlias(c, "owner", "alias", "https://example.com", expireAt)
+	service.CreateShortURLs(c, "owner", nil)
+
+	if created := testutil.ToFloat64(m.ShortURLsCreated); created != 3 {
+		t.Errorf("expected 3 created short urls, got %v", created)
+	}
+	if reused := testutil.ToFloat64(m.ShortURLsReused); reused != 2 {
+		t.Errorf("expected 2 reused short urls, got %v", reused)
+	}
+}
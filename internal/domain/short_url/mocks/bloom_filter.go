@@ -0,0 +1,64 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/domain/short_url/bloom_filter.go
+
+// Package mock_shorturl is a generated GoMock package.
+package mock_shorturl
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockShortURLFilter is a mock of ShortURLFilter interface.
+type MockShortURLFilter struct {
+	ctrl     *gomock.Controller
+	recorder *MockShortURLFilterMockRecorder
+}
+
+// MockShortURLFilterMockRecorder is the mock recorder for MockShortURLFilter.
+type MockShortURLFilterMockRecorder struct {
+	mock *MockShortURLFilter
+}
+
+// NewMockShortURLFilter creates a new mock instance.
+func NewMockShortURLFilter(ctrl *gomock.Controller) *MockShortURLFilter {
+	mock := &MockShortURLFilter{ctrl: ctrl}
+	mock.recorder = &MockShortURLFilterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockShortURLFilter) EXPECT() *MockShortURLFilterMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockShortURLFilter) Add(c context.Context, shortURL string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", c, shortURL)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockShortURLFilterMockRecorder) Add(c, shortURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockShortURLFilter)(nil).Add), c, shortURL)
+}
+
+// MightContain mocks base method.
+func (m *MockShortURLFilter) MightContain(c context.Context, shortURL string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MightContain", c, shortURL)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MightContain indicates an expected call of MightContain.
+func (mr *MockShortURLFilterMockRecorder) MightContain(c, shortURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MightContain", reflect.TypeOf((*MockShortURLFilter)(nil).MightContain), c, shortURL)
+}
@@ -34,6 +34,20 @@ func (m *MockCacheStore) EXPECT() *MockCacheStoreMockRecorder {
 	return m.recorder
 }
 
+// Delete mocks base method.
+func (m *MockCacheStore) Delete(c context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", c, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockCacheStoreMockRecorder) Delete(c, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCacheStore)(nil).Delete), c, key)
+}
+
 // Get mocks base method.
 func (m *MockCacheStore) Get(c context.Context, key string) (*string, error) {
 	m.ctrl.T.Helper()
@@ -35,21 +35,81 @@ func (m *MockPersistentStore) EXPECT() *MockPersistentStoreMockRecorder {
 	return m.recorder
 }
 
-// FindUnexpiredByShortURL mocks base method.
-func (m *MockPersistentStore) FindUnexpiredByShortURL(c context.Context, shortURL string) (*shorturl.ShortURLWithExpireTime, error) {
+// Delete mocks base method.
+func (m *MockPersistentStore) Delete(c context.Context, shortURL string) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindUnexpiredByShortURL", c, shortURL)
+	ret := m.ctrl.Call(m, "Delete", c, shortURL)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockPersistentStoreMockRecorder) Delete(c, shortURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPersistentStore)(nil).Delete), c, shortURL)
+}
+
+// FindByShortURL mocks base method.
+func (m *MockPersistentStore) FindByShortURL(c context.Context, shortURL string) (*shorturl.ShortURLWithExpireTime, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByShortURL", c, shortURL)
 	ret0, _ := ret[0].(*shorturl.ShortURLWithExpireTime)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// FindByShortURL indicates an expected call of FindByShortURL.
+func (mr *MockPersistentStoreMockRecorder) FindByShortURL(c, shortURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByShortURL", reflect.TypeOf((*MockPersistentStore)(nil).FindByShortURL), c, shortURL)
+}
+
+// FindUnexpiredByShortURL mocks base method.
+func (m *MockPersistentStore) FindUnexpiredByShortURL(c context.Context, shortURL string) (*shorturl.ShortURLWithExpireTime, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUnexpiredByShortURL", c, shortURL)
+	ret0, _ := ret[0].(*shorturl.ShortURLWithExpireTime)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
 // FindUnexpiredByShortURL indicates an expected call of FindUnexpiredByShortURL.
 func (mr *MockPersistentStoreMockRecorder) FindUnexpiredByShortURL(c, shortURL interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUnexpiredByShortURL", reflect.TypeOf((*MockPersistentStore)(nil).FindUnexpiredByShortURL), c, shortURL)
 }
 
+// FindUnexpiredByURLHash mocks base method.
+func (m *MockPersistentStore) FindUnexpiredByURLHash(c context.Context, ownerID, urlHash string) (*shorturl.ShortURLWithExpireTime, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUnexpiredByURLHash", c, ownerID, urlHash)
+	ret0, _ := ret[0].(*shorturl.ShortURLWithExpireTime)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUnexpiredByURLHash indicates an expected call of FindUnexpiredByURLHash.
+func (mr *MockPersistentStoreMockRecorder) FindUnexpiredByURLHash(c, ownerID, urlHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUnexpiredByURLHash", reflect.TypeOf((*MockPersistentStore)(nil).FindUnexpiredByURLHash), c, ownerID, urlHash)
+}
+
+// ForEachShortURL mocks base method.
+func (m *MockPersistentStore) ForEachShortURL(c context.Context, fn func(string) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForEachShortURL", c, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForEachShortURL indicates an expected call of ForEachShortURL.
+func (mr *MockPersistentStoreMockRecorder) ForEachShortURL(c, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForEachShortURL", reflect.TypeOf((*MockPersistentStore)(nil).ForEachShortURL), c, fn)
+}
+
 // Save mocks base method.
 func (m *MockPersistentStore) Save(c context.Context, shortUrl *shorturl.ShortURLWithExpireTime) error {
 	m.ctrl.T.Helper()
@@ -63,3 +123,33 @@ func (mr *MockPersistentStoreMockRecorder) Save(c, shortUrl interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockPersistentStore)(nil).Save), c, shortUrl)
 }
+
+// SaveMany mocks base method.
+func (m *MockPersistentStore) SaveMany(c context.Context, shortUrls []*shorturl.ShortURLWithExpireTime) ([]error, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveMany", c, shortUrls)
+	ret0, _ := ret[0].([]error)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveMany indicates an expected call of SaveMany.
+func (mr *MockPersistentStoreMockRecorder) SaveMany(c, shortUrls interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMany", reflect.TypeOf((*MockPersistentStore)(nil).SaveMany), c, shortUrls)
+}
+
+// Update mocks base method.
+func (m *MockPersistentStore) Update(c context.Context, shortURL string, update *shorturl.ShortURLUpdate) (*shorturl.ShortURLWithExpireTime, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", c, shortURL, update)
+	ret0, _ := ret[0].(*shorturl.ShortURLWithExpireTime)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockPersistentStoreMockRecorder) Update(c, shortURL, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockPersistentStore)(nil).Update), c, shortURL, update)
+}
@@ -7,6 +7,7 @@ package mock_shorturl
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
 	gomock "github.com/golang/mock/gomock"
@@ -35,6 +36,21 @@ func (m *MockShortURLRepository) EXPECT() *MockShortURLRepositoryMockRecorder {
 	return m.recorder
 }
 
+// Delete mocks base method.
+func (m *MockShortURLRepository) Delete(arg0 context.Context, arg1 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockShortURLRepositoryMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockShortURLRepository)(nil).Delete), arg0, arg1)
+}
+
 // FindByShortURL mocks base method.
 func (m *MockShortURLRepository) FindByShortURL(arg0 context.Context, arg1 string) (*shorturl.ShortURL, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +66,36 @@ func (mr *MockShortURLRepositoryMockRecorder) FindByShortURL(arg0, arg1 interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByShortURL", reflect.TypeOf((*MockShortURLRepository)(nil).FindByShortURL), arg0, arg1)
 }
 
+// FindUnexpiredByOriginalURL mocks base method.
+func (m *MockShortURLRepository) FindUnexpiredByOriginalURL(c context.Context, ownerID, originalURL string) (*shorturl.ShortURLWithExpireTime, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUnexpiredByOriginalURL", c, ownerID, originalURL)
+	ret0, _ := ret[0].(*shorturl.ShortURLWithExpireTime)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUnexpiredByOriginalURL indicates an expected call of FindUnexpiredByOriginalURL.
+func (mr *MockShortURLRepositoryMockRecorder) FindUnexpiredByOriginalURL(c, ownerID, originalURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUnexpiredByOriginalURL", reflect.TypeOf((*MockShortURLRepository)(nil).FindUnexpiredByOriginalURL), c, ownerID, originalURL)
+}
+
+// GetByShortURL mocks base method.
+func (m *MockShortURLRepository) GetByShortURL(arg0 context.Context, arg1 string) (*shorturl.ShortURLWithExpireTime, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByShortURL", arg0, arg1)
+	ret0, _ := ret[0].(*shorturl.ShortURLWithExpireTime)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByShortURL indicates an expected call of GetByShortURL.
+func (mr *MockShortURLRepositoryMockRecorder) GetByShortURL(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByShortURL", reflect.TypeOf((*MockShortURLRepository)(nil).GetByShortURL), arg0, arg1)
+}
+
 // Save mocks base method.
 func (m *MockShortURLRepository) Save(arg0 context.Context, arg1 *shorturl.ShortURLWithExpireTime) error {
 	m.ctrl.T.Helper()
@@ -63,3 +109,107 @@ func (mr *MockShortURLRepositoryMockRecorder) Save(arg0, arg1 interface{}) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockShortURLRepository)(nil).Save), arg0, arg1)
 }
+
+// SaveMany mocks base method.
+func (m *MockShortURLRepository) SaveMany(arg0 context.Context, arg1 []*shorturl.ShortURLWithExpireTime) ([]error, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveMany", arg0, arg1)
+	ret0, _ := ret[0].([]error)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveMany indicates an expected call of SaveMany.
+func (mr *MockShortURLRepositoryMockRecorder) SaveMany(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMany", reflect.TypeOf((*MockShortURLRepository)(nil).SaveMany), arg0, arg1)
+}
+
+// Update mocks base method.
+func (m *MockShortURLRepository) Update(arg0 context.Context, arg1 string, arg2 *shorturl.ShortURLUpdate) (*shorturl.ShortURLWithExpireTime, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*shorturl.ShortURLWithExpireTime)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockShortURLRepositoryMockRecorder) Update(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockShortURLRepository)(nil).Update), arg0, arg1, arg2)
+}
+
+// MockLocker is a mock of Locker interface.
+type MockLocker struct {
+	ctrl     *gomock.Controller
+	recorder *MockLockerMockRecorder
+}
+
+// MockLockerMockRecorder is the mock recorder for MockLocker.
+type MockLockerMockRecorder struct {
+	mock *MockLocker
+}
+
+// NewMockLocker creates a new mock instance.
+func NewMockLocker(ctrl *gomock.Controller) *MockLocker {
+	mock := &MockLocker{ctrl: ctrl}
+	mock.recorder = &MockLockerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLocker) EXPECT() *MockLockerMockRecorder {
+	return m.recorder
+}
+
+// TryLock mocks base method.
+func (m *MockLocker) TryLock(c context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TryLock", c, key, ttl)
+	ret0, _ := ret[0].(func())
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// TryLock indicates an expected call of TryLock.
+func (mr *MockLockerMockRecorder) TryLock(c, key, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TryLock", reflect.TypeOf((*MockLocker)(nil).TryLock), c, key, ttl)
+}
+
+// MockCounter is a mock of Counter interface.
+type MockCounter struct {
+	ctrl     *gomock.Controller
+	recorder *MockCounterMockRecorder
+}
+
+// MockCounterMockRecorder is the mock recorder for MockCounter.
+type MockCounterMockRecorder struct {
+	mock *MockCounter
+}
+
+// NewMockCounter creates a new mock instance.
+func NewMockCounter(ctrl *gomock.Controller) *MockCounter {
+	mock := &MockCounter{ctrl: ctrl}
+	mock.recorder = &MockCounterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCounter) EXPECT() *MockCounterMockRecorder {
+	return m.recorder
+}
+
+// Inc mocks base method.
+func (m *MockCounter) Inc() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Inc")
+}
+
+// Inc indicates an expected call of Inc.
+func (mr *MockCounterMockRecorder) Inc() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Inc", reflect.TypeOf((*MockCounter)(nil).Inc))
+}
@@ -37,18 +37,93 @@ func (m *MockShortURLGenerator) EXPECT() *MockShortURLGeneratorMockRecorder {
 }
 
 // Generate mocks base method.
-func (m *MockShortURLGenerator) Generate(arg0 int) (string, error) {
+func (m *MockShortURLGenerator) Generate(c context.Context, length int) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Generate", arg0)
+	ret := m.ctrl.Call(m, "Generate", c, length)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Generate indicates an expected call of Generate.
-func (mr *MockShortURLGeneratorMockRecorder) Generate(arg0 interface{}) *gomock.Call {
+func (mr *MockShortURLGeneratorMockRecorder) Generate(c, length interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Generate", reflect.TypeOf((*MockShortURLGenerator)(nil).Generate), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Generate", reflect.TypeOf((*MockShortURLGenerator)(nil).Generate), c, length)
+}
+
+// MockURLNormalizer is a mock of URLNormalizer interface.
+type MockURLNormalizer struct {
+	ctrl     *gomock.Controller
+	recorder *MockURLNormalizerMockRecorder
+}
+
+// MockURLNormalizerMockRecorder is the mock recorder for MockURLNormalizer.
+type MockURLNormalizerMockRecorder struct {
+	mock *MockURLNormalizer
+}
+
+// NewMockURLNormalizer creates a new mock instance.
+func NewMockURLNormalizer(ctrl *gomock.Controller) *MockURLNormalizer {
+	mock := &MockURLNormalizer{ctrl: ctrl}
+	mock.recorder = &MockURLNormalizerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockURLNormalizer) EXPECT() *MockURLNormalizerMockRecorder {
+	return m.recorder
+}
+
+// Normalize mocks base method.
+func (m *MockURLNormalizer) Normalize(rawURL string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Normalize", rawURL)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Normalize indicates an expected call of Normalize.
+func (mr *MockURLNormalizerMockRecorder) Normalize(rawURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Normalize", reflect.TypeOf((*MockURLNormalizer)(nil).Normalize), rawURL)
+}
+
+// MockURLScreener is a mock of URLScreener interface.
+type MockURLScreener struct {
+	ctrl     *gomock.Controller
+	recorder *MockURLScreenerMockRecorder
+}
+
+// MockURLScreenerMockRecorder is the mock recorder for MockURLScreener.
+type MockURLScreenerMockRecorder struct {
+	mock *MockURLScreener
+}
+
+// NewMockURLScreener creates a new mock instance.
+func NewMockURLScreener(ctrl *gomock.Controller) *MockURLScreener {
+	mock := &MockURLScreener{ctrl: ctrl}
+	mock.recorder = &MockURLScreenerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockURLScreener) EXPECT() *MockURLScreenerMockRecorder {
+	return m.recorder
+}
+
+// Screen mocks base method.
+func (m *MockURLScreener) Screen(c context.Context, rawURL string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Screen", c, rawURL)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Screen indicates an expected call of Screen.
+func (mr *MockURLScreenerMockRecorder) Screen(c, rawURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Screen", reflect.TypeOf((*MockURLScreener)(nil).Screen), c, rawURL)
 }
 
 // MockService is a mock of Service interface.
@@ -75,31 +150,106 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 }
 
 // CreateShortURL mocks base method.
-func (m *MockService) CreateShortURL(arg0 context.Context, arg1 string, arg2 time.Time) (*shorturl.ShortURLWithExpireTime, error) {
+func (m *MockService) CreateShortURL(c context.Context, ownerID, originalURL string, expireAt time.Time) (*shorturl.ShortURLWithExpireTime, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateShortURL", c, ownerID, originalURL, expireAt)
+	ret0, _ := ret[0].(*shorturl.ShortURLWithExpireTime)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateShortURL indicates an expected call of CreateShortURL.
+func (mr *MockServiceMockRecorder) CreateShortURL(c, ownerID, originalURL, expireAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShortURL", reflect.TypeOf((*MockService)(nil).CreateShortURL), c, ownerID, originalURL, expireAt)
+}
+
+// CreateShortURLWithAlias mocks base method.
+func (m *MockService) CreateShortURLWithAlias(c context.Context, ownerID, alias, originalURL string, expireAt time.Time) (*shorturl.ShortURLWithExpireTime, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateShortURL", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "CreateShortURLWithAlias", c, ownerID, alias, originalURL, expireAt)
 	ret0, _ := ret[0].(*shorturl.ShortURLWithExpireTime)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateShortURL indicates an expected call of CreateShortURL.
-func (mr *MockServiceMockRecorder) CreateShortURL(arg0, arg1, arg2 interface{}) *gomock.Call {
+// CreateShortURLWithAlias indicates an expected call of CreateShortURLWithAlias.
+func (mr *MockServiceMockRecorder) CreateShortURLWithAlias(c, ownerID, alias, originalURL, expireAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShortURLWithAlias", reflect.TypeOf((*MockService)(nil).CreateShortURLWithAlias), c, ownerID, alias, originalURL, expireAt)
+}
+
+// CreateShortURLs mocks base method.
+func (m *MockService) CreateShortURLs(c context.Context, ownerID string, inputs []*shorturl.CreateShortURLInput) ([]*shorturl.CreateShortURLResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateShortURLs", c, ownerID, inputs)
+	ret0, _ := ret[0].([]*shorturl.CreateShortURLResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateShortURLs indicates an expected call of CreateShortURLs.
+func (mr *MockServiceMockRecorder) CreateShortURLs(c, ownerID, inputs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShortURLs", reflect.TypeOf((*MockService)(nil).CreateShortURLs), c, ownerID, inputs)
+}
+
+// DeleteShortURL mocks base method.
+func (m *MockService) DeleteShortURL(c context.Context, ownerID, short string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteShortURL", c, ownerID, short)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteShortURL indicates an expected call of DeleteShortURL.
+func (mr *MockServiceMockRecorder) DeleteShortURL(c, ownerID, short interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShortURL", reflect.TypeOf((*MockService)(nil).CreateShortURL), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteShortURL", reflect.TypeOf((*MockService)(nil).DeleteShortURL), c, ownerID, short)
 }
 
 // GetOriginalURL mocks base method.
-func (m *MockService) GetOriginalURL(arg0 context.Context, arg1 string) (*shorturl.ShortURL, error) {
+func (m *MockService) GetOriginalURL(c context.Context, short string) (*shorturl.ShortURL, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOriginalURL", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetOriginalURL", c, short)
 	ret0, _ := ret[0].(*shorturl.ShortURL)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOriginalURL indicates an expected call of GetOriginalURL.
-func (mr *MockServiceMockRecorder) GetOriginalURL(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) GetOriginalURL(c, short interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOriginalURL", reflect.TypeOf((*MockService)(nil).GetOriginalURL), c, short)
+}
+
+// GetShortURL mocks base method.
+func (m *MockService) GetShortURL(c context.Context, ownerID, short string) (*shorturl.ShortURLWithExpireTime, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShortURL", c, ownerID, short)
+	ret0, _ := ret[0].(*shorturl.ShortURLWithExpireTime)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShortURL indicates an expected call of GetShortURL.
+func (mr *MockServiceMockRecorder) GetShortURL(c, ownerID, short interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShortURL", reflect.TypeOf((*MockService)(nil).GetShortURL), c, ownerID, short)
+}
+
+// UpdateShortURL mocks base method.
+func (m *MockService) UpdateShortURL(c context.Context, ownerID, short string, update *shorturl.ShortURLUpdate) (*shorturl.ShortURLWithExpireTime, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateShortURL", c, ownerID, short, update)
+	ret0, _ := ret[0].(*shorturl.ShortURLWithExpireTime)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateShortURL indicates an expected call of UpdateShortURL.
+func (mr *MockServiceMockRecorder) UpdateShortURL(c, ownerID, short, update interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOriginalURL", reflect.TypeOf((*MockService)(nil).GetOriginalURL), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateShortURL", reflect.TypeOf((*MockService)(nil).UpdateShortURL), c, ownerID, short, update)
 }
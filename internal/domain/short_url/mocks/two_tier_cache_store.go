@@ -0,0 +1,146 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/domain/short_url/two_tier_cache_store.go
+
+// Package mock_shorturl is a generated GoMock package.
+package mock_shorturl
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTTLCacheStore is a mock of TTLCacheStore interface.
+type MockTTLCacheStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockTTLCacheStoreMockRecorder
+}
+
+// MockTTLCacheStoreMockRecorder is the mock recorder for MockTTLCacheStore.
+type MockTTLCacheStoreMockRecorder struct {
+	mock *MockTTLCacheStore
+}
+
+// NewMockTTLCacheStore creates a new mock instance.
+func NewMockTTLCacheStore(ctrl *gomock.Controller) *MockTTLCacheStore {
+	mock := &MockTTLCacheStore{ctrl: ctrl}
+	mock.recorder = &MockTTLCacheStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTTLCacheStore) EXPECT() *MockTTLCacheStoreMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockTTLCacheStore) Delete(c context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", c, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockTTLCacheStoreMockRecorder) Delete(c, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTTLCacheStore)(nil).Delete), c, key)
+}
+
+// Get mocks base method.
+func (m *MockTTLCacheStore) Get(c context.Context, key string) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", c, key)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockTTLCacheStoreMockRecorder) Get(c, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockTTLCacheStore)(nil).Get), c, key)
+}
+
+// GetWithTTL mocks base method.
+func (m *MockTTLCacheStore) GetWithTTL(c context.Context, key string) (*string, time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithTTL", c, key)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(time.Duration)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetWithTTL indicates an expected call of GetWithTTL.
+func (mr *MockTTLCacheStoreMockRecorder) GetWithTTL(c, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithTTL", reflect.TypeOf((*MockTTLCacheStore)(nil).GetWithTTL), c, key)
+}
+
+// Set mocks base method.
+func (m *MockTTLCacheStore) Set(c context.Context, key, value string, expireSecond uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", c, key, value, expireSecond)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockTTLCacheStoreMockRecorder) Set(c, key, value, expireSecond interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockTTLCacheStore)(nil).Set), c, key, value, expireSecond)
+}
+
+// MockCacheInvalidator is a mock of CacheInvalidator interface.
+type MockCacheInvalidator struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheInvalidatorMockRecorder
+}
+
+// MockCacheInvalidatorMockRecorder is the mock recorder for MockCacheInvalidator.
+type MockCacheInvalidatorMockRecorder struct {
+	mock *MockCacheInvalidator
+}
+
+// NewMockCacheInvalidator creates a new mock instance.
+func NewMockCacheInvalidator(ctrl *gomock.Controller) *MockCacheInvalidator {
+	mock := &MockCacheInvalidator{ctrl: ctrl}
+	mock.recorder = &MockCacheInvalidatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheInvalidator) EXPECT() *MockCacheInvalidatorMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method.
+func (m *MockCacheInvalidator) Publish(c context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", c, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockCacheInvalidatorMockRecorder) Publish(c, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockCacheInvalidator)(nil).Publish), c, key)
+}
+
+// Subscribe mocks base method.
+func (m *MockCacheInvalidator) Subscribe(c context.Context, fn func(string)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", c, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockCacheInvalidatorMockRecorder) Subscribe(c, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockCacheInvalidator)(nil).Subscribe), c, fn)
+}
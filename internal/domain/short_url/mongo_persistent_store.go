@@ -20,36 +20,164 @@ type ShortURLDocument struct {
 	ShortURL    string    `bson:"short_url"`
 	OriginalURL string    `bson:"original_url"`
 	ExpireAt    time.Time `bson:"expire_at"`
+	OwnerID     string    `bson:"owner_id"`
+	URLHash     string    `bson:"url_hash"`
 }
 
 func NewMongoPersistentStore(c *mongo.Client, d string) *MongoPersistentStore {
 	unique := true
-	index := mongo.IndexModel{
-		Keys: bson.D{
-			bson.E{Key: "short_url", Value: 1},
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "short_url", Value: 1},
+			},
+			Options: &options.IndexOptions{Unique: &unique},
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "owner_id", Value: 1},
+				bson.E{Key: "url_hash", Value: 1},
+				bson.E{Key: "expire_at", Value: -1},
+			},
 		},
-		Options: &options.IndexOptions{Unique: &unique},
 	}
-	c.Database(d).Collection(COLLECTION_NAME).Indexes().CreateOne(context.Background(), index)
+	c.Database(d).Collection(COLLECTION_NAME).Indexes().CreateMany(context.Background(), indexes)
 	return &MongoPersistentStore{c, d}
 }
 
 func (m *MongoPersistentStore) Save(c context.Context, shortUrl *ShortURLWithExpireTime) error {
-	doc := ShortURLDocument{shortUrl.ShortUrl.ShortURL, shortUrl.ShortUrl.OriginalURL, shortUrl.ExpireAt}
+	doc := newShortURLDocument(shortUrl)
 
 	_, err := m.client.Database(m.database).Collection(COLLECTION_NAME).InsertOne(c, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return NewDuplicateShortURLError(doc.ShortURL)
+	}
 
 	return err
 }
 
-func (m *MongoPersistentStore) FindUnexpiredByShortURL(c context.Context, shortURL string) (*ShortURLWithExpireTime, error) {
+func (m *MongoPersistentStore) SaveMany(c context.Context, shortUrls []*ShortURLWithExpireTime) ([]error, error) {
+	docs := make([]interface{}, len(shortUrls))
+	for i, shortUrl := range shortUrls {
+		docs[i] = newShortURLDocument(shortUrl)
+	}
+
+	errs := make([]error, len(shortUrls))
+	_, err := m.client.Database(m.database).Collection(COLLECTION_NAME).InsertMany(c, docs, options.InsertMany().SetOrdered(false))
+	if err == nil {
+		return errs, nil
+	}
+
+	bulkErr, ok := err.(mongo.BulkWriteException)
+	if !ok || bulkErr.WriteConcernError != nil {
+		return nil, err
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if mongo.IsDuplicateKeyError(writeErr.WriteError) {
+			errs[writeErr.Index] = NewDuplicateShortURLError(shortUrls[writeErr.Index].ShortUrl.ShortURL)
+		} else {
+			errs[writeErr.Index] = writeErr.WriteError
+		}
+	}
+
+	return errs, nil
+}
+
+func (m *MongoPersistentStore) FindByShortURL(c context.Context, shortURL string) (*ShortURLWithExpireTime, error) {
 	var doc ShortURLDocument
 	err := m.client.Database(m.database).Collection(COLLECTION_NAME).FindOne(c, bson.M{
 		"short_url": shortURL,
+	}).Decode(&doc)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return doc.toShortURLWithExpireTime(), nil
+}
+
+func (m *MongoPersistentStore) Update(c context.Context, shortURL string, update *ShortURLUpdate) (*ShortURLWithExpireTime, error) {
+	set := bson.M{}
+	if update.OriginalURL != nil {
+		set["original_url"] = *update.OriginalURL
+		set["url_hash"] = URLHash(*update.OriginalURL)
+	}
+	if update.ExpireAt != nil {
+		set["expire_at"] = *update.ExpireAt
+	}
+
+	var doc ShortURLDocument
+	err := m.client.Database(m.database).Collection(COLLECTION_NAME).FindOneAndUpdate(
+		c,
+		bson.M{"short_url": shortURL},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&doc)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return doc.toShortURLWithExpireTime(), nil
+}
+
+func (m *MongoPersistentStore) Delete(c context.Context, shortURL string) (bool, error) {
+	result, err := m.client.Database(m.database).Collection(COLLECTION_NAME).DeleteOne(c, bson.M{
+		"short_url": shortURL,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount > 0, nil
+}
+
+func (m *MongoPersistentStore) ForEachShortURL(c context.Context, fn func(shortURL string) error) error {
+	cursor, err := m.client.Database(m.database).Collection(COLLECTION_NAME).Find(c, bson.M{},
+		options.Find().SetProjection(bson.M{"short_url": 1, "_id": 0}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(c)
+
+	for cursor.Next(c) {
+		var doc ShortURLDocument
+		err = cursor.Decode(&doc)
+		if err != nil {
+			return err
+		}
+		err = fn(doc.ShortURL)
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (m *MongoPersistentStore) FindUnexpiredByShortURL(c context.Context, shortURL string) (*ShortURLWithExpireTime, bool, error) {
+	url, err := m.FindByShortURL(c, shortURL)
+	if err != nil {
+		return nil, false, err
+	}
+	url, expired := unexpired(url, time.Now())
+	return url, expired, nil
+}
+
+func (m *MongoPersistentStore) FindUnexpiredByURLHash(c context.Context, ownerID, urlHash string) (*ShortURLWithExpireTime, error) {
+	var doc ShortURLDocument
+	err := m.client.Database(m.database).Collection(COLLECTION_NAME).FindOne(c, bson.M{
+		"owner_id": ownerID,
+		"url_hash": urlHash,
 		"expire_at": bson.M{
 			"$gt": time.Now(),
 		},
-	}).Decode(&doc)
+	}, options.FindOne().SetSort(bson.M{"expire_at": -1})).Decode(&doc)
 
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
@@ -58,8 +186,23 @@ func (m *MongoPersistentStore) FindUnexpiredByShortURL(c context.Context, shortU
 		return nil, err
 	}
 
+	return doc.toShortURLWithExpireTime(), nil
+}
+
+func newShortURLDocument(shortUrl *ShortURLWithExpireTime) ShortURLDocument {
+	return ShortURLDocument{
+		ShortURL:    shortUrl.ShortUrl.ShortURL,
+		OriginalURL: shortUrl.ShortUrl.OriginalURL,
+		ExpireAt:    shortUrl.ExpireAt,
+		OwnerID:     shortUrl.OwnerID,
+		URLHash:     URLHash(shortUrl.ShortUrl.OriginalURL),
+	}
+}
+
+func (doc *ShortURLDocument) toShortURLWithExpireTime() *ShortURLWithExpireTime {
 	return &ShortURLWithExpireTime{
 		ShortUrl: &ShortURL{ShortURL: doc.ShortURL, OriginalURL: doc.OriginalURL},
 		ExpireAt: doc.ExpireAt,
-	}, nil
+		OwnerID:  doc.OwnerID,
+	}
 }
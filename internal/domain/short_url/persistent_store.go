@@ -1,8 +1,65 @@
 package shorturl
 
-import "context"
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
 
 type PersistentStore interface {
+	// Save must return *DuplicateShortURLError if the short url already exists.
 	Save(c context.Context, shortUrl *ShortURLWithExpireTime) error
-	FindUnexpiredByShortURL(c context.Context, shortURL string) (*ShortURLWithExpireTime, error)
+	// SaveMany saves as many short urls as possible. The returned errors are in the same order as
+	// the given short urls, nil if the short url is saved, or *DuplicateShortURLError if it already exists.
+	SaveMany(c context.Context, shortUrls []*ShortURLWithExpireTime) ([]error, error)
+	// FindUnexpiredByShortURL returns nil if the short url does not exist or is expired, and expired
+	// tells the two apart.
+	FindUnexpiredByShortURL(c context.Context, shortURL string) (url *ShortURLWithExpireTime, expired bool, err error)
+	FindByShortURL(c context.Context, shortURL string) (*ShortURLWithExpireTime, error)
+	// FindUnexpiredByURLHash returns the unexpired short url of the owner whose original url has the URLHash,
+	// the one expiring last if there are many, or nil if there is none.
+	FindUnexpiredByURLHash(c context.Context, ownerID, urlHash string) (*ShortURLWithExpireTime, error)
+	// Update returns nil if the short url does not exist.
+	Update(c context.Context, shortURL string, update *ShortURLUpdate) (*ShortURLWithExpireTime, error)
+	// Delete returns false if the short url does not exist.
+	Delete(c context.Context, shortURL string) (bool, error)
+	// ForEachShortURL calls fn with every stored short url id, including expired ones.
+	// It stops and returns the error if fn returns an error.
+	ForEachShortURL(c context.Context, fn func(shortURL string) error) error
+}
+
+// unexpired returns the short url found regardless of its expire time, or nil and true if it is expired.
+func unexpired(url *ShortURLWithExpireTime, now time.Time) (*ShortURLWithExpireTime, bool) {
+	if url == nil || url.ExpireAt.After(now) {
+		return url, false
+	}
+	return nil, true
+}
+
+type DuplicateShortURLError struct {
+	ShortURL string
+}
+
+func (e *DuplicateShortURLError) Error() string {
+	return fmt.Sprintf("short url %s already exists", e.ShortURL)
+}
+
+func NewDuplicateShortURLError(shortURL string) *DuplicateShortURLError {
+	return &DuplicateShortURLError{shortURL}
+}
+
+// URLHash identifies the original url in the store indexes, so long urls are looked up without indexing them.
+// The scheme and the host are case insensitive, so they are lowercased first.
+func URLHash(originalURL string) string {
+	if u, err := url.Parse(originalURL); err == nil {
+		u.Scheme = strings.ToLower(u.Scheme)
+		u.Host = strings.ToLower(u.Host)
+		originalURL = u.String()
+	}
+	sum := sha256.Sum256([]byte(originalURL))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,28 @@
+package shorturl
+
+import (
+	"context"
+
+	"github.com/redis/rueidis"
+)
+
+const INVALIDATION_CHANNEL = "cache:invalidate"
+
+// RedisCacheInvalidator broadcasts deleted cache keys with redis Pub/Sub.
+type RedisCacheInvalidator struct {
+	client rueidis.Client
+}
+
+func NewRedisCacheInvalidator(client rueidis.Client) *RedisCacheInvalidator {
+	return &RedisCacheInvalidator{client}
+}
+
+func (r *RedisCacheInvalidator) Publish(c context.Context, key string) error {
+	return r.client.Do(c, r.client.B().Publish().Channel(INVALIDATION_CHANNEL).Message(key).Build()).Error()
+}
+
+func (r *RedisCacheInvalidator) Subscribe(c context.Context, fn func(key string)) error {
+	return r.client.Receive(c, r.client.B().Subscribe().Channel(INVALIDATION_CHANNEL).Build(), func(msg rueidis.PubSubMessage) {
+		fn(msg.Message)
+	})
+}
@@ -2,29 +2,94 @@ package shorturl
 
 import (
 	"context"
+	"sync/atomic"
+	"time"
 
 	"github.com/redis/rueidis"
 )
 
+// RedisCacheStore reads values with redis server-assisted client side caching if clientCacheTTL is positive.
+// Cached values are kept in process for at most clientCacheTTL, and redis pushes an invalidation
+// when a cached key is changed, so they are never stale for longer than the invalidation takes.
 type RedisCacheStore struct {
-	client rueidis.Client
+	client         rueidis.Client
+	clientCacheTTL time.Duration
+
+	hits   uint64
+	misses uint64
 }
 
-func NewRedisCacheStore(client rueidis.Client) *RedisCacheStore {
-	return &RedisCacheStore{client}
+// NewRedisCacheStore creates the store, a zero clientCacheTTL disables client side caching.
+func NewRedisCacheStore(client rueidis.Client, clientCacheTTL time.Duration) *RedisCacheStore {
+	return &RedisCacheStore{client: client, clientCacheTTL: clientCacheTTL}
 }
 
 func (r *RedisCacheStore) Get(c context.Context, key string) (*string, error) {
-	v, err := r.client.Do(c, r.client.B().Get().Key(key).Build()).ToString()
+	v, _, err := r.get(c, key)
+	return v, err
+}
+
+func (r *RedisCacheStore) GetWithTTL(c context.Context, key string) (*string, time.Duration, error) {
+	if r.clientCacheTTL > 0 {
+		// The client side cache is bounded by the PTTL of the key, so its remaining time is a safe ttl.
+		return r.get(c, key)
+	}
+
+	results := r.client.DoMulti(c, r.client.B().Get().Key(key).Build(), r.client.B().Pttl().Key(key).Build())
+
+	v, err := results[0].ToString()
+	if err != nil {
+		if rueidis.IsRedisNil(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	ttl, err := results[1].AsInt64()
+	if err != nil {
+		return nil, 0, err
+	}
 
+	// PTTL returns -1 if the key has no expiry, and -2 if it is deleted after GET.
+	if ttl == -2 {
+		return nil, 0, nil
+	}
+	if ttl == -1 {
+		return &v, -1, nil
+	}
+	return &v, time.Duration(ttl) * time.Millisecond, nil
+}
+
+// Stats returns the hits and misses of the client side cache, both are zero if it is disabled.
+func (r *RedisCacheStore) Stats() CacheStats {
+	return CacheStats{atomic.LoadUint64(&r.hits), atomic.LoadUint64(&r.misses)}
+}
+
+// get returns the remaining ttl of the client side cache, which is negative if client side caching is disabled.
+func (r *RedisCacheStore) get(c context.Context, key string) (*string, time.Duration, error) {
+	var result rueidis.RedisResult
+	if r.clientCacheTTL > 0 {
+		result = r.client.DoCache(c, r.client.B().Get().Key(key).Cache(), r.clientCacheTTL)
+		if result.IsCacheHit() {
+			atomic.AddUint64(&r.hits, 1)
+		} else {
+			atomic.AddUint64(&r.misses, 1)
+		}
+	} else {
+		result = r.client.Do(c, r.client.B().Get().Key(key).Build())
+	}
+
+	v, err := result.ToString()
 	if err != nil {
 		if rueidis.IsRedisNil(err) {
-			return nil, nil
+			return nil, 0, nil
 		}
-		return nil, err
+		return nil, 0, err
 	}
 
-	return &v, nil
+	if r.clientCacheTTL <= 0 {
+		return &v, -1, nil
+	}
+	return &v, time.Duration(result.CachePTTL()) * time.Millisecond, nil
 }
 
 func (r *RedisCacheStore) Set(c context.Context, key, value string, expireSecond uint) error {
@@ -36,3 +101,8 @@ func (r *RedisCacheStore) Set(c context.Context, key, value string, expireSecond
 	}
 	return nil
 }
+
+func (r *RedisCacheStore) Delete(c context.Context, key string) error {
+	cmd := r.client.B().Del().Key(key).Build()
+	return r.client.Do(c, cmd).Error()
+}
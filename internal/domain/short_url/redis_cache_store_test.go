@@ -0,0 +1,141 @@
+package shorturl_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+	"github.com/golang/mock/gomock"
+	"github.com/redis/rueidis"
+	"github.com/redis/rueidis/mock"
+)
+
+func TestRedisCacheStoreGetSetDelete(t *testing.T) {
+	_, client := createRedisClient(t, false)
+	store := shorturl.NewRedisCacheStore(client, 0)
+	c := context.Background()
+
+	value, err := store.Get(c, "short")
+	if err != nil || value != nil {
+		t.Errorf("expected missing value to be nil, got %v %v", value, err)
+	}
+
+	store.Set(c, "short", "https://example.com/long", 10)
+	value, _ = store.Get(c, "short")
+	if value == nil || *value != "https://example.com/long" {
+		t.Errorf("expected cached value, got %v", value)
+	}
+
+	store.Delete(c, "short")
+	value, _ = store.Get(c, "short")
+	if value != nil {
+		t.Errorf("expected deleted value to be nil, got %v", *value)
+	}
+}
+
+func TestRedisCacheStoreGetWithTTLReturnRemainingTTL(t *testing.T) {
+	m, client := createRedisClient(t, false)
+	store := shorturl.NewRedisCacheStore(client, 0)
+	c := context.Background()
+
+	store.Set(c, "short", "https://example.com/long", 10)
+	m.FastForward(4 * time.Second)
+
+	value, ttl, err := store.GetWithTTL(c, "short")
+	if err != nil || value == nil || *value != "https://example.com/long" || ttl != 6*time.Second {
+		t.Errorf("expected value with remaining ttl, got %v %v %v", value, ttl, err)
+	}
+
+	value, _, _ = store.GetWithTTL(c, "missing")
+	if value != nil {
+		t.Errorf("expected missing value to be nil, got %v", *value)
+	}
+	if stats := store.Stats(); stats.Hits != 0 || stats.Misses != 0 {
+		t.Errorf("expected no client side cache stats, got %+v", stats)
+	}
+}
+
+func TestRedisCacheStoreCountClientSideCacheHitsAndMisses(t *testing.T) {
+	_, client := createRedisClient(t, true)
+	store := shorturl.NewRedisCacheStore(client, time.Minute)
+	c := context.Background()
+
+	store.Set(c, "short", "https://example.com/long", 300)
+	for i := 0; i < 3; i++ {
+		value, err := store.Get(c, "short")
+		if err != nil || value == nil || *value != "https://example.com/long" {
+			t.Fatalf("expected cached value, got %v %v", value, err)
+		}
+	}
+
+	if stats := store.Stats(); stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("expected 2 local hits and 1 miss, got %+v", stats)
+	}
+}
+
+func TestRedisCacheStoreClientSideCacheTTLIsBoundedByKey(t *testing.T) {
+	_, client := createRedisClient(t, true)
+	store := shorturl.NewRedisCacheStore(client, time.Minute)
+	c := context.Background()
+
+	store.Set(c, "long-lived", "https://example.com/a", 300)
+	store.Set(c, "short-lived", "https://example.com/b", 10)
+
+	for key, max := range map[string]time.Duration{"long-lived": time.Minute, "short-lived": 10 * time.Second} {
+		for i := 0; i < 2; i++ {
+			value, ttl, err := store.GetWithTTL(c, key)
+			if err != nil || value == nil || ttl <= 0 || ttl > max {
+				t.Errorf("expected %s to be cached at most %v, got %v %v %v", key, max, value, ttl, err)
+			}
+		}
+	}
+}
+
+func TestRedisCacheStorePassClientSideCacheTTLToDoCache(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	client := mock.NewClient(mockCtrl)
+	store := shorturl.NewRedisCacheStore(client, 10*time.Second)
+	c := context.Background()
+
+	client.EXPECT().DoCache(c, mock.Match("GET", "short"), 10*time.Second).Return(mock.Result(mock.RedisString("https://example.com/long")))
+	client.EXPECT().DoCache(c, mock.Match("GET", "missing"), 10*time.Second).Return(mock.Result(mock.RedisNil()))
+
+	value, err := store.Get(c, "short")
+	if err != nil || value == nil || *value != "https://example.com/long" {
+		t.Errorf("expected cached value, got %v %v", value, err)
+	}
+	value, err = store.Get(c, "missing")
+	if err != nil || value != nil {
+		t.Errorf("expected missing value to be nil, got %v %v", value, err)
+	}
+	if stats := store.Stats(); stats.Misses != 2 {
+		t.Errorf("expected 2 misses, got %+v", stats)
+	}
+}
+
+// createRedisClient connects to an in-process redis. miniredis does not support client side caching,
+// so with clientSideCache the tracking commands are acknowledged without pushing invalidations.
+func createRedisClient(t *testing.T, clientSideCache bool) (*miniredis.Miniredis, rueidis.Client) {
+	m := miniredis.RunT(t)
+	if clientSideCache {
+		m.Server().SetPreHook(func(c *server.Peer, cmd string, args ...string) bool {
+			if strings.EqualFold(cmd, "CLIENT") && len(args) > 0 && (strings.EqualFold(args[0], "TRACKING") || strings.EqualFold(args[0], "CACHING")) {
+				c.WriteOK()
+				return true
+			}
+			return false
+		})
+	}
+	client, err := rueidis.NewClient(rueidis.ClientOption{InitAddress: []string{m.Addr()}, DisableCache: !clientSideCache})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return m, client
+}
@@ -2,23 +2,68 @@ package shorturl
 
 import (
 	"context"
+	"log/slog"
 	"math"
 	"time"
 
 	"github.com/WeiAnAn/url-shortener/internal/utils"
+	"golang.org/x/sync/singleflight"
 )
 
-const MAX_CACHE_SECOND = 300
+const (
+	MAX_CACHE_SECOND = 300
+	// CACHE_LOCK_TTL bounds how long a replica can hold the lock for loading a short url into the cache.
+	CACHE_LOCK_TTL = 3 * time.Second
+	// Replicas which do not get the lock check the cache CACHE_LOCK_WAIT_RETRY times
+	// before loading the short url by themselves.
+	CACHE_LOCK_WAIT_RETRY    = 10
+	CACHE_LOCK_WAIT_INTERVAL = 50 * time.Millisecond
+	// CACHE_LOAD_TIMEOUT bounds the lookup shared by concurrent cache misses, which outlives the caller starting it.
+	CACHE_LOAD_TIMEOUT = 5 * time.Second
+)
 
 type ShortURLRepository interface {
 	Save(context.Context, *ShortURLWithExpireTime) error
+	SaveMany(context.Context, []*ShortURLWithExpireTime) ([]error, error)
 	FindByShortURL(context.Context, string) (*ShortURL, error)
+	GetByShortURL(context.Context, string) (*ShortURLWithExpireTime, error)
+	// FindUnexpiredByOriginalURL returns the unexpired short url of the owner to the original url
+	// which expires last, or nil if there is none.
+	FindUnexpiredByOriginalURL(c context.Context, ownerID, originalURL string) (*ShortURLWithExpireTime, error)
+	Update(context.Context, string, *ShortURLUpdate) (*ShortURLWithExpireTime, error)
+	Delete(context.Context, string) (bool, error)
+}
+
+// Locker makes only one replica load a short url into the cache when it is missing.
+type Locker interface {
+	// TryLock returns false without waiting if the lock is held by others.
+	// The returned function releases the lock.
+	TryLock(c context.Context, key string, ttl time.Duration) (func(), bool, error)
+}
+
+// Counter counts events, prometheus.Counter implements it.
+type Counter interface {
+	Inc()
+}
+
+type RepositoryConfig struct {
+	// PopulateCacheOnSave caches saved short urls immediately, so the first redirects of a new short url
+	// do not all miss the cache. It also overwrites the empty string cached for a short url which did not exist.
+	PopulateCacheOnSave bool
+	// ExpiredLookups counts the cache misses of short urls which exist but are expired. Later lookups are
+	// served the cached empty string like unknown short urls, so they are not counted. It is optional.
+	ExpiredLookups Counter
 }
 
 type shortURLRepository struct {
 	persistentStore PersistentStore
 	cacheStore      CacheStore
 	time            utils.TimeUtil
+	locker          Locker
+	filter          ShortURLFilter
+	config          RepositoryConfig
+	// loadGroup coalesces concurrent cache misses of the same short url into one persistent store lookup.
+	loadGroup singleflight.Group
 }
 
 type ShortURL struct {
@@ -29,10 +74,19 @@ type ShortURL struct {
 type ShortURLWithExpireTime struct {
 	ShortUrl *ShortURL
 	ExpireAt time.Time
+	OwnerID  string
 }
 
-func NewRepository(ps PersistentStore, cs CacheStore, t utils.TimeUtil) *shortURLRepository {
-	repo := &shortURLRepository{ps, cs, t}
+// ShortURLUpdate holds the fields to be updated, nil fields are left unchanged.
+type ShortURLUpdate struct {
+	OriginalURL *string
+	ExpireAt    *time.Time
+}
+
+// NewRepository creates the repository. The locker and the filter are optional,
+// the locker is only needed when there are multiple replicas.
+func NewRepository(ps PersistentStore, cs CacheStore, t utils.TimeUtil, l Locker, f ShortURLFilter, config RepositoryConfig) *shortURLRepository {
+	repo := &shortURLRepository{persistentStore: ps, cacheStore: cs, time: t, locker: l, filter: f, config: config}
 
 	return repo
 }
@@ -42,10 +96,73 @@ func (repo *shortURLRepository) Save(c context.Context, shortURL *ShortURLWithEx
 	if err != nil {
 		return err
 	}
+	repo.addToFilter(c, shortURL.ShortUrl.ShortURL)
+	repo.populateCache(c, shortURL)
 	return nil
 }
 
+func (repo *shortURLRepository) SaveMany(c context.Context, shortURLs []*ShortURLWithExpireTime) ([]error, error) {
+	errs, err := repo.persistentStore.SaveMany(c, shortURLs)
+	if err != nil || (repo.filter == nil && !repo.config.PopulateCacheOnSave) {
+		return errs, err
+	}
+	for i, shortURL := range shortURLs {
+		if errs[i] == nil {
+			repo.addToFilter(c, shortURL.ShortUrl.ShortURL)
+			repo.populateCache(c, shortURL)
+		}
+	}
+	return errs, nil
+}
+
+// populateCache does not fail the save, the short url is cached by the first redirect instead.
+func (repo *shortURLRepository) populateCache(c context.Context, shortURL *ShortURLWithExpireTime) {
+	if !repo.config.PopulateCacheOnSave {
+		return
+	}
+	cacheSecond := repo.cacheSecond(shortURL.ExpireAt)
+	if cacheSecond == 0 {
+		return
+	}
+	// Set is not broadcast to the local tiers of other replicas, since it also fills cache misses. Deleting
+	// first is, so they drop the empty string cached while the short url did not exist.
+	err := repo.cacheStore.Delete(c, shortURL.ShortUrl.ShortURL)
+	if err == nil {
+		err = repo.cacheStore.Set(c, shortURL.ShortUrl.ShortURL, shortURL.ShortUrl.OriginalURL, cacheSecond)
+	}
+	if err != nil {
+		slog.WarnContext(c, "cache short url failed", slog.String("short_url", shortURL.ShortUrl.ShortURL), slog.Any("error", err))
+	}
+}
+
+// cacheSecond keeps the short url in the cache until it expires, but no longer than MAX_CACHE_SECOND.
+func (repo *shortURLRepository) cacheSecond(expireAt time.Time) uint {
+	timeToExpired := repo.time.Until(expireAt).Seconds()
+	return uint(math.Max(math.Min(timeToExpired, MAX_CACHE_SECOND), 0))
+}
+
+// addToFilter does not fail the save, the short url is already stored and the filter is fixed by the next rebuild.
+func (repo *shortURLRepository) addToFilter(c context.Context, shortURL string) {
+	if repo.filter == nil {
+		return
+	}
+	err := repo.filter.Add(c, shortURL)
+	if err != nil {
+		slog.WarnContext(c, "add short url to filter failed", slog.String("short_url", shortURL), slog.Any("error", err))
+	}
+}
+
 func (repo *shortURLRepository) FindByShortURL(c context.Context, shortURL string) (*ShortURL, error) {
+	if repo.filter != nil {
+		exists, err := repo.filter.MightContain(c, shortURL)
+		if err != nil {
+			slog.WarnContext(c, "short url filter failed, checking cache", slog.Any("error", err))
+		}
+		if !exists {
+			return nil, nil
+		}
+	}
+
 	originalURL, err := repo.cacheStore.Get(c, shortURL)
 	if err != nil {
 		return nil, err
@@ -57,12 +174,54 @@ func (repo *shortURLRepository) FindByShortURL(c context.Context, shortURL strin
 		return &ShortURL{ShortURL: shortURL, OriginalURL: *originalURL}, nil
 	}
 
-	url, err := repo.persistentStore.FindUnexpiredByShortURL(c, shortURL)
+	// The lookup is shared by concurrent callers, so it is not canceled with the caller starting it;
+	// each caller stops waiting for it when its own context is done.
+	ch := repo.loadGroup.DoChan(shortURL, func() (interface{}, error) {
+		lc, cancel := context.WithTimeout(context.WithoutCancel(c), CACHE_LOAD_TIMEOUT)
+		defer cancel()
+		return repo.loadShortURL(lc, shortURL)
+	})
+	var result singleflight.Result
+	select {
+	case <-c.Done():
+		return nil, c.Err()
+	case result = <-ch:
+	}
+	if result.Err != nil || result.Val.(*ShortURL) == nil {
+		return nil, result.Err
+	}
+
+	shared := *result.Val.(*ShortURL)
+	return &shared, nil
+}
+
+// loadShortURL reads the short url from the persistent store and caches it. If another replica
+// holds the lock, the result of that replica is read from the cache instead.
+func (repo *shortURLRepository) loadShortURL(c context.Context, shortURL string) (*ShortURL, error) {
+	if repo.locker != nil {
+		unlock, locked, err := repo.locker.TryLock(c, shortURL, CACHE_LOCK_TTL)
+		switch {
+		case err != nil:
+			slog.WarnContext(c, "cache lock failed, loading short url without lock", slog.Any("error", err))
+		case locked:
+			defer unlock()
+		default:
+			url, found, err := repo.waitForCache(c, shortURL)
+			if err != nil || found {
+				return url, err
+			}
+		}
+	}
+
+	url, expired, err := repo.persistentStore.FindUnexpiredByShortURL(c, shortURL)
 	if err != nil {
 		return nil, err
 	}
 
 	if url == nil {
+		if expired && repo.config.ExpiredLookups != nil {
+			repo.config.ExpiredLookups.Inc()
+		}
 		err = repo.cacheStore.Set(c, shortURL, "", MAX_CACHE_SECOND)
 		if err != nil {
 			return nil, err
@@ -70,12 +229,74 @@ func (repo *shortURLRepository) FindByShortURL(c context.Context, shortURL strin
 		return nil, nil
 	}
 
-	timeToExpired := repo.time.Until(url.ExpireAt).Seconds()
-	cacheSecond := math.Min(timeToExpired, MAX_CACHE_SECOND)
-	err = repo.cacheStore.Set(c, url.ShortUrl.ShortURL, url.ShortUrl.OriginalURL, uint(cacheSecond))
+	// A short url expiring within a second is not cached, since a zero expire second is invalid.
+	if cacheSecond := repo.cacheSecond(url.ExpireAt); cacheSecond > 0 {
+		err = repo.cacheStore.Set(c, url.ShortUrl.ShortURL, url.ShortUrl.OriginalURL, cacheSecond)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return url.ShortUrl, nil
+}
+
+// waitForCache polls the cache until it is populated by the lock holder, found is false if it is never populated.
+func (repo *shortURLRepository) waitForCache(c context.Context, shortURL string) (url *ShortURL, found bool, err error) {
+	for i := 0; i < CACHE_LOCK_WAIT_RETRY; i++ {
+		select {
+		case <-c.Done():
+			return nil, false, c.Err()
+		case <-time.After(CACHE_LOCK_WAIT_INTERVAL):
+		}
+
+		originalURL, err := repo.cacheStore.Get(c, shortURL)
+		if err != nil {
+			return nil, false, err
+		}
+		if originalURL != nil {
+			if *originalURL == "" {
+				return nil, true, nil
+			}
+			return &ShortURL{ShortURL: shortURL, OriginalURL: *originalURL}, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// GetByShortURL reads the short url from the persistent store, including expired ones.
+func (repo *shortURLRepository) GetByShortURL(c context.Context, shortURL string) (*ShortURLWithExpireTime, error) {
+	return repo.persistentStore.FindByShortURL(c, shortURL)
+}
+
+// FindUnexpiredByOriginalURL is not cached, it is only used when creating short urls.
+func (repo *shortURLRepository) FindUnexpiredByOriginalURL(c context.Context, ownerID, originalURL string) (*ShortURLWithExpireTime, error) {
+	return repo.persistentStore.FindUnexpiredByURLHash(c, ownerID, URLHash(originalURL))
+}
+
+func (repo *shortURLRepository) Update(c context.Context, shortURL string, update *ShortURLUpdate) (*ShortURLWithExpireTime, error) {
+	url, err := repo.persistentStore.Update(c, shortURL, update)
 	if err != nil {
 		return nil, err
 	}
 
-	return url.ShortUrl, nil
+	err = repo.cacheStore.Delete(c, shortURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return url, nil
+}
+
+func (repo *shortURLRepository) Delete(c context.Context, shortURL string) (bool, error) {
+	deleted, err := repo.persistentStore.Delete(c, shortURL)
+	if err != nil {
+		return false, err
+	}
+
+	err = repo.cacheStore.Delete(c, shortURL)
+	if err != nil {
+		return false, err
+	}
+
+	return deleted, nil
 }
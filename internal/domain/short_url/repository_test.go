@@ -3,13 +3,16 @@ package shorturl_test
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
 	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
 	mock_shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url/mocks"
+	"github.com/WeiAnAn/url-shortener/internal/metrics"
 	mock_utils "github.com/WeiAnAn/url-shortener/internal/utils/mocks"
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestSaveCallPersistentStoreSave(t *testing.T) {
@@ -17,7 +20,7 @@ func TestSaveCallPersistentStoreSave(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	ps, cs, tu := createMock(mockCtrl)
-	repo := shorturl.NewRepository(ps, cs, tu)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
 
 	now := time.Now()
 	url := &shorturl.ShortURLWithExpireTime{
@@ -38,7 +41,7 @@ func TestSaveReturnError(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	ps, cs, tu := createMock(mockCtrl)
-	repo := shorturl.NewRepository(ps, cs, tu)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
 
 	now := time.Now()
 	url := &shorturl.ShortURLWithExpireTime{
@@ -60,12 +63,120 @@ func TestSaveReturnError(t *testing.T) {
 	}
 }
 
+func TestSaveManyCallPersistentStoreSaveMany(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
+
+	urls := []*shorturl.ShortURLWithExpireTime{{
+		ShortUrl: &shorturl.ShortURL{
+			ShortURL:    "short",
+			OriginalURL: "https://example.com/long",
+		},
+		ExpireAt: time.Now(),
+	}}
+	errs := []error{shorturl.NewDuplicateShortURLError("short")}
+	c := context.Background()
+	ps.EXPECT().SaveMany(c, urls).Return(errs, nil)
+
+	result, err := repo.SaveMany(c, urls)
+	if err != nil {
+		t.Fail()
+	}
+	if len(result) != 1 || result[0] != errs[0] {
+		t.Fail()
+	}
+}
+
+func TestSavePopulateCacheIfEnabled(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{PopulateCacheOnSave: true})
+
+	expireAt := time.Now().Add(time.Hour)
+	url := &shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{
+			ShortURL:    "short",
+			OriginalURL: "https://example.com/long",
+		},
+		ExpireAt: expireAt,
+	}
+	c := context.Background()
+	ps.EXPECT().Save(c, url).Return(nil)
+	tu.EXPECT().Until(expireAt).Return(time.Hour)
+	// Overwrites the empty string cached when the short url did not exist, on every replica.
+	gomock.InOrder(
+		cs.EXPECT().Delete(c, "short").Return(nil),
+		cs.EXPECT().Set(c, "short", url.ShortUrl.OriginalURL, uint(300)).Return(nil),
+	)
+
+	err := repo.Save(c, url)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSaveNotFailIfPopulateCacheFails(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{PopulateCacheOnSave: true})
+
+	expireAt := time.Now().Add(time.Minute)
+	url := &shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{
+			ShortURL:    "short",
+			OriginalURL: "https://example.com/long",
+		},
+		ExpireAt: expireAt,
+	}
+	c := context.Background()
+	ps.EXPECT().Save(c, url).Return(nil)
+	tu.EXPECT().Until(expireAt).Return(time.Minute)
+	cs.EXPECT().Delete(c, "short").Return(nil)
+	cs.EXPECT().Set(c, "short", url.ShortUrl.OriginalURL, uint(60)).Return(errors.New("error"))
+
+	err := repo.Save(c, url)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSaveManyPopulateCacheOfSavedShortURLs(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{PopulateCacheOnSave: true})
+
+	expireAt := time.Now().Add(time.Hour)
+	urls := []*shorturl.ShortURLWithExpireTime{
+		{ShortUrl: &shorturl.ShortURL{ShortURL: "saved", OriginalURL: "https://example.com/long"}, ExpireAt: expireAt},
+		{ShortUrl: &shorturl.ShortURL{ShortURL: "taken", OriginalURL: "https://example.com/long"}, ExpireAt: expireAt},
+	}
+	c := context.Background()
+	ps.EXPECT().SaveMany(c, urls).Return([]error{nil, shorturl.NewDuplicateShortURLError("taken")}, nil)
+	tu.EXPECT().Until(expireAt).Return(time.Hour)
+	cs.EXPECT().Delete(c, "saved").Return(nil)
+	cs.EXPECT().Set(c, "saved", "https://example.com/long", uint(300)).Return(nil)
+
+	_, err := repo.SaveMany(c, urls)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
 func TestFindByShortURLGetFromCacheFirst(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
 	ps, cs, tu := createMock(mockCtrl)
-	repo := shorturl.NewRepository(ps, cs, tu)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
 
 	now := time.Now()
 	url := &shorturl.ShortURLWithExpireTime{
@@ -91,7 +202,7 @@ func TestFindByShortURLReturnNilIfCacheReturnEmptyString(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	ps, cs, tu := createMock(mockCtrl)
-	repo := shorturl.NewRepository(ps, cs, tu)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
 
 	s := ""
 	shortURL := "short"
@@ -112,7 +223,7 @@ func TestFindByShortURLGetFromPersistent(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	ps, cs, tu := createMock(mockCtrl)
-	repo := shorturl.NewRepository(ps, cs, tu)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
 
 	d, _ := time.ParseDuration("24h")
 	expireAt := time.Now().Add(d)
@@ -125,8 +236,8 @@ func TestFindByShortURLGetFromPersistent(t *testing.T) {
 	}
 	c := context.Background()
 	cs.EXPECT().Get(c, gomock.Eq(url.ShortUrl.ShortURL)).Return(nil, nil)
-	ps.EXPECT().FindUnexpiredByShortURL(c, gomock.Eq(url.ShortUrl.ShortURL)).Return(url, nil)
-	cs.EXPECT().Set(c, url.ShortUrl.ShortURL, url.ShortUrl.OriginalURL, uint(300)).Return(nil)
+	ps.EXPECT().FindUnexpiredByShortURL(gomock.Any(), gomock.Eq(url.ShortUrl.ShortURL)).Return(url, false, nil)
+	cs.EXPECT().Set(gomock.Any(), url.ShortUrl.ShortURL, url.ShortUrl.OriginalURL, uint(300)).Return(nil)
 	tu.EXPECT().Until(expireAt).Return(d)
 
 	result, err := repo.FindByShortURL(c, url.ShortUrl.ShortURL)
@@ -143,7 +254,7 @@ func TestFindByShortURLSetCacheIfTimeToExpireIsLessThan300Second(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	ps, cs, tu := createMock(mockCtrl)
-	repo := shorturl.NewRepository(ps, cs, tu)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
 
 	d, _ := time.ParseDuration("200s")
 	expireAt := time.Now().Add(d)
@@ -156,9 +267,9 @@ func TestFindByShortURLSetCacheIfTimeToExpireIsLessThan300Second(t *testing.T) {
 	}
 	c := context.Background()
 	cs.EXPECT().Get(c, gomock.Eq(url.ShortUrl.ShortURL)).Return(nil, nil)
-	ps.EXPECT().FindUnexpiredByShortURL(c, gomock.Eq(url.ShortUrl.ShortURL)).Return(url, nil)
+	ps.EXPECT().FindUnexpiredByShortURL(gomock.Any(), gomock.Eq(url.ShortUrl.ShortURL)).Return(url, false, nil)
 	tu.EXPECT().Until(expireAt).Return(d)
-	cs.EXPECT().Set(c, url.ShortUrl.ShortURL, url.ShortUrl.OriginalURL, uint(d.Seconds())).Return(nil)
+	cs.EXPECT().Set(gomock.Any(), url.ShortUrl.ShortURL, url.ShortUrl.OriginalURL, uint(d.Seconds())).Return(nil)
 
 	result, err := repo.FindByShortURL(c, url.ShortUrl.ShortURL)
 	if err != nil {
@@ -174,7 +285,7 @@ func TestFindByShortURLSetCacheEmptyStringIfPersistentStoreReturnNil(t *testing.
 	defer mockCtrl.Finish()
 
 	ps, cs, tu := createMock(mockCtrl)
-	repo := shorturl.NewRepository(ps, cs, tu)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
 
 	expireAt := time.Now().AddDate(0, 0, 1)
 	url := &shorturl.ShortURLWithExpireTime{
@@ -186,8 +297,8 @@ func TestFindByShortURLSetCacheEmptyStringIfPersistentStoreReturnNil(t *testing.
 	}
 	c := context.Background()
 	cs.EXPECT().Get(c, gomock.Eq(url.ShortUrl.ShortURL)).Return(nil, nil)
-	ps.EXPECT().FindUnexpiredByShortURL(c, gomock.Eq(url.ShortUrl.ShortURL)).Return(nil, nil)
-	cs.EXPECT().Set(c, url.ShortUrl.ShortURL, "", uint(300)).Return(nil)
+	ps.EXPECT().FindUnexpiredByShortURL(gomock.Any(), gomock.Eq(url.ShortUrl.ShortURL)).Return(nil, false, nil)
+	cs.EXPECT().Set(gomock.Any(), url.ShortUrl.ShortURL, "", uint(300)).Return(nil)
 
 	result, err := repo.FindByShortURL(c, url.ShortUrl.ShortURL)
 	if err != nil {
@@ -198,12 +309,38 @@ func TestFindByShortURLSetCacheEmptyStringIfPersistentStoreReturnNil(t *testing.
 	}
 }
 
+func TestFindByShortURLCountExpiredLookups(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	m := metrics.New()
+	ps, cs, tu := createMock(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{ExpiredLookups: m.ExpiredLookups})
+
+	c := context.Background()
+	cs.EXPECT().Get(c, gomock.Any()).Times(2).Return(nil, nil)
+	ps.EXPECT().FindUnexpiredByShortURL(gomock.Any(), "expired").Return(nil, true, nil)
+	ps.EXPECT().FindUnexpiredByShortURL(gomock.Any(), "unknown").Return(nil, false, nil)
+	cs.EXPECT().Set(gomock.Any(), gomock.Any(), "", uint(300)).Times(2).Return(nil)
+
+	for _, shortURL := range []string{"expired", "unknown"} {
+		result, err := repo.FindByShortURL(c, shortURL)
+		if err != nil || result != nil {
+			t.Errorf("expect %s not to be found, got %v %v", shortURL, result, err)
+		}
+	}
+
+	if count := testutil.ToFloat64(m.ExpiredLookups); count != 1 {
+		t.Errorf("expect 1 expired lookup, got %v", count)
+	}
+}
+
 func TestFindByShortURLReturnErrorIfCacheGetReturnError(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
 	ps, cs, tu := createMock(mockCtrl)
-	repo := shorturl.NewRepository(ps, cs, tu)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
 
 	expireAt := time.Now()
 	url := &shorturl.ShortURLWithExpireTime{
@@ -228,7 +365,7 @@ func TestFindByShortURLReturnErrorIfPersistentGetReturnError(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	ps, cs, tu := createMock(mockCtrl)
-	repo := shorturl.NewRepository(ps, cs, tu)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
 
 	expireAt := time.Now()
 	url := &shorturl.ShortURLWithExpireTime{
@@ -241,7 +378,7 @@ func TestFindByShortURLReturnErrorIfPersistentGetReturnError(t *testing.T) {
 	mockErr := errors.New("Error")
 	c := context.Background()
 	cs.EXPECT().Get(c, gomock.Eq(url.ShortUrl.ShortURL)).Return(nil, nil)
-	ps.EXPECT().FindUnexpiredByShortURL(c, gomock.Eq(url.ShortUrl.ShortURL)).Return(nil, mockErr)
+	ps.EXPECT().FindUnexpiredByShortURL(gomock.Any(), gomock.Eq(url.ShortUrl.ShortURL)).Return(nil, false, mockErr)
 
 	_, err := repo.FindByShortURL(c, url.ShortUrl.ShortURL)
 	if err != mockErr {
@@ -254,7 +391,7 @@ func TestFindByShortURLReturnErrorIfCacheSetReturnError(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	ps, cs, tu := createMock(mockCtrl)
-	repo := shorturl.NewRepository(ps, cs, tu)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
 
 	d, _ := time.ParseDuration("200s")
 	expireAt := time.Now().Add(d)
@@ -268,9 +405,9 @@ func TestFindByShortURLReturnErrorIfCacheSetReturnError(t *testing.T) {
 	mockErr := errors.New("Error")
 	c := context.Background()
 	cs.EXPECT().Get(c, gomock.Eq(url.ShortUrl.ShortURL)).Return(nil, nil)
-	ps.EXPECT().FindUnexpiredByShortURL(c, gomock.Eq(url.ShortUrl.ShortURL)).Return(url, nil)
+	ps.EXPECT().FindUnexpiredByShortURL(gomock.Any(), gomock.Eq(url.ShortUrl.ShortURL)).Return(url, false, nil)
 	tu.EXPECT().Until(expireAt).Return(d)
-	cs.EXPECT().Set(c, url.ShortUrl.ShortURL, url.ShortUrl.OriginalURL, uint(d.Seconds())).Return(mockErr)
+	cs.EXPECT().Set(gomock.Any(), url.ShortUrl.ShortURL, url.ShortUrl.OriginalURL, uint(d.Seconds())).Return(mockErr)
 
 	_, err := repo.FindByShortURL(c, url.ShortUrl.ShortURL)
 	if err != mockErr {
@@ -278,6 +415,428 @@ func TestFindByShortURLReturnErrorIfCacheSetReturnError(t *testing.T) {
 	}
 }
 
+func TestFindByShortURLCoalesceConcurrentCacheMisses(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
+
+	const n = 20
+	url := &shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{
+			ShortURL:    "short",
+			OriginalURL: "https://example.com/long",
+		},
+		ExpireAt: time.Now().Add(time.Hour),
+	}
+	c := context.Background()
+
+	var missed sync.WaitGroup
+	missed.Add(n)
+	release := make(chan struct{})
+	cs.EXPECT().Get(c, "short").Times(n).DoAndReturn(func(context.Context, string) (*string, error) {
+		missed.Done()
+		return nil, nil
+	})
+	ps.EXPECT().FindUnexpiredByShortURL(gomock.Any(), "short").Times(1).DoAndReturn(func(context.Context, string) (*shorturl.ShortURLWithExpireTime, bool, error) {
+		<-release
+		return url, false, nil
+	})
+	tu.EXPECT().Until(url.ExpireAt).Return(time.Hour)
+	cs.EXPECT().Set(gomock.Any(), "short", url.ShortUrl.OriginalURL, uint(300)).Times(1).Return(nil)
+
+	results := make([]*shorturl.ShortURL, n)
+	var done sync.WaitGroup
+	done.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer done.Done()
+			results[i], _ = repo.FindByShortURL(c, "short")
+		}(i)
+	}
+
+	// Every lookup has missed the cache, give them time to join the in-flight lookup before it returns.
+	missed.Wait()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	done.Wait()
+
+	for _, result := range results {
+		if result == nil || result.OriginalURL != url.ShortUrl.OriginalURL {
+			t.Fatalf("unexpected result %v", result)
+		}
+	}
+	if results[0] == results[1] {
+		t.Error("callers share the same short url instance")
+	}
+}
+
+func TestFindByShortURLNotCancelSharedLoadWithFirstCaller(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
+
+	url := &shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{
+			ShortURL:    "short",
+			OriginalURL: "https://example.com/long",
+		},
+		ExpireAt: time.Now().Add(time.Hour),
+	}
+
+	loading := make(chan struct{})
+	release := make(chan struct{})
+	cs.EXPECT().Get(gomock.Any(), "short").Times(2).Return(nil, nil)
+	ps.EXPECT().FindUnexpiredByShortURL(gomock.Any(), "short").DoAndReturn(func(c context.Context, _ string) (*shorturl.ShortURLWithExpireTime, bool, error) {
+		close(loading)
+		<-release
+		return url, false, c.Err()
+	})
+	tu.EXPECT().Until(url.ExpireAt).Return(time.Hour)
+	cs.EXPECT().Set(gomock.Any(), "short", url.ShortUrl.OriginalURL, uint(300)).Return(nil)
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() {
+		_, err := repo.FindByShortURL(first, "short")
+		firstErr <- err
+	}()
+	<-loading
+
+	second := make(chan *shorturl.ShortURL)
+	go func() {
+		result, _ := repo.FindByShortURL(context.Background(), "short")
+		second <- result
+	}()
+	// give the second lookup time to join the in-flight lookup
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	if err := <-firstErr; err != context.Canceled {
+		t.Errorf("expect the canceled caller to return context.Canceled, got %v", err)
+	}
+	close(release)
+	if result := <-second; result == nil || result.OriginalURL != url.ShortUrl.OriginalURL {
+		t.Errorf("expect the other caller to get the short url, got %v", result)
+	}
+}
+
+func TestFindByShortURLLoadWithLockAndUnlock(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	locker := mock_shorturl.NewMockLocker(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, locker, nil, shorturl.RepositoryConfig{})
+
+	url := &shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{
+			ShortURL:    "short",
+			OriginalURL: "https://example.com/long",
+		},
+		ExpireAt: time.Now().Add(time.Hour),
+	}
+	c := context.Background()
+	unlocked := false
+	cs.EXPECT().Get(c, "short").Return(nil, nil)
+	locker.EXPECT().TryLock(gomock.Any(), "short", shorturl.CACHE_LOCK_TTL).Return(func() { unlocked = true }, true, nil)
+	ps.EXPECT().FindUnexpiredByShortURL(gomock.Any(), "short").Return(url, false, nil)
+	tu.EXPECT().Until(url.ExpireAt).Return(time.Hour)
+	cs.EXPECT().Set(gomock.Any(), "short", url.ShortUrl.OriginalURL, uint(300)).Return(nil)
+
+	result, err := repo.FindByShortURL(c, "short")
+	if err != nil || result.OriginalURL != url.ShortUrl.OriginalURL {
+		t.Errorf("unexpected result %v %v", result, err)
+	}
+	if !unlocked {
+		t.Error("lock is not released")
+	}
+}
+
+func TestFindByShortURLWaitForCacheIfLockIsHeld(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	locker := mock_shorturl.NewMockLocker(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, locker, nil, shorturl.RepositoryConfig{})
+
+	originalURL := "https://example.com/long"
+	c := context.Background()
+	gomock.InOrder(
+		cs.EXPECT().Get(c, "short").Return(nil, nil),
+		locker.EXPECT().TryLock(gomock.Any(), "short", shorturl.CACHE_LOCK_TTL).Return(nil, false, nil),
+		cs.EXPECT().Get(gomock.Any(), "short").Return(nil, nil),
+		cs.EXPECT().Get(gomock.Any(), "short").Return(&originalURL, nil),
+	)
+	ps.EXPECT().FindUnexpiredByShortURL(gomock.Any(), gomock.Any()).Times(0)
+
+	result, err := repo.FindByShortURL(c, "short")
+	if err != nil || result.OriginalURL != originalURL {
+		t.Errorf("unexpected result %v %v", result, err)
+	}
+}
+
+func TestFindByShortURLLoadFromPersistentStoreIfLockHolderNeverCaches(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	locker := mock_shorturl.NewMockLocker(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, locker, nil, shorturl.RepositoryConfig{})
+
+	c := context.Background()
+	cs.EXPECT().Get(gomock.Any(), "short").Times(shorturl.CACHE_LOCK_WAIT_RETRY+1).Return(nil, nil)
+	locker.EXPECT().TryLock(gomock.Any(), "short", shorturl.CACHE_LOCK_TTL).Return(nil, false, nil)
+	ps.EXPECT().FindUnexpiredByShortURL(gomock.Any(), "short").Return(nil, false, nil)
+	cs.EXPECT().Set(gomock.Any(), "short", "", uint(300)).Return(nil)
+
+	result, err := repo.FindByShortURL(c, "short")
+	if err != nil || result != nil {
+		t.Errorf("unexpected result %v %v", result, err)
+	}
+}
+
+func TestFindByShortURLLoadWithoutLockIfLockReturnError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	locker := mock_shorturl.NewMockLocker(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, locker, nil, shorturl.RepositoryConfig{})
+
+	c := context.Background()
+	cs.EXPECT().Get(c, "short").Return(nil, nil)
+	locker.EXPECT().TryLock(gomock.Any(), "short", shorturl.CACHE_LOCK_TTL).Return(nil, false, errors.New("error"))
+	ps.EXPECT().FindUnexpiredByShortURL(gomock.Any(), "short").Return(nil, false, nil)
+	cs.EXPECT().Set(gomock.Any(), "short", "", uint(300)).Return(nil)
+
+	result, err := repo.FindByShortURL(c, "short")
+	if err != nil || result != nil {
+		t.Errorf("unexpected result %v %v", result, err)
+	}
+}
+
+func TestFindByShortURLReturnNilIfFilterDoesNotContain(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	filter := mock_shorturl.NewMockShortURLFilter(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, filter, shorturl.RepositoryConfig{})
+
+	c := context.Background()
+	filter.EXPECT().MightContain(c, "short").Return(false, nil)
+
+	result, err := repo.FindByShortURL(c, "short")
+	if err != nil || result != nil {
+		t.Errorf("unexpected result %v %v", result, err)
+	}
+}
+
+func TestFindByShortURLCheckCacheIfFilterMightContain(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	filter := mock_shorturl.NewMockShortURLFilter(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, filter, shorturl.RepositoryConfig{})
+
+	originalURL := "https://example.com/long"
+	c := context.Background()
+	filter.EXPECT().MightContain(c, "short").Return(true, nil)
+	cs.EXPECT().Get(c, "short").Return(&originalURL, nil)
+
+	result, err := repo.FindByShortURL(c, "short")
+	if err != nil || result.OriginalURL != originalURL {
+		t.Errorf("unexpected result %v %v", result, err)
+	}
+}
+
+func TestFindByShortURLCheckCacheIfFilterReturnError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	filter := mock_shorturl.NewMockShortURLFilter(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, filter, shorturl.RepositoryConfig{})
+
+	originalURL := "https://example.com/long"
+	c := context.Background()
+	filter.EXPECT().MightContain(c, "short").Return(true, errors.New("error"))
+	cs.EXPECT().Get(c, "short").Return(&originalURL, nil)
+
+	result, err := repo.FindByShortURL(c, "short")
+	if err != nil || result.OriginalURL != originalURL {
+		t.Errorf("unexpected result %v %v", result, err)
+	}
+}
+
+func TestSaveAddShortURLToFilter(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	filter := mock_shorturl.NewMockShortURLFilter(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, filter, shorturl.RepositoryConfig{})
+
+	url := &shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{
+			ShortURL:    "short",
+			OriginalURL: "https://example.com/long",
+		},
+		ExpireAt: time.Now(),
+	}
+	c := context.Background()
+	ps.EXPECT().Save(c, url).Return(nil)
+	filter.EXPECT().Add(c, "short").Return(nil)
+
+	err := repo.Save(c, url)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSaveManyAddSavedShortURLsToFilter(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	filter := mock_shorturl.NewMockShortURLFilter(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, filter, shorturl.RepositoryConfig{})
+
+	urls := []*shorturl.ShortURLWithExpireTime{
+		{ShortUrl: &shorturl.ShortURL{ShortURL: "saved"}},
+		{ShortUrl: &shorturl.ShortURL{ShortURL: "taken"}},
+	}
+	c := context.Background()
+	ps.EXPECT().SaveMany(c, urls).Return([]error{nil, shorturl.NewDuplicateShortURLError("taken")}, nil)
+	filter.EXPECT().Add(c, "saved").Return(nil)
+
+	_, err := repo.SaveMany(c, urls)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetByShortURLCallPersistentStoreFindByShortURL(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
+
+	url := &shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{
+			ShortURL:    "short",
+			OriginalURL: "https://example.com/long",
+		},
+		ExpireAt: time.Now(),
+	}
+	c := context.Background()
+	ps.EXPECT().FindByShortURL(c, url.ShortUrl.ShortURL).Return(url, nil)
+
+	result, err := repo.GetByShortURL(c, url.ShortUrl.ShortURL)
+	if err != nil {
+		t.Fail()
+	}
+	if result != url {
+		t.Fail()
+	}
+}
+
+func TestUpdateCallPersistentStoreUpdateAndDeleteCache(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
+
+	originalURL := "https://example.com/new"
+	update := &shorturl.ShortURLUpdate{OriginalURL: &originalURL}
+	url := &shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{
+			ShortURL:    "short",
+			OriginalURL: originalURL,
+		},
+		ExpireAt: time.Now(),
+	}
+	c := context.Background()
+	gomock.InOrder(
+		ps.EXPECT().Update(c, url.ShortUrl.ShortURL, update).Return(url, nil),
+		cs.EXPECT().Delete(c, url.ShortUrl.ShortURL).Return(nil),
+	)
+
+	result, err := repo.Update(c, url.ShortUrl.ShortURL, update)
+	if err != nil {
+		t.Fail()
+	}
+	if result != url {
+		t.Fail()
+	}
+}
+
+func TestUpdateReturnErrorIfPersistentStoreReturnError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
+
+	mockErr := errors.New("error")
+	update := &shorturl.ShortURLUpdate{}
+	c := context.Background()
+	ps.EXPECT().Update(c, "short", update).Return(nil, mockErr)
+
+	_, err := repo.Update(c, "short", update)
+	if err != mockErr {
+		t.Fail()
+	}
+}
+
+func TestDeleteCallPersistentStoreDeleteAndDeleteCache(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
+
+	c := context.Background()
+	gomock.InOrder(
+		ps.EXPECT().Delete(c, "short").Return(true, nil),
+		cs.EXPECT().Delete(c, "short").Return(nil),
+	)
+
+	deleted, err := repo.Delete(c, "short")
+	if err != nil {
+		t.Fail()
+	}
+	if !deleted {
+		t.Fail()
+	}
+}
+
+func TestDeleteReturnErrorIfCacheDeleteReturnError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ps, cs, tu := createMock(mockCtrl)
+	repo := shorturl.NewRepository(ps, cs, tu, nil, nil, shorturl.RepositoryConfig{})
+
+	mockErr := errors.New("error")
+	c := context.Background()
+	ps.EXPECT().Delete(c, "short").Return(true, nil)
+	cs.EXPECT().Delete(c, "short").Return(mockErr)
+
+	_, err := repo.Delete(c, "short")
+	if err != mockErr {
+		t.Fail()
+	}
+}
+
 func createMock(ctrl *gomock.Controller) (*mock_shorturl.MockPersistentStore, *mock_shorturl.MockCacheStore, *mock_utils.MockTimeUtil) {
 	mockCacheStore := mock_shorturl.NewMockCacheStore(ctrl)
 	mockPersistentStore := mock_shorturl.NewMockPersistentStore(ctrl)
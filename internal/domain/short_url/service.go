@@ -2,29 +2,199 @@ package shorturl
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"time"
+
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+	"golang.org/x/sync/singleflight"
 )
 
+var ErrShortURLExhausted = errors.New("unable to generate an unused short url")
+
 type ShortURLGenerator interface {
-	Generate(int) (string, error)
+	Generate(c context.Context, length int) (string, error)
+}
+
+// URLNormalizer rewrites original urls into a canonical form before they are saved.
+type URLNormalizer interface {
+	Normalize(rawURL string) (string, error)
+}
+
+// URLScreener blocks unsafe original urls, such as phishing sites. Screen returns *myerror.BlockedURLError
+// if the url is blocked, other errors mean the url could not be screened.
+type URLScreener interface {
+	Screen(c context.Context, rawURL string) error
 }
 
 type Service interface {
-	CreateShortURL(context.Context, string, time.Time) (*ShortURLWithExpireTime, error)
-	GetOriginalURL(context.Context, string) (*ShortURL, error)
+	// The owner ID argument is the ID of the API key that manages the short url.
+	// CreateShortURL returns true if an existing short url is reused instead of creating one.
+	CreateShortURL(c context.Context, ownerID, originalURL string, expireAt time.Time) (*ShortURLWithExpireTime, bool, error)
+	CreateShortURLWithAlias(c context.Context, ownerID, alias, originalURL string, expireAt time.Time) (*ShortURLWithExpireTime, error)
+	// CreateShortURLs returns the result of each input in the same order,
+	// the error is only returned if the whole batch fails.
+	CreateShortURLs(c context.Context, ownerID string, inputs []*CreateShortURLInput) ([]*CreateShortURLResult, error)
+	// GetOriginalURL returns *myerror.BlockedURLError if the original url is blocked since the short url is created.
+	GetOriginalURL(c context.Context, short string) (*ShortURL, error)
+	GetShortURL(c context.Context, ownerID, short string) (*ShortURLWithExpireTime, error)
+	UpdateShortURL(c context.Context, ownerID, short string, update *ShortURLUpdate) (*ShortURLWithExpireTime, error)
+	DeleteShortURL(c context.Context, ownerID, short string) error
+}
+
+type ServiceConfig struct {
+	// ShortURLLength is the length of generated short urls.
+	ShortURLLength int
+	// MaxRetry is how many times a colliding short url is regenerated before giving up
+	// on the current length.
+	MaxRetry int
+	// GrowLength increases the short url length by one when retries are exhausted,
+	// up to MaxShortURLLength.
+	GrowLength        bool
+	MaxShortURLLength int
+	// DeduplicateURLs reuses the unexpired short url of the owner to the same original url
+	// instead of creating one. The reused short url is extended if it expires before the requested time.
+	DeduplicateURLs bool
+}
+
+type CreateShortURLInput struct {
+	OriginalURL string
+	ExpireAt    time.Time
+	// Alias is used as the short url if it is not empty.
+	Alias string
+}
+
+type CreateShortURLResult struct {
+	ShortURL *ShortURLWithExpireTime
+	// Reused is true if an existing short url is returned instead of creating one.
+	Reused bool
+	Err    error
 }
 
 type service struct {
 	shortURLRepository ShortURLRepository
 	shortURLGenerator  ShortURLGenerator
+	urlNormalizer      URLNormalizer
+	urlScreener        URLScreener
+	config             ServiceConfig
+	createGroup        singleflight.Group
+}
+
+// NewService creates the service. The normalizer and the screener are optional, original urls are saved
+// as given without the normalizer and are not screened without the screener.
+func NewService(sr ShortURLRepository, sg ShortURLGenerator, n URLNormalizer, us URLScreener, config ServiceConfig) *service {
+	return &service{shortURLRepository: sr, shortURLGenerator: sg, urlNormalizer: n, urlScreener: us, config: config}
 }
 
-func NewService(sr ShortURLRepository, sg ShortURLGenerator) *service {
-	return &service{sr, sg}
+type createShortURLResult struct {
+	shortURL *ShortURLWithExpireTime
+	reused   bool
 }
 
-func (s *service) CreateShortURL(c context.Context, originalURL string, expireAt time.Time) (*ShortURLWithExpireTime, error) {
-	short, err := s.shortURLGenerator.Generate(7)
+// CreateShortURL deduplicates concurrent requests of the same owner and original url in this replica,
+// requests to different replicas may still create duplicated short urls.
+func (s *service) CreateShortURL(c context.Context, ownerID, originalURL string, expireAt time.Time) (*ShortURLWithExpireTime, bool, error) {
+	originalURL, err := s.checkURL(c, originalURL)
+	if err != nil {
+		return nil, false, err
+	}
+	if !s.config.DeduplicateURLs {
+		shortURL, err := s.createShortURL(c, ownerID, originalURL, expireAt)
+		return shortURL, false, err
+	}
+
+	key := ownerID + ":" + URLHash(originalURL)
+	for {
+		// shared of Do is also true for the caller running fn if others joined it, so the caller
+		// running fn is told by leader instead.
+		leader := false
+		v, err, _ := s.createGroup.Do(key, func() (any, error) {
+			leader = true
+			shortURL, reused, err := s.reuseShortURL(c, ownerID, originalURL, expireAt)
+			if err != nil || reused {
+				return &createShortURLResult{shortURL, reused}, err
+			}
+			shortURL, err = s.createShortURL(c, ownerID, originalURL, expireAt)
+			return &createShortURLResult{shortURL, false}, err
+		})
+		if leader {
+			if err != nil {
+				return nil, false, err
+			}
+			result := v.(*createShortURLResult)
+			return result.shortURL, result.reused, nil
+		}
+		// The shared result may expire before the requested time or fail due to the other request,
+		// so it is only reused if it fits; otherwise the request runs again.
+		if err == nil {
+			result := v.(*createShortURLResult)
+			if !result.shortURL.ExpireAt.Before(expireAt) {
+				return result.shortURL, true, nil
+			}
+		}
+		if c.Err() != nil {
+			return nil, false, c.Err()
+		}
+	}
+}
+
+// reuseShortURL finds the unexpired short url of the owner to the original url, and extends it
+// to the expire time if it expires earlier.
+func (s *service) reuseShortURL(c context.Context, ownerID, originalURL string, expireAt time.Time) (*ShortURLWithExpireTime, bool, error) {
+	shortURL, err := s.shortURLRepository.FindUnexpiredByOriginalURL(c, ownerID, originalURL)
+	if err != nil || shortURL == nil {
+		return nil, false, err
+	}
+	if !shortURL.ExpireAt.Before(expireAt) {
+		return shortURL, true, nil
+	}
+
+	shortURL, err = s.shortURLRepository.Update(c, shortURL.ShortUrl.ShortURL, &ShortURLUpdate{ExpireAt: &expireAt})
+	if err != nil || shortURL == nil {
+		// The short url is deleted meanwhile, a new one is created instead.
+		return nil, false, err
+	}
+	return shortURL, true, nil
+}
+
+func (s *service) createShortURL(c context.Context, ownerID, originalURL string, expireAt time.Time) (*ShortURLWithExpireTime, error) {
+	length := s.config.ShortURLLength
+	for {
+		for i := 0; i <= s.config.MaxRetry; i++ {
+			short, err := s.shortURLGenerator.Generate(c, length)
+			if err != nil {
+				return nil, err
+			}
+
+			shortURL := &ShortURLWithExpireTime{
+				ShortUrl: &ShortURL{
+					OriginalURL: originalURL,
+					ShortURL:    short,
+				},
+				ExpireAt: expireAt,
+				OwnerID:  ownerID,
+			}
+
+			err = s.shortURLRepository.Save(c, shortURL)
+			if err == nil {
+				return shortURL, nil
+			}
+
+			var duplicateErr *DuplicateShortURLError
+			if !errors.As(err, &duplicateErr) {
+				return nil, err
+			}
+		}
+
+		if !s.config.GrowLength || length >= s.config.MaxShortURLLength {
+			return nil, ErrShortURLExhausted
+		}
+		length++
+	}
+}
+
+func (s *service) CreateShortURLWithAlias(c context.Context, ownerID, alias, originalURL string, expireAt time.Time) (*ShortURLWithExpireTime, error) {
+	originalURL, err := s.checkURL(c, originalURL)
 	if err != nil {
 		return nil, err
 	}
@@ -32,23 +202,219 @@ func (s *service) CreateShortURL(c context.Context, originalURL string, expireAt
 	shortURL := &ShortURLWithExpireTime{
 		ShortUrl: &ShortURL{
 			OriginalURL: originalURL,
-			ShortURL:    short,
+			ShortURL:    alias,
 		},
 		ExpireAt: expireAt,
+		OwnerID:  ownerID,
 	}
 
 	err = s.shortURLRepository.Save(c, shortURL)
 	if err != nil {
+		var duplicateErr *DuplicateShortURLError
+		if errors.As(err, &duplicateErr) {
+			return nil, newAliasConflictError(alias)
+		}
 		return nil, err
 	}
 
 	return shortURL, nil
 }
 
+// CreateShortURLs regenerates colliding short urls up to MaxRetry times without growing the length.
+// With DeduplicateURLs, inputs without an alias reuse the existing short url of the original url,
+// and the inputs of the same original url in the batch share one short url, which lasts until
+// the latest requested expire time among them.
+func (s *service) CreateShortURLs(c context.Context, ownerID string, inputs []*CreateShortURLInput) ([]*CreateShortURLResult, error) {
+	results := make([]*CreateShortURLResult, len(inputs))
+	originalURLs := make([]string, len(inputs))
+	expireAts := make([]time.Time, len(inputs))
+	// duplicates maps the first input of an original url to the later inputs of the same original url.
+	duplicates := map[int][]int{}
+	firsts := map[string]int{}
+	var deduplicated []int
+	pending := make([]int, 0, len(inputs))
+	for i, input := range inputs {
+		originalURL, err := s.checkURL(c, input.OriginalURL)
+		if err != nil {
+			results[i] = &CreateShortURLResult{Err: err}
+			continue
+		}
+		originalURLs[i] = originalURL
+		expireAts[i] = input.ExpireAt
+		if !s.config.DeduplicateURLs || input.Alias != "" {
+			pending = append(pending, i)
+			continue
+		}
+		hash := URLHash(originalURL)
+		first, ok := firsts[hash]
+		if !ok {
+			firsts[hash] = i
+			deduplicated = append(deduplicated, i)
+			continue
+		}
+		duplicates[first] = append(duplicates[first], i)
+		if input.ExpireAt.After(expireAts[first]) {
+			expireAts[first] = input.ExpireAt
+		}
+	}
+
+	for _, i := range deduplicated {
+		shortURL, reused, err := s.reuseShortURL(c, ownerID, originalURLs[i], expireAts[i])
+		switch {
+		case err != nil:
+			results[i] = &CreateShortURLResult{Err: err}
+		case reused:
+			results[i] = &CreateShortURLResult{ShortURL: shortURL, Reused: true}
+		default:
+			pending = append(pending, i)
+		}
+	}
+
+	for retry := 0; len(pending) > 0; retry++ {
+		shortURLs := make([]*ShortURLWithExpireTime, len(pending))
+		for j, i := range pending {
+			short := inputs[i].Alias
+			if short == "" {
+				var err error
+				short, err = s.shortURLGenerator.Generate(c, s.config.ShortURLLength)
+				if err != nil {
+					return nil, err
+				}
+			}
+			shortURLs[j] = &ShortURLWithExpireTime{
+				ShortUrl: &ShortURL{
+					OriginalURL: originalURLs[i],
+					ShortURL:    short,
+				},
+				ExpireAt: expireAts[i],
+				OwnerID:  ownerID,
+			}
+		}
+
+		errs, err := s.shortURLRepository.SaveMany(c, shortURLs)
+		if err != nil {
+			return nil, err
+		}
+
+		var collided []int
+		for j, i := range pending {
+			var duplicateErr *DuplicateShortURLError
+			switch {
+			case errs[j] == nil:
+				results[i] = &CreateShortURLResult{ShortURL: shortURLs[j]}
+			case !errors.As(errs[j], &duplicateErr):
+				results[i] = &CreateShortURLResult{Err: errs[j]}
+			case inputs[i].Alias != "":
+				results[i] = &CreateShortURLResult{Err: newAliasConflictError(inputs[i].Alias)}
+			case retry < s.config.MaxRetry:
+				collided = append(collided, i)
+			default:
+				results[i] = &CreateShortURLResult{Err: ErrShortURLExhausted}
+			}
+		}
+		pending = collided
+	}
+
+	for first, later := range duplicates {
+		for _, i := range later {
+			result := *results[first]
+			result.Reused = result.Err == nil
+			results[i] = &result
+		}
+	}
+
+	return results, nil
+}
+
 func (s *service) GetOriginalURL(c context.Context, short string) (*ShortURL, error) {
 	shortURL, err := s.shortURLRepository.FindByShortURL(c, short)
+	if err != nil || shortURL == nil || s.urlScreener == nil {
+		return shortURL, err
+	}
+
+	// The lists may have changed since the short url is created. Redirects are not failed
+	// if the url can not be screened.
+	err = s.urlScreener.Screen(c, shortURL.OriginalURL)
+	var blockedErr *myerror.BlockedURLError
+	if errors.As(err, &blockedErr) {
+		return nil, blockedErr
+	}
+	if err != nil {
+		slog.WarnContext(c, "screen original url failed, redirecting", slog.String("short_url", short), slog.Any("error", err))
+	}
+	return shortURL, nil
+}
+
+// GetShortURL returns NotFoundError if the short url is owned by others,
+// so the existence of other owners' short urls is not leaked.
+func (s *service) GetShortURL(c context.Context, ownerID, short string) (*ShortURLWithExpireTime, error) {
+	shortURL, err := s.shortURLRepository.GetByShortURL(c, short)
+	if err != nil {
+		return nil, err
+	}
+	if shortURL == nil || shortURL.OwnerID != ownerID {
+		return nil, myerror.NewNotFoundError("short url", short)
+	}
+	return shortURL, nil
+}
+
+func (s *service) UpdateShortURL(c context.Context, ownerID, short string, update *ShortURLUpdate) (*ShortURLWithExpireTime, error) {
+	_, err := s.GetShortURL(c, ownerID, short)
 	if err != nil {
 		return nil, err
 	}
+	if update.OriginalURL != nil {
+		originalURL, err := s.checkURL(c, *update.OriginalURL)
+		if err != nil {
+			return nil, err
+		}
+		update = &ShortURLUpdate{OriginalURL: &originalURL, ExpireAt: update.ExpireAt}
+	}
+
+	shortURL, err := s.shortURLRepository.Update(c, short, update)
+	if err != nil {
+		return nil, err
+	}
+	if shortURL == nil {
+		return nil, myerror.NewNotFoundError("short url", short)
+	}
 	return shortURL, nil
 }
+
+func (s *service) DeleteShortURL(c context.Context, ownerID, short string) error {
+	_, err := s.GetShortURL(c, ownerID, short)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.shortURLRepository.Delete(c, short)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return myerror.NewNotFoundError("short url", short)
+	}
+	return nil
+}
+
+// checkURL normalizes the url and screens the normalized url. It returns ValidationError if the normalizer
+// rejects the url, or BlockedURLError if the screener blocks it.
+func (s *service) checkURL(c context.Context, originalURL string) (string, error) {
+	if s.urlNormalizer != nil {
+		normalized, err := s.urlNormalizer.Normalize(originalURL)
+		if err != nil {
+			return "", myerror.NewValidationError("url", originalURL, err.Error())
+		}
+		originalURL = normalized
+	}
+	if s.urlScreener != nil {
+		if err := s.urlScreener.Screen(c, originalURL); err != nil {
+			return "", err
+		}
+	}
+	return originalURL, nil
+}
+
+func newAliasConflictError(alias string) *myerror.ConflictError {
+	return myerror.NewConflictError("alias", alias, "alias is already taken")
+}
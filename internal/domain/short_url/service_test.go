@@ -3,11 +3,13 @@ package shorturl_test
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
 	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
 	mock_shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url/mocks"
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
 	"github.com/golang/mock/gomock"
 )
 
@@ -19,7 +21,7 @@ func TestCreateShortURLGenerateShortURLAndCallRepoSave(t *testing.T) {
 	originalURL := "https://pkg.go.dev/"
 	expireAt := time.Now()
 	shortURL := "aaaaaaa"
-	mockShortURLGenerator.EXPECT().Generate(7).Return(shortURL, nil)
+	mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return(shortURL, nil)
 	c := context.Background()
 	mockRepo.EXPECT().Save(c, &shorturl.ShortURLWithExpireTime{
 		ShortUrl: &shorturl.ShortURL{
@@ -27,14 +29,15 @@ func TestCreateShortURLGenerateShortURLAndCallRepoSave(t *testing.T) {
 			OriginalURL: originalURL,
 		},
 		ExpireAt: expireAt,
+		OwnerID:  "owner",
 	}).Return(nil)
 
-	result, err := service.CreateShortURL(c, originalURL, expireAt)
+	result, _, err := service.CreateShortURL(c, "owner", originalURL, expireAt)
 	if err != nil {
 		t.Fail()
 	}
 
-	if !result.ExpireAt.Equal(expireAt) || result.ShortUrl.OriginalURL != originalURL || result.ShortUrl.ShortURL != shortURL {
+	if !result.ExpireAt.Equal(expireAt) || result.ShortUrl.OriginalURL != originalURL || result.ShortUrl.ShortURL != shortURL || result.OwnerID != "owner" {
 		t.Fail()
 	}
 }
@@ -48,10 +51,10 @@ func TestCreateShortURLReturnErrorIfGeneratorReturnError(t *testing.T) {
 	expireAt := time.Now()
 
 	mockErr := errors.New("error")
-	mockShortURLGenerator.EXPECT().Generate(7).Return("", mockErr)
+	mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("", mockErr)
 
 	c := context.Background()
-	_, err := service.CreateShortURL(c, originalURL, expireAt)
+	_, _, err := service.CreateShortURL(c, "", originalURL, expireAt)
 
 	if err != mockErr {
 		t.Fail()
@@ -66,7 +69,7 @@ func TestCreateShortURLReturnErrorIfRepoSaveReturnError(t *testing.T) {
 	originalURL := "https://pkg.go.dev/"
 	expireAt := time.Now()
 	shortURL := "aaaaaaa"
-	mockShortURLGenerator.EXPECT().Generate(7).Return(shortURL, nil)
+	mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return(shortURL, nil)
 	mockErr := errors.New("error")
 	c := context.Background()
 	mockRepo.EXPECT().Save(c, &shorturl.ShortURLWithExpireTime{
@@ -77,7 +80,146 @@ func TestCreateShortURLReturnErrorIfRepoSaveReturnError(t *testing.T) {
 		ExpireAt: expireAt,
 	}).Return(mockErr)
 
-	_, err := service.CreateShortURL(c, originalURL, expireAt)
+	_, _, err := service.CreateShortURL(c, "", originalURL, expireAt)
+	if err != mockErr {
+		t.Fail()
+	}
+}
+
+func TestCreateShortURLWithAliasCallRepoSave(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, _, service := createService(ctrl)
+
+	originalURL := "https://pkg.go.dev/"
+	expireAt := time.Now()
+	alias := "spring-sale"
+	c := context.Background()
+	mockRepo.EXPECT().Save(c, &shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{
+			ShortURL:    alias,
+			OriginalURL: originalURL,
+		},
+		ExpireAt: expireAt,
+		OwnerID:  "owner",
+	}).Return(nil)
+
+	result, err := service.CreateShortURLWithAlias(c, "owner", alias, originalURL, expireAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ShortUrl.ShortURL != alias {
+		t.Fail()
+	}
+}
+
+func TestCreateShortURLWithAliasReturnConflictErrorIfAliasIsTaken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, _, service := createService(ctrl)
+
+	alias := "spring-sale"
+	c := context.Background()
+	mockRepo.EXPECT().Save(c, gomock.Any()).Return(shorturl.NewDuplicateShortURLError(alias))
+
+	_, err := service.CreateShortURLWithAlias(c, "", alias, "https://pkg.go.dev/", time.Now())
+	if _, ok := err.(*myerror.ConflictError); !ok {
+		t.Errorf("expect ConflictError, got %v", err)
+	}
+}
+
+func TestCreateShortURLsReturnResultsInOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, mockShortURLGenerator, service := createService(ctrl)
+
+	c := context.Background()
+	expireAt := time.Now()
+	inputs := []*shorturl.CreateShortURLInput{
+		{OriginalURL: "https://pkg.go.dev/a", ExpireAt: expireAt},
+		{OriginalURL: "https://pkg.go.dev/b", ExpireAt: expireAt, Alias: "taken"},
+		{OriginalURL: "https://pkg.go.dev/c", ExpireAt: expireAt, Alias: "spring-sale"},
+	}
+	mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("aaaaaaa", nil)
+	mockRepo.EXPECT().SaveMany(c, gomock.Len(3)).DoAndReturn(func(_ context.Context, urls []*shorturl.ShortURLWithExpireTime) ([]error, error) {
+		if urls[0].ShortUrl.ShortURL != "aaaaaaa" || urls[1].ShortUrl.ShortURL != "taken" || urls[2].ShortUrl.ShortURL != "spring-sale" {
+			t.Error("unexpected short urls")
+		}
+		if urls[2].OwnerID != "owner" || urls[2].ShortUrl.OriginalURL != "https://pkg.go.dev/c" {
+			t.Error("unexpected short url")
+		}
+		return []error{nil, shorturl.NewDuplicateShortURLError("taken"), nil}, nil
+	})
+
+	results, err := service.CreateShortURLs(c, "owner", inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].ShortURL.ShortUrl.ShortURL != "aaaaaaa" || results[2].ShortURL.ShortUrl.ShortURL != "spring-sale" {
+		t.Error("unexpected results")
+	}
+	if _, ok := results[1].Err.(*myerror.ConflictError); !ok {
+		t.Errorf("expect ConflictError, got %v", results[1].Err)
+	}
+}
+
+func TestCreateShortURLsRetryCollidedShortURLs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, mockShortURLGenerator, service := createService(ctrl)
+
+	c := context.Background()
+	inputs := []*shorturl.CreateShortURLInput{
+		{OriginalURL: "https://pkg.go.dev/a", ExpireAt: time.Now()},
+		{OriginalURL: "https://pkg.go.dev/b", ExpireAt: time.Now()},
+	}
+	gomock.InOrder(
+		mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("aaaaaaa", nil),
+		mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("bbbbbbb", nil),
+		mockRepo.EXPECT().SaveMany(c, gomock.Len(2)).Return([]error{nil, shorturl.NewDuplicateShortURLError("bbbbbbb")}, nil),
+		mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("ccccccc", nil),
+		mockRepo.EXPECT().SaveMany(c, gomock.Len(1)).Return([]error{nil}, nil),
+	)
+
+	results, err := service.CreateShortURLs(c, "", inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].ShortURL.ShortUrl.ShortURL != "aaaaaaa" || results[1].ShortURL.ShortUrl.ShortURL != "ccccccc" {
+		t.Error("unexpected results")
+	}
+}
+
+func TestCreateShortURLsReturnExhaustedErrorIfRetryIsExhausted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, mockShortURLGenerator, service := createService(ctrl)
+
+	c := context.Background()
+	inputs := []*shorturl.CreateShortURLInput{{OriginalURL: "https://pkg.go.dev/a", ExpireAt: time.Now()}}
+	mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("aaaaaaa", nil).Times(3)
+	mockRepo.EXPECT().SaveMany(c, gomock.Len(1)).Return([]error{shorturl.NewDuplicateShortURLError("aaaaaaa")}, nil).Times(3)
+
+	results, err := service.CreateShortURLs(c, "", inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err != shorturl.ErrShortURLExhausted {
+		t.Errorf("expect ErrShortURLExhausted, got %v", results[0].Err)
+	}
+}
+
+func TestCreateShortURLsReturnErrorIfRepoSaveManyReturnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, mockShortURLGenerator, service := createService(ctrl)
+
+	c := context.Background()
+	mockErr := errors.New("error")
+	mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("aaaaaaa", nil)
+	mockRepo.EXPECT().SaveMany(c, gomock.Len(1)).Return(nil, mockErr)
+
+	_, err := service.CreateShortURLs(c, "", []*shorturl.CreateShortURLInput{{OriginalURL: "https://pkg.go.dev/a"}})
 	if err != mockErr {
 		t.Fail()
 	}
@@ -123,9 +265,672 @@ func TestGetOriginalURLReturnErrorIfRepoFindReturnError(t *testing.T) {
 	}
 }
 
+func TestCreateShortURLRetryIfRepoSaveReturnDuplicateError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, mockShortURLGenerator, service := createService(ctrl)
+
+	originalURL := "https://pkg.go.dev/"
+	expireAt := time.Now()
+	c := context.Background()
+	gomock.InOrder(
+		mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("aaaaaaa", nil),
+		mockRepo.EXPECT().Save(c, gomock.Any()).Return(shorturl.NewDuplicateShortURLError("aaaaaaa")),
+		mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("bbbbbbb", nil),
+		mockRepo.EXPECT().Save(c, gomock.Any()).Return(nil),
+	)
+
+	result, _, err := service.CreateShortURL(c, "", originalURL, expireAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ShortUrl.ShortURL != "bbbbbbb" {
+		t.Errorf("expect short url bbbbbbb, got %s", result.ShortUrl.ShortURL)
+	}
+}
+
+func TestCreateShortURLReturnErrorIfRetryIsExhausted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, mockShortURLGenerator, service := createService(ctrl)
+
+	c := context.Background()
+	mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("aaaaaaa", nil).Times(3)
+	mockRepo.EXPECT().Save(c, gomock.Any()).Return(shorturl.NewDuplicateShortURLError("aaaaaaa")).Times(3)
+
+	_, _, err := service.CreateShortURL(c, "", "https://pkg.go.dev/", time.Now())
+	if err != shorturl.ErrShortURLExhausted {
+		t.Errorf("expect ErrShortURLExhausted, got %v", err)
+	}
+}
+
+func TestCreateShortURLGrowLengthIfRetryIsExhausted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mock_shorturl.NewMockShortURLRepository(ctrl)
+	mockShortURLGenerator := mock_shorturl.NewMockShortURLGenerator(ctrl)
+	service := shorturl.NewService(mockRepo, mockShortURLGenerator, nil, nil, shorturl.ServiceConfig{
+		ShortURLLength:    7,
+		MaxRetry:          1,
+		GrowLength:        true,
+		MaxShortURLLength: 8,
+	})
+
+	c := context.Background()
+	duplicateErr := shorturl.NewDuplicateShortURLError("aaaaaaa")
+	gomock.InOrder(
+		mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("aaaaaaa", nil),
+		mockRepo.EXPECT().Save(c, gomock.Any()).Return(duplicateErr),
+		mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("aaaaaaa", nil),
+		mockRepo.EXPECT().Save(c, gomock.Any()).Return(duplicateErr),
+		mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 8).Return("aaaaaaaa", nil),
+		mockRepo.EXPECT().Save(c, gomock.Any()).Return(nil),
+	)
+
+	result, _, err := service.CreateShortURL(c, "", "https://pkg.go.dev/", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ShortUrl.ShortURL != "aaaaaaaa" {
+		t.Errorf("expect short url aaaaaaaa, got %s", result.ShortUrl.ShortURL)
+	}
+}
+
+func TestGetShortURLReturnExpectedURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, _, service := createService(ctrl)
+
+	shortURL := createOwnedShortURL("owner")
+	c := context.Background()
+	mockRepo.EXPECT().GetByShortURL(c, "aaaaaaa").Return(shortURL, nil)
+
+	result, err := service.GetShortURL(c, "owner", "aaaaaaa")
+	if err != nil {
+		t.Fail()
+	}
+	if result != shortURL {
+		t.Fail()
+	}
+}
+
+func TestGetShortURLReturnNotFoundErrorIfRepoReturnNil(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, _, service := createService(ctrl)
+
+	c := context.Background()
+	mockRepo.EXPECT().GetByShortURL(c, "aaaaaaa").Return(nil, nil)
+
+	_, err := service.GetShortURL(c, "owner", "aaaaaaa")
+	if _, ok := err.(*myerror.NotFoundError); !ok {
+		t.Errorf("expect NotFoundError, got %v", err)
+	}
+}
+
+func TestGetShortURLReturnNotFoundErrorIfOwnedByOthers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, _, service := createService(ctrl)
+
+	c := context.Background()
+	mockRepo.EXPECT().GetByShortURL(c, "aaaaaaa").Return(createOwnedShortURL("other"), nil)
+
+	_, err := service.GetShortURL(c, "owner", "aaaaaaa")
+	if _, ok := err.(*myerror.NotFoundError); !ok {
+		t.Errorf("expect NotFoundError, got %v", err)
+	}
+}
+
+func TestUpdateShortURLCallRepoUpdate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, _, service := createService(ctrl)
+
+	c := context.Background()
+	update := &shorturl.ShortURLUpdate{}
+	shortURL := createOwnedShortURL("owner")
+	mockRepo.EXPECT().GetByShortURL(c, "aaaaaaa").Return(shortURL, nil)
+	mockRepo.EXPECT().Update(c, "aaaaaaa", update).Return(shortURL, nil)
+
+	result, err := service.UpdateShortURL(c, "owner", "aaaaaaa", update)
+	if err != nil {
+		t.Fail()
+	}
+	if result != shortURL {
+		t.Fail()
+	}
+}
+
+func TestUpdateShortURLReturnNotFoundErrorIfOwnedByOthers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, _, service := createService(ctrl)
+
+	c := context.Background()
+	update := &shorturl.ShortURLUpdate{}
+	mockRepo.EXPECT().GetByShortURL(c, "aaaaaaa").Return(createOwnedShortURL("other"), nil)
+
+	_, err := service.UpdateShortURL(c, "owner", "aaaaaaa", update)
+	if _, ok := err.(*myerror.NotFoundError); !ok {
+		t.Errorf("expect NotFoundError, got %v", err)
+	}
+}
+
+func TestUpdateShortURLReturnNotFoundErrorIfRepoReturnNil(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, _, service := createService(ctrl)
+
+	c := context.Background()
+	update := &shorturl.ShortURLUpdate{}
+	mockRepo.EXPECT().GetByShortURL(c, "aaaaaaa").Return(createOwnedShortURL("owner"), nil)
+	mockRepo.EXPECT().Update(c, "aaaaaaa", update).Return(nil, nil)
+
+	_, err := service.UpdateShortURL(c, "owner", "aaaaaaa", update)
+	if _, ok := err.(*myerror.NotFoundError); !ok {
+		t.Errorf("expect NotFoundError, got %v", err)
+	}
+}
+
+func TestDeleteShortURLCallRepoDelete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, _, service := createService(ctrl)
+
+	c := context.Background()
+	mockRepo.EXPECT().GetByShortURL(c, "aaaaaaa").Return(createOwnedShortURL("owner"), nil)
+	mockRepo.EXPECT().Delete(c, "aaaaaaa").Return(true, nil)
+
+	err := service.DeleteShortURL(c, "owner", "aaaaaaa")
+	if err != nil {
+		t.Fail()
+	}
+}
+
+func TestDeleteShortURLReturnNotFoundErrorIfOwnedByOthers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, _, service := createService(ctrl)
+
+	c := context.Background()
+	mockRepo.EXPECT().GetByShortURL(c, "aaaaaaa").Return(createOwnedShortURL("other"), nil)
+
+	err := service.DeleteShortURL(c, "owner", "aaaaaaa")
+	if _, ok := err.(*myerror.NotFoundError); !ok {
+		t.Errorf("expect NotFoundError, got %v", err)
+	}
+}
+
+func TestDeleteShortURLReturnNotFoundErrorIfNothingDeleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, _, service := createService(ctrl)
+
+	c := context.Background()
+	mockRepo.EXPECT().GetByShortURL(c, "aaaaaaa").Return(createOwnedShortURL("owner"), nil)
+	mockRepo.EXPECT().Delete(c, "aaaaaaa").Return(false, nil)
+
+	err := service.DeleteShortURL(c, "owner", "aaaaaaa")
+	if _, ok := err.(*myerror.NotFoundError); !ok {
+		t.Errorf("expect NotFoundError, got %v", err)
+	}
+}
+
+func createOwnedShortURL(ownerID string) *shorturl.ShortURLWithExpireTime {
+	return &shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{
+			ShortURL:    "aaaaaaa",
+			OriginalURL: "https://pkg.go.dev",
+		},
+		ExpireAt: time.Now(),
+		OwnerID:  ownerID,
+	}
+}
+
+func TestCreateShortURLReuseUnexpiredShortURLIfDeduplicated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, _, service := createDeduplicatingService(ctrl)
+
+	c := context.Background()
+	expireAt := time.Now().Add(time.Hour)
+	existing := &shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{ShortURL: "aaaaaaa", OriginalURL: "https://pkg.go.dev/"},
+		ExpireAt: expireAt.Add(time.Hour),
+		OwnerID:  "owner",
+	}
+	mockRepo.EXPECT().FindUnexpiredByOriginalURL(c, "owner", "https://pkg.go.dev/").Return(existing, nil)
+
+	result, reused, err := service.CreateShortURL(c, "owner", "https://pkg.go.dev/", expireAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reused || result != existing {
+		t.Errorf("expect the existing short url to be reused, got %+v reused %v", result, reused)
+	}
+}
+
+func TestCreateShortURLExtendReusedShortURLExpiringEarlier(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, _, service := createDeduplicatingService(ctrl)
+
+	c := context.Background()
+	expireAt := time.Now().Add(time.Hour)
+	existing := &shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{ShortURL: "aaaaaaa", OriginalURL: "https://pkg.go.dev/"},
+		ExpireAt: expireAt.Add(-time.Minute),
+		OwnerID:  "owner",
+	}
+	extended := &shorturl.ShortURLWithExpireTime{ShortUrl: existing.ShortUrl, ExpireAt: expireAt, OwnerID: "owner"}
+	mockRepo.EXPECT().FindUnexpiredByOriginalURL(c, "owner", "https://pkg.go.dev/").Return(existing, nil)
+	mockRepo.EXPECT().Update(c, "aaaaaaa", &shorturl.ShortURLUpdate{ExpireAt: &expireAt}).Return(extended, nil)
+
+	result, reused, err := service.CreateShortURL(c, "owner", "https://pkg.go.dev/", expireAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reused || !result.ExpireAt.Equal(expireAt) {
+		t.Errorf("expect the existing short url to be extended, got %+v reused %v", result, reused)
+	}
+}
+
+func TestCreateShortURLCreateShortURLIfNoneToReuse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, mockShortURLGenerator, service := createDeduplicatingService(ctrl)
+
+	c := context.Background()
+	expireAt := time.Now().Add(time.Hour)
+	mockRepo.EXPECT().FindUnexpiredByOriginalURL(c, "owner", "https://pkg.go.dev/").Return(nil, nil)
+	mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("aaaaaaa", nil)
+	mockRepo.EXPECT().Save(c, gomock.Any()).Return(nil)
+
+	result, reused, err := service.CreateShortURL(c, "owner", "https://pkg.go.dev/", expireAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reused || result.ShortUrl.ShortURL != "aaaaaaa" {
+		t.Errorf("expect a short url to be created, got %+v reused %v", result, reused)
+	}
+}
+
+func TestCreateShortURLDoNotLookUpIfNotDeduplicated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, mockShortURLGenerator, service := createService(ctrl)
+
+	c := context.Background()
+	mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("aaaaaaa", nil)
+	mockRepo.EXPECT().Save(c, gomock.Any()).Return(nil)
+	mockRepo.EXPECT().FindUnexpiredByOriginalURL(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, reused, err := service.CreateShortURL(c, "owner", "https://pkg.go.dev/", time.Now())
+	if err != nil || reused {
+		t.Errorf("expect a short url to be created, got reused %v error %v", reused, err)
+	}
+}
+
+// createConcurrently calls CreateShortURL of the same url n times. The first call blocks in the
+// repository until the others had time to join it.
+func createConcurrently(service shorturl.Service, n int, started <-chan struct{}, release chan<- struct{}) ([]bool, []error) {
+	c := context.Background()
+	expireAt := time.Now().Add(time.Hour)
+	reused := make([]bool, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	create := func(i int) {
+		defer wg.Done()
+		_, reused[i], errs[i] = service.CreateShortURL(c, "owner", "https://pkg.go.dev/", expireAt)
+	}
+
+	wg.Add(n)
+	go create(0)
+	<-started
+	for i := 1; i < n; i++ {
+		go create(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	return reused, errs
+}
+
+func TestCreateShortURLReportCreatedToTheConcurrentRequestCreatingIt(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, mockShortURLGenerator, service := createDeduplicatingService(ctrl)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mockRepo.EXPECT().FindUnexpiredByOriginalURL(gomock.Any(), "owner", "https://pkg.go.dev/").DoAndReturn(
+		func(context.Context, string, string) (*shorturl.ShortURLWithExpireTime, error) {
+			close(started)
+			<-release
+			return nil, nil
+		})
+	mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("aaaaaaa", nil)
+	mockRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+
+	reused, errs := createConcurrently(service, 5, started, release)
+
+	created := 0
+	for i := range reused {
+		if errs[i] != nil {
+			t.Errorf("unexpected error %v", errs[i])
+		}
+		if !reused[i] {
+			created++
+		}
+	}
+	if created != 1 || reused[0] {
+		t.Errorf("expect only the first request to report created, got reused %v", reused)
+	}
+}
+
+func TestCreateShortURLReturnErrorToTheConcurrentRequestFailing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, _, service := createDeduplicatingService(ctrl)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mockErr := errors.New("error")
+	existing := &shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{ShortURL: "aaaaaaa", OriginalURL: "https://pkg.go.dev/"},
+		ExpireAt: time.Now().Add(2 * time.Hour),
+		OwnerID:  "owner",
+	}
+	mockRepo.EXPECT().FindUnexpiredByOriginalURL(gomock.Any(), "owner", "https://pkg.go.dev/").DoAndReturn(
+		func(context.Context, string, string) (*shorturl.ShortURLWithExpireTime, error) {
+			close(started)
+			<-release
+			return nil, mockErr
+		})
+	// the requests joining the failed one run again
+	mockRepo.EXPECT().FindUnexpiredByOriginalURL(gomock.Any(), "owner", "https://pkg.go.dev/").Return(existing, nil).AnyTimes()
+
+	reused, errs := createConcurrently(service, 5, started, release)
+
+	if errs[0] != mockErr {
+		t.Errorf("expect the first request to return its error, got %v", errs[0])
+	}
+	for i := 1; i < len(errs); i++ {
+		if errs[i] != nil || !reused[i] {
+			t.Errorf("expect the joined request to reuse the short url, got reused %v error %v", reused[i], errs[i])
+		}
+	}
+}
+
+func TestCreateShortURLsDeduplicateInputs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, mockShortURLGenerator, service := createDeduplicatingService(ctrl)
+
+	c := context.Background()
+	expireAt := time.Now().Add(time.Hour)
+	existing := &shorturl.ShortURLWithExpireTime{
+		ShortUrl: &shorturl.ShortURL{ShortURL: "existing", OriginalURL: "https://pkg.go.dev/a"},
+		ExpireAt: expireAt,
+		OwnerID:  "owner",
+	}
+	inputs := []*shorturl.CreateShortURLInput{
+		{OriginalURL: "https://pkg.go.dev/a", ExpireAt: expireAt},
+		{OriginalURL: "https://pkg.go.dev/b", ExpireAt: expireAt},
+		{OriginalURL: "HTTPS://PKG.GO.DEV/b", ExpireAt: expireAt.Add(time.Hour)},
+		{OriginalURL: "https://pkg.go.dev/b", ExpireAt: expireAt, Alias: "spring-sale"},
+	}
+	mockRepo.EXPECT().FindUnexpiredByOriginalURL(c, "owner", "https://pkg.go.dev/a").Return(existing, nil)
+	mockRepo.EXPECT().FindUnexpiredByOriginalURL(c, "owner", "https://pkg.go.dev/b").Return(nil, nil)
+	mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("bbbbbbb", nil)
+	mockRepo.EXPECT().SaveMany(c, gomock.Len(2)).DoAndReturn(func(_ context.Context, urls []*shorturl.ShortURLWithExpireTime) ([]error, error) {
+		if urls[0].ShortUrl.ShortURL != "spring-sale" || urls[1].ShortUrl.ShortURL != "bbbbbbb" {
+			t.Error("unexpected short urls")
+		}
+		if !urls[1].ExpireAt.Equal(expireAt.Add(time.Hour)) {
+			t.Errorf("expect the latest expire time of duplicated inputs, got %v", urls[1].ExpireAt)
+		}
+		return []error{nil, nil}, nil
+	})
+
+	results, err := service.CreateShortURLs(c, "owner", inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].ShortURL != existing || !results[0].Reused {
+		t.Errorf("expect the existing short url to be reused, got %+v", results[0])
+	}
+	if results[1].ShortURL.ShortUrl.ShortURL != "bbbbbbb" || results[1].Reused {
+		t.Errorf("expect a short url to be created, got %+v", results[1])
+	}
+	if results[2].ShortURL.ShortUrl.ShortURL != "bbbbbbb" || !results[2].Reused {
+		t.Errorf("expect the short url created in the batch to be reused, got %+v", results[2])
+	}
+	if results[3].ShortURL.ShortUrl.ShortURL != "spring-sale" || results[3].Reused {
+		t.Errorf("expect the alias not to be deduplicated, got %+v", results[3])
+	}
+	if inputs[1].ExpireAt != expireAt {
+		t.Error("expect the inputs not to be modified")
+	}
+}
+
+func TestCreateShortURLSaveNormalizedURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, mockShortURLGenerator, mockNormalizer, service := createNormalizingService(ctrl)
+
+	c := context.Background()
+	mockNormalizer.EXPECT().Normalize("HTTPS://PKG.GO.DEV:443/").Return("https://pkg.go.dev/", nil)
+	mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("aaaaaaa", nil)
+	mockRepo.EXPECT().Save(c, gomock.Any()).DoAndReturn(func(_ context.Context, url *shorturl.ShortURLWithExpireTime) error {
+		if url.ShortUrl.OriginalURL != "https://pkg.go.dev/" {
+			t.Errorf("expect the normalized url to be saved, got %s", url.ShortUrl.OriginalURL)
+		}
+		return nil
+	})
+
+	_, _, err := service.CreateShortURL(c, "owner", "HTTPS://PKG.GO.DEV:443/", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateShortURLReturnValidationErrorIfNormalizeFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	_, _, mockNormalizer, service := createNormalizingService(ctrl)
+
+	c := context.Background()
+	mockNormalizer.EXPECT().Normalize("https://pkg.go.dev/%zz").Return("", errors.New("invalid url")).Times(2)
+
+	_, _, err := service.CreateShortURL(c, "owner", "https://pkg.go.dev/%zz", time.Now())
+	if _, ok := err.(*myerror.ValidationError); !ok {
+		t.Errorf("expect ValidationError, got %v", err)
+	}
+	_, err = service.CreateShortURLWithAlias(c, "owner", "alias", "https://pkg.go.dev/%zz", time.Now())
+	if _, ok := err.(*myerror.ValidationError); !ok {
+		t.Errorf("expect ValidationError, got %v", err)
+	}
+}
+
+func TestCreateShortURLsNormalizeEachInput(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, mockShortURLGenerator, mockNormalizer, service := createNormalizingService(ctrl)
+
+	c := context.Background()
+	inputs := []*shorturl.CreateShortURLInput{
+		{OriginalURL: "https://pkg.go.dev/%zz"},
+		{OriginalURL: "HTTPS://pkg.go.dev/a"},
+	}
+	mockNormalizer.EXPECT().Normalize("https://pkg.go.dev/%zz").Return("", errors.New("invalid url"))
+	mockNormalizer.EXPECT().Normalize("HTTPS://pkg.go.dev/a").Return("https://pkg.go.dev/a", nil)
+	mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("aaaaaaa", nil)
+	mockRepo.EXPECT().SaveMany(c, gomock.Len(1)).DoAndReturn(func(_ context.Context, urls []*shorturl.ShortURLWithExpireTime) ([]error, error) {
+		if urls[0].ShortUrl.OriginalURL != "https://pkg.go.dev/a" {
+			t.Errorf("expect the normalized url to be saved, got %s", urls[0].ShortUrl.OriginalURL)
+		}
+		return []error{nil}, nil
+	})
+
+	results, err := service.CreateShortURLs(c, "owner", inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := results[0].Err.(*myerror.ValidationError); !ok {
+		t.Errorf("expect ValidationError, got %v", results[0].Err)
+	}
+	if results[1].Err != nil || inputs[1].OriginalURL != "HTTPS://pkg.go.dev/a" {
+		t.Errorf("expect the url to be created without modifying the input, got %v", results[1].Err)
+	}
+}
+
+func TestUpdateShortURLSaveNormalizedURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, _, mockNormalizer, service := createNormalizingService(ctrl)
+
+	c := context.Background()
+	originalURL := "HTTPS://pkg.go.dev/a"
+	normalized := "https://pkg.go.dev/a"
+	shortURL := createOwnedShortURL("owner")
+	mockRepo.EXPECT().GetByShortURL(c, "aaaaaaa").Return(shortURL, nil)
+	mockNormalizer.EXPECT().Normalize(originalURL).Return(normalized, nil)
+	mockRepo.EXPECT().Update(c, "aaaaaaa", &shorturl.ShortURLUpdate{OriginalURL: &normalized}).Return(shortURL, nil)
+
+	_, err := service.UpdateShortURL(c, "owner", "aaaaaaa", &shorturl.ShortURLUpdate{OriginalURL: &originalURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateShortURLReturnBlockedURLErrorIfScreenerBlocks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	_, _, mockScreener, service := createScreeningService(ctrl)
+
+	c := context.Background()
+	originalURL := "http://127.0.0.1/admin"
+	blockedErr := myerror.NewBlockedURLError(originalURL, "the host is a loopback address")
+	mockScreener.EXPECT().Screen(c, originalURL).Return(blockedErr).Times(2)
+
+	_, _, err := service.CreateShortURL(c, "owner", originalURL, time.Now())
+	if err != blockedErr {
+		t.Errorf("expect BlockedURLError, got %v", err)
+	}
+	_, err = service.CreateShortURLWithAlias(c, "owner", "alias", originalURL, time.Now())
+	if err != blockedErr {
+		t.Errorf("expect BlockedURLError, got %v", err)
+	}
+}
+
+func TestCreateShortURLsScreenEachInput(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, mockShortURLGenerator, mockScreener, service := createScreeningService(ctrl)
+
+	c := context.Background()
+	inputs := []*shorturl.CreateShortURLInput{
+		{OriginalURL: "http://127.0.0.1/admin"},
+		{OriginalURL: "https://pkg.go.dev"},
+	}
+	blockedErr := myerror.NewBlockedURLError(inputs[0].OriginalURL, "the host is a loopback address")
+	mockScreener.EXPECT().Screen(c, inputs[0].OriginalURL).Return(blockedErr)
+	mockScreener.EXPECT().Screen(c, inputs[1].OriginalURL).Return(nil)
+	mockShortURLGenerator.EXPECT().Generate(gomock.Any(), 7).Return("aaaaaaa", nil)
+	mockRepo.EXPECT().SaveMany(c, gomock.Len(1)).Return([]error{nil}, nil)
+
+	results, err := service.CreateShortURLs(c, "owner", inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err != blockedErr {
+		t.Errorf("expect BlockedURLError, got %v", results[0].Err)
+	}
+	if results[1].Err != nil {
+		t.Errorf("expect the url to be created, got %v", results[1].Err)
+	}
+}
+
+func TestGetOriginalURLReturnBlockedURLErrorIfScreenerBlocks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, _, mockScreener, service := createScreeningService(ctrl)
+
+	shortURL := &shorturl.ShortURL{
+		ShortURL:    "aaaaaaa",
+		OriginalURL: "https://phishing.example",
+	}
+	c := context.Background()
+	blockedErr := myerror.NewBlockedURLError(shortURL.OriginalURL, "phishing.example is in the blocklist")
+	mockRepo.EXPECT().FindByShortURL(c, shortURL.ShortURL).Return(shortURL, nil)
+	mockScreener.EXPECT().Screen(c, shortURL.OriginalURL).Return(blockedErr)
+
+	result, err := service.GetOriginalURL(c, shortURL.ShortURL)
+	if err != blockedErr || result != nil {
+		t.Errorf("expect BlockedURLError, got %v", err)
+	}
+}
+
+func TestGetOriginalURLReturnURLIfScreenerFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo, _, mockScreener, service := createScreeningService(ctrl)
+
+	shortURL := &shorturl.ShortURL{
+		ShortURL:    "aaaaaaa",
+		OriginalURL: "https://pkg.go.dev",
+	}
+	c := context.Background()
+	mockRepo.EXPECT().FindByShortURL(c, shortURL.ShortURL).Return(shortURL, nil)
+	mockScreener.EXPECT().Screen(c, shortURL.OriginalURL).Return(errors.New("error"))
+
+	result, err := service.GetOriginalURL(c, shortURL.ShortURL)
+	if err != nil || result != shortURL {
+		t.Errorf("expect the url to be returned, got %v", err)
+	}
+}
+
 func createService(ctrl *gomock.Controller) (*mock_shorturl.MockShortURLRepository, *mock_shorturl.MockShortURLGenerator, shorturl.Service) {
 	mockRepo := mock_shorturl.NewMockShortURLRepository(ctrl)
 	mockShortURLGenerator := mock_shorturl.NewMockShortURLGenerator(ctrl)
-	service := shorturl.NewService(mockRepo, mockShortURLGenerator)
+	service := shorturl.NewService(mockRepo, mockShortURLGenerator, nil, nil, shorturl.ServiceConfig{
+		ShortURLLength: 7,
+		MaxRetry:       2,
+	})
+	return mockRepo, mockShortURLGenerator, service
+}
+
+func createDeduplicatingService(ctrl *gomock.Controller) (*mock_shorturl.MockShortURLRepository, *mock_shorturl.MockShortURLGenerator, shorturl.Service) {
+	mockRepo := mock_shorturl.NewMockShortURLRepository(ctrl)
+	mockShortURLGenerator := mock_shorturl.NewMockShortURLGenerator(ctrl)
+	service := shorturl.NewService(mockRepo, mockShortURLGenerator, nil, nil, shorturl.ServiceConfig{
+		ShortURLLength:  7,
+		MaxRetry:        2,
+		DeduplicateURLs: true,
+	})
 	return mockRepo, mockShortURLGenerator, service
 }
+
+func createNormalizingService(ctrl *gomock.Controller) (*mock_shorturl.MockShortURLRepository, *mock_shorturl.MockShortURLGenerator, *mock_shorturl.MockURLNormalizer, shorturl.Service) {
+	mockRepo := mock_shorturl.NewMockShortURLRepository(ctrl)
+	mockShortURLGenerator := mock_shorturl.NewMockShortURLGenerator(ctrl)
+	mockNormalizer := mock_shorturl.NewMockURLNormalizer(ctrl)
+	service := shorturl.NewService(mockRepo, mockShortURLGenerator, mockNormalizer, nil, shorturl.ServiceConfig{
+		ShortURLLength: 7,
+		MaxRetry:       2,
+	})
+	return mockRepo, mockShortURLGenerator, mockNormalizer, service
+}
+
+func createScreeningService(ctrl *gomock.Controller) (*mock_shorturl.MockShortURLRepository, *mock_shorturl.MockShortURLGenerator, *mock_shorturl.MockURLScreener, shorturl.Service) {
+	mockRepo := mock_shorturl.NewMockShortURLRepository(ctrl)
+	mockShortURLGenerator := mock_shorturl.NewMockShortURLGenerator(ctrl)
+	mockScreener := mock_shorturl.NewMockURLScreener(ctrl)
+	service := shorturl.NewService(mockRepo, mockShortURLGenerator, nil, mockScreener, shorturl.ServiceConfig{
+		ShortURLLength: 7,
+		MaxRetry:       2,
+	})
+	return mockRepo, mockShortURLGenerator, mockScreener, service
+}
@@ -0,0 +1,178 @@
+package shorturl
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/database"
+)
+
+const SHORT_URL_COLUMNS = "short_url, original_url, expire_at, owner_id"
+
+// SQL_FOR_EACH_BATCH_SIZE is how many short url ids ForEachShortURL reads per query.
+const SQL_FOR_EACH_BATCH_SIZE = 1000
+
+// INSERT_COLUMNS also fills url_hash, which is only used in lookups.
+const INSERT_COLUMNS = SHORT_URL_COLUMNS + ", url_hash"
+
+// SQLPersistentStore stores short urls in the short_urls table created by the database migrations.
+type SQLPersistentStore struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+func NewSQLPersistentStore(db *sql.DB, dialect database.Dialect) *SQLPersistentStore {
+	return &SQLPersistentStore{db, dialect}
+}
+
+func (s *SQLPersistentStore) Save(c context.Context, shortUrl *ShortURLWithExpireTime) error {
+	_, err := s.db.ExecContext(c, s.dialect.Rebind("INSERT INTO short_urls ("+INSERT_COLUMNS+") VALUES (?, ?, ?, ?, ?)"),
+		shortUrl.ShortUrl.ShortURL, shortUrl.ShortUrl.OriginalURL, shortUrl.ExpireAt.UTC(), shortUrl.OwnerID, URLHash(shortUrl.ShortUrl.OriginalURL))
+	if database.IsUniqueViolation(err) {
+		return NewDuplicateShortURLError(shortUrl.ShortUrl.ShortURL)
+	}
+
+	return err
+}
+
+// SaveMany inserts the short urls in a transaction, "ON CONFLICT DO NOTHING" reports duplicates
+// without aborting the transaction in Postgres.
+func (s *SQLPersistentStore) SaveMany(c context.Context, shortUrls []*ShortURLWithExpireTime) ([]error, error) {
+	tx, err := s.db.BeginTx(c, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(c, s.dialect.Rebind("INSERT INTO short_urls ("+INSERT_COLUMNS+") VALUES (?, ?, ?, ?, ?) ON CONFLICT (short_url) DO NOTHING"))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	errs := make([]error, len(shortUrls))
+	for i, shortUrl := range shortUrls {
+		result, err := stmt.ExecContext(c, shortUrl.ShortUrl.ShortURL, shortUrl.ShortUrl.OriginalURL, shortUrl.ExpireAt.UTC(), shortUrl.OwnerID,
+			URLHash(shortUrl.ShortUrl.OriginalURL))
+		if err != nil {
+			return nil, err
+		}
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if inserted == 0 {
+			errs[i] = NewDuplicateShortURLError(shortUrl.ShortUrl.ShortURL)
+		}
+	}
+
+	return errs, tx.Commit()
+}
+
+func (s *SQLPersistentStore) FindByShortURL(c context.Context, shortURL string) (*ShortURLWithExpireTime, error) {
+	row := s.db.QueryRowContext(c, s.dialect.Rebind("SELECT "+SHORT_URL_COLUMNS+" FROM short_urls WHERE short_url = ?"), shortURL)
+	return scanShortURL(row)
+}
+
+func (s *SQLPersistentStore) FindUnexpiredByShortURL(c context.Context, shortURL string) (*ShortURLWithExpireTime, bool, error) {
+	url, err := s.FindByShortURL(c, shortURL)
+	if err != nil {
+		return nil, false, err
+	}
+	url, expired := unexpired(url, time.Now())
+	return url, expired, nil
+}
+
+func (s *SQLPersistentStore) FindUnexpiredByURLHash(c context.Context, ownerID, urlHash string) (*ShortURLWithExpireTime, error) {
+	row := s.db.QueryRowContext(c, s.dialect.Rebind("SELECT "+SHORT_URL_COLUMNS+" FROM short_urls WHERE owner_id = ? AND url_hash = ? AND expire_at > ? ORDER BY expire_at DESC LIMIT 1"),
+		ownerID, urlHash, time.Now().UTC())
+	return scanShortURL(row)
+}
+
+func (s *SQLPersistentStore) Update(c context.Context, shortURL string, update *ShortURLUpdate) (*ShortURLWithExpireTime, error) {
+	var sets []string
+	var args []interface{}
+	if update.OriginalURL != nil {
+		sets = append(sets, "original_url = ?", "url_hash = ?")
+		args = append(args, *update.OriginalURL, URLHash(*update.OriginalURL))
+	}
+	if update.ExpireAt != nil {
+		sets = append(sets, "expire_at = ?")
+		args = append(args, update.ExpireAt.UTC())
+	}
+	if len(sets) == 0 {
+		return s.FindByShortURL(c, shortURL)
+	}
+	args = append(args, shortURL)
+
+	query := "UPDATE short_urls SET " + strings.Join(sets, ", ") + " WHERE short_url = ? RETURNING " + SHORT_URL_COLUMNS
+	row := s.db.QueryRowContext(c, s.dialect.Rebind(query), args...)
+	return scanShortURL(row)
+}
+
+func (s *SQLPersistentStore) Delete(c context.Context, shortURL string) (bool, error) {
+	result, err := s.db.ExecContext(c, s.dialect.Rebind("DELETE FROM short_urls WHERE short_url = ?"), shortURL)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	return deleted > 0, err
+}
+
+// ForEachShortURL reads the short url ids in pages ordered by the key and calls fn after each page is
+// closed, so fn may use the store even when the pool has a single connection, as with SQLite.
+func (s *SQLPersistentStore) ForEachShortURL(c context.Context, fn func(shortURL string) error) error {
+	after := ""
+	for {
+		shortURLs, err := s.findShortURLsAfter(c, after, SQL_FOR_EACH_BATCH_SIZE)
+		if err != nil {
+			return err
+		}
+		for _, shortURL := range shortURLs {
+			err = fn(shortURL)
+			if err != nil {
+				return err
+			}
+		}
+		if len(shortURLs) < SQL_FOR_EACH_BATCH_SIZE {
+			return nil
+		}
+		after = shortURLs[len(shortURLs)-1]
+	}
+}
+
+func (s *SQLPersistentStore) findShortURLsAfter(c context.Context, after string, limit int) ([]string, error) {
+	rows, err := s.db.QueryContext(c, s.dialect.Rebind("SELECT short_url FROM short_urls WHERE short_url > ? ORDER BY short_url LIMIT ?"), after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shortURLs := make([]string, 0, limit)
+	for rows.Next() {
+		var shortURL string
+		err = rows.Scan(&shortURL)
+		if err != nil {
+			return nil, err
+		}
+		shortURLs = append(shortURLs, shortURL)
+	}
+	return shortURLs, rows.Err()
+}
+
+func scanShortURL(row *sql.Row) (*ShortURLWithExpireTime, error) {
+	shortUrl := &ShortURLWithExpireTime{ShortUrl: &ShortURL{}}
+	err := row.Scan(&shortUrl.ShortUrl.ShortURL, &shortUrl.ShortUrl.OriginalURL, &shortUrl.ExpireAt, &shortUrl.OwnerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	shortUrl.ExpireAt = shortUrl.ExpireAt.UTC()
+	return shortUrl, nil
+}
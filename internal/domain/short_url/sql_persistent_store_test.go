@@ -0,0 +1,223 @@
+package shorturl_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/database"
+	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
+)
+
+// createSQLStores returns a store for SQLite, and for Postgres if TEST_POSTGRES_URI is set.
+func createSQLStores(t *testing.T) map[database.Dialect]*shorturl.SQLPersistentStore {
+	stores := make(map[database.Dialect]*shorturl.SQLPersistentStore)
+	stores[database.SQLITE] = shorturl.NewSQLPersistentStore(openSQL(t, database.SQLITE, filepath.Join(t.TempDir(), "test.db")), database.SQLITE)
+
+	if uri := os.Getenv("TEST_POSTGRES_URI"); uri != "" {
+		db := openSQL(t, database.POSTGRES, uri)
+		db.Exec("DELETE FROM short_urls")
+		stores[database.POSTGRES] = shorturl.NewSQLPersistentStore(db, database.POSTGRES)
+	}
+	return stores
+}
+
+func openSQL(t *testing.T, dialect database.Dialect, dsn string) *sql.DB {
+	db, err := database.Open(context.Background(), dialect, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSQLPersistentStoreSaveAndFind(t *testing.T) {
+	for dialect, store := range createSQLStores(t) {
+		c := context.Background()
+		expireAt := time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond)
+		err := store.Save(c, newMemoryShortURL("short", expireAt))
+		if err != nil {
+			t.Fatalf("%s: %v", dialect, err)
+		}
+
+		url, expired, err := store.FindUnexpiredByShortURL(c, "short")
+		if err != nil || url == nil || expired {
+			t.Fatalf("%s: expected short url, got %v %v", dialect, url, err)
+		}
+		if url.ShortUrl.OriginalURL != "https://example.com/long" || !url.ExpireAt.Equal(expireAt) || url.OwnerID != "owner" {
+			t.Errorf("%s: unexpected short url %+v %+v", dialect, url, url.ShortUrl)
+		}
+
+		url, err = store.FindByShortURL(c, "missing")
+		if err != nil || url != nil {
+			t.Errorf("%s: expected nil, got %v %v", dialect, url, err)
+		}
+	}
+}
+
+func TestSQLPersistentStoreSaveReturnDuplicateError(t *testing.T) {
+	for dialect, store := range createSQLStores(t) {
+		c := context.Background()
+		store.Save(c, newMemoryShortURL("short", time.Now().Add(time.Hour)))
+
+		err := store.Save(c, newMemoryShortURL("short", time.Now().Add(time.Hour)))
+		var duplicateErr *shorturl.DuplicateShortURLError
+		if !errors.As(err, &duplicateErr) {
+			t.Errorf("%s: expected DuplicateShortURLError, got %v", dialect, err)
+		}
+	}
+}
+
+func TestSQLPersistentStoreSaveManyReturnErrorPerItem(t *testing.T) {
+	for dialect, store := range createSQLStores(t) {
+		c := context.Background()
+		expireAt := time.Now().Add(time.Hour)
+		store.Save(c, newMemoryShortURL("taken", expireAt))
+
+		errs, err := store.SaveMany(c, []*shorturl.ShortURLWithExpireTime{
+			newMemoryShortURL("first", expireAt),
+			newMemoryShortURL("taken", expireAt),
+			newMemoryShortURL("first", expireAt),
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", dialect, err)
+		}
+
+		var duplicateErr *shorturl.DuplicateShortURLError
+		if errs[0] != nil || !errors.As(errs[1], &duplicateErr) || !errors.As(errs[2], &duplicateErr) {
+			t.Errorf("%s: unexpected errors %v", dialect, errs)
+		}
+
+		url, _ := store.FindByShortURL(c, "first")
+		if url == nil {
+			t.Errorf("%s: saved short url not found", dialect)
+		}
+	}
+}
+
+func TestSQLPersistentStoreFindUnexpiredByShortURLIgnoreExpired(t *testing.T) {
+	for dialect, store := range createSQLStores(t) {
+		c := context.Background()
+		store.Save(c, newMemoryShortURL("expired", time.Now().Add(-time.Second)))
+
+		url, expired, err := store.FindUnexpiredByShortURL(c, "expired")
+		if err != nil || url != nil || !expired {
+			t.Errorf("%s: expected nil and expired, got %v %v %v", dialect, url, expired, err)
+		}
+
+		_, expired, _ = store.FindUnexpiredByShortURL(c, "missing")
+		if expired {
+			t.Errorf("%s: expected missing short url not to be expired", dialect)
+		}
+
+		url, err = store.FindByShortURL(c, "expired")
+		if err != nil || url == nil {
+			t.Errorf("%s: expected expired short url, got %v %v", dialect, url, err)
+		}
+	}
+}
+
+func TestSQLPersistentStoreFindUnexpiredByURLHash(t *testing.T) {
+	for dialect, store := range createSQLStores(t) {
+		c := context.Background()
+		expireAt := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Millisecond)
+		other := newMemoryShortURL("other", time.Now().Add(3*time.Hour))
+		other.OwnerID = "other"
+		store.SaveMany(c, []*shorturl.ShortURLWithExpireTime{
+			newMemoryShortURL("expired", time.Now().Add(-time.Second)),
+			newMemoryShortURL("short", time.Now().Add(time.Hour)),
+			newMemoryShortURL("long", expireAt),
+			other,
+		})
+
+		url, err := store.FindUnexpiredByURLHash(c, "owner", shorturl.URLHash("https://EXAMPLE.com/long"))
+		if err != nil || url == nil || url.ShortUrl.ShortURL != "long" || !url.ExpireAt.Equal(expireAt) {
+			t.Errorf("%s: expected the short url expiring last, got %v %v", dialect, url, err)
+		}
+
+		originalURL := "https://example.com/new"
+		store.Update(c, "long", &shorturl.ShortURLUpdate{OriginalURL: &originalURL})
+		url, err = store.FindUnexpiredByURLHash(c, "owner", shorturl.URLHash(originalURL))
+		if err != nil || url == nil || url.ShortUrl.ShortURL != "long" {
+			t.Errorf("%s: expected the hash to be updated, got %v %v", dialect, url, err)
+		}
+
+		url, err = store.FindUnexpiredByURLHash(c, "owner", shorturl.URLHash("https://example.com/missing"))
+		if err != nil || url != nil {
+			t.Errorf("%s: expected nil, got %v %v", dialect, url, err)
+		}
+	}
+}
+
+func TestSQLPersistentStoreUpdate(t *testing.T) {
+	for dialect, store := range createSQLStores(t) {
+		c := context.Background()
+		store.Save(c, newMemoryShortURL("short", time.Now().Add(time.Hour)))
+
+		originalURL := "https://example.com/new"
+		expireAt := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Millisecond)
+		url, err := store.Update(c, "short", &shorturl.ShortURLUpdate{OriginalURL: &originalURL, ExpireAt: &expireAt})
+		if err != nil || url == nil {
+			t.Fatalf("%s: update failed, got %v %v", dialect, url, err)
+		}
+		if url.ShortUrl.OriginalURL != originalURL || !url.ExpireAt.Equal(expireAt) {
+			t.Errorf("%s: unexpected short url %+v %+v", dialect, url, url.ShortUrl)
+		}
+
+		url, err = store.Update(c, "missing", &shorturl.ShortURLUpdate{OriginalURL: &originalURL})
+		if err != nil || url != nil {
+			t.Errorf("%s: expected nil for missing short url, got %v %v", dialect, url, err)
+		}
+	}
+}
+
+func TestSQLPersistentStoreDelete(t *testing.T) {
+	for dialect, store := range createSQLStores(t) {
+		c := context.Background()
+		store.Save(c, newMemoryShortURL("short", time.Now().Add(time.Hour)))
+
+		deleted, err := store.Delete(c, "short")
+		if err != nil || !deleted {
+			t.Errorf("%s: expected deleted, got %v %v", dialect, deleted, err)
+		}
+
+		deleted, err = store.Delete(c, "short")
+		if err != nil || deleted {
+			t.Errorf("%s: expected not deleted, got %v %v", dialect, deleted, err)
+		}
+	}
+}
+
+func TestSQLPersistentStoreForEachShortURLAllowStoreUseInCallback(t *testing.T) {
+	for dialect, store := range createSQLStores(t) {
+		c := context.Background()
+		shortUrls := make([]*shorturl.ShortURLWithExpireTime, shorturl.SQL_FOR_EACH_BATCH_SIZE+1)
+		for i := range shortUrls {
+			shortUrls[i] = newMemoryShortURL(fmt.Sprintf("short%05d", i), time.Now().Add(time.Hour))
+		}
+		if _, err := store.SaveMany(c, shortUrls); err != nil {
+			t.Fatalf("%s: %v", dialect, err)
+		}
+
+		seen := make(map[string]bool)
+		err := store.ForEachShortURL(c, func(shortURL string) error {
+			// SQLite has a single connection, which must not be held by the iteration
+			ctx, cancel := context.WithTimeout(c, time.Second)
+			defer cancel()
+			_, err := store.FindByShortURL(ctx, shortURL)
+			seen[shortURL] = true
+			return err
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", dialect, err)
+		}
+		if len(seen) != len(shortUrls) {
+			t.Errorf("%s: expected %d short urls, got %d", dialect, len(shortUrls), len(seen))
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package shorturl
+
+import (
+	"context"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingCacheStore starts a client span for every operation of the wrapped store.
+type TracingCacheStore struct {
+	cacheStore CacheStore
+	name       string
+	tracer     trace.Tracer
+}
+
+// NewTracingCacheStore labels the spans with name, e.g. "redis_cache".
+func NewTracingCacheStore(cs CacheStore, name string, tp trace.TracerProvider) *TracingCacheStore {
+	return &TracingCacheStore{cs, name, tp.Tracer(tracing.TRACER_NAME)}
+}
+
+func (s *TracingCacheStore) start(c context.Context, operation, key string) (context.Context, trace.Span) {
+	return s.tracer.Start(c, "CacheStore."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(tracing.STORE_KEY.String(s.name), tracing.SHORT_URL_KEY.String(key)),
+	)
+}
+
+func (s *TracingCacheStore) Get(c context.Context, key string) (*string, error) {
+	c, span := s.start(c, "Get", key)
+	value, err := s.cacheStore.Get(c, key)
+	span.SetAttributes(attribute.Bool("hit", value != nil))
+	tracing.End(span, err)
+	return value, err
+}
+
+func (s *TracingCacheStore) Set(c context.Context, key, value string, expireSecond uint) error {
+	c, span := s.start(c, "Set", key)
+	err := s.cacheStore.Set(c, key, value, expireSecond)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *TracingCacheStore) Delete(c context.Context, key string) error {
+	c, span := s.start(c, "Delete", key)
+	err := s.cacheStore.Delete(c, key)
+	tracing.End(span, err)
+	return err
+}
+
+// TracingTTLCacheStore is the TracingCacheStore of a TTLCacheStore, so it can be put behind a TwoTierCacheStore.
+type TracingTTLCacheStore struct {
+	*TracingCacheStore
+	ttlCacheStore TTLCacheStore
+}
+
+func NewTracingTTLCacheStore(cs TTLCacheStore, name string, tp trace.TracerProvider) *TracingTTLCacheStore {
+	return &TracingTTLCacheStore{NewTracingCacheStore(cs, name, tp), cs}
+}
+
+func (s *TracingTTLCacheStore) GetWithTTL(c context.Context, key string) (*string, time.Duration, error) {
+	c, span := s.start(c, "GetWithTTL", key)
+	value, ttl, err := s.ttlCacheStore.GetWithTTL(c, key)
+	span.SetAttributes(attribute.Bool("hit", value != nil))
+	tracing.End(span, err)
+	return value, ttl, err
+}
@@ -0,0 +1,82 @@
+package shorturl
+
+import (
+	"context"
+
+	"github.com/WeiAnAn/url-shortener/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingPersistentStore starts a client span for every operation of the wrapped store.
+type TracingPersistentStore struct {
+	persistentStore PersistentStore
+	name            string
+	tracer          trace.Tracer
+}
+
+// NewTracingPersistentStore labels the spans with name, e.g. "mongo".
+func NewTracingPersistentStore(ps PersistentStore, name string, tp trace.TracerProvider) *TracingPersistentStore {
+	return &TracingPersistentStore{ps, name, tp.Tracer(tracing.TRACER_NAME)}
+}
+
+func (s *TracingPersistentStore) start(c context.Context, operation string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	attributes = append(attributes, tracing.STORE_KEY.String(s.name))
+	return s.tracer.Start(c, "PersistentStore."+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+}
+
+func (s *TracingPersistentStore) Save(c context.Context, shortUrl *ShortURLWithExpireTime) error {
+	c, span := s.start(c, "Save", tracing.SHORT_URL_KEY.String(shortUrl.ShortUrl.ShortURL))
+	err := s.persistentStore.Save(c, shortUrl)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *TracingPersistentStore) SaveMany(c context.Context, shortUrls []*ShortURLWithExpireTime) ([]error, error) {
+	c, span := s.start(c, "SaveMany", attribute.Int("batch_size", len(shortUrls)))
+	errs, err := s.persistentStore.SaveMany(c, shortUrls)
+	tracing.End(span, err)
+	return errs, err
+}
+
+func (s *TracingPersistentStore) FindUnexpiredByShortURL(c context.Context, shortURL string) (*ShortURLWithExpireTime, bool, error) {
+	c, span := s.start(c, "FindUnexpiredByShortURL", tracing.SHORT_URL_KEY.String(shortURL))
+	url, expired, err := s.persistentStore.FindUnexpiredByShortURL(c, shortURL)
+	tracing.End(span, err)
+	return url, expired, err
+}
+
+func (s *TracingPersistentStore) FindByShortURL(c context.Context, shortURL string) (*ShortURLWithExpireTime, error) {
+	c, span := s.start(c, "FindByShortURL", tracing.SHORT_URL_KEY.String(shortURL))
+	url, err := s.persistentStore.FindByShortURL(c, shortURL)
+	tracing.End(span, err)
+	return url, err
+}
+
+func (s *TracingPersistentStore) FindUnexpiredByURLHash(c context.Context, ownerID, urlHash string) (*ShortURLWithExpireTime, error) {
+	c, span := s.start(c, "FindUnexpiredByURLHash")
+	url, err := s.persistentStore.FindUnexpiredByURLHash(c, ownerID, urlHash)
+	tracing.End(span, err)
+	return url, err
+}
+
+func (s *TracingPersistentStore) Update(c context.Context, shortURL string, update *ShortURLUpdate) (*ShortURLWithExpireTime, error) {
+	c, span := s.start(c, "Update", tracing.SHORT_URL_KEY.String(shortURL))
+	url, err := s.persistentStore.Update(c, shortURL, update)
+	tracing.End(span, err)
+	return url, err
+}
+
+func (s *TracingPersistentStore) Delete(c context.Context, shortURL string) (bool, error) {
+	c, span := s.start(c, "Delete", tracing.SHORT_URL_KEY.String(shortURL))
+	deleted, err := s.persistentStore.Delete(c, shortURL)
+	tracing.End(span, err)
+	return deleted, err
+}
+
+func (s *TracingPersistentStore) ForEachShortURL(c context.Context, fn func(shortURL string) error) error {
+	c, span := s.start(c, "ForEachShortURL")
+	err := s.persistentStore.ForEachShortURL(c, fn)
+	tracing.End(span, err)
+	return err
+}
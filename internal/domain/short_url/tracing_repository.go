@@ -0,0 +1,70 @@
+package shorturl
+
+import (
+	"context"
+
+	"github.com/WeiAnAn/url-shortener/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingRepository starts a span for every method of the wrapped repository.
+type TracingRepository struct {
+	repository ShortURLRepository
+	tracer     trace.Tracer
+}
+
+func NewTracingRepository(r ShortURLRepository, tp trace.TracerProvider) *TracingRepository {
+	return &TracingRepository{r, tp.Tracer(tracing.TRACER_NAME)}
+}
+
+func (r *TracingRepository) Save(c context.Context, shortURL *ShortURLWithExpireTime) error {
+	c, span := r.tracer.Start(c, "ShortURLRepository.Save", trace.WithAttributes(tracing.SHORT_URL_KEY.String(shortURL.ShortUrl.ShortURL)))
+	err := r.repository.Save(c, shortURL)
+	tracing.End(span, err)
+	return err
+}
+
+func (r *TracingRepository) SaveMany(c context.Context, shortURLs []*ShortURLWithExpireTime) ([]error, error) {
+	c, span := r.tracer.Start(c, "ShortURLRepository.SaveMany", trace.WithAttributes(attribute.Int("batch_size", len(shortURLs))))
+	errs, err := r.repository.SaveMany(c, shortURLs)
+	tracing.End(span, err)
+	return errs, err
+}
+
+func (r *TracingRepository) FindByShortURL(c context.Context, shortURL string) (*ShortURL, error) {
+	c, span := r.tracer.Start(c, "ShortURLRepository.FindByShortURL", trace.WithAttributes(tracing.SHORT_URL_KEY.String(shortURL)))
+	url, err := r.repository.FindByShortURL(c, shortURL)
+	span.SetAttributes(attribute.Bool("found", url != nil))
+	tracing.End(span, err)
+	return url, err
+}
+
+func (r *TracingRepository) GetByShortURL(c context.Context, shortURL string) (*ShortURLWithExpireTime, error) {
+	c, span := r.tracer.Start(c, "ShortURLRepository.GetByShortURL", trace.WithAttributes(tracing.SHORT_URL_KEY.String(shortURL)))
+	url, err := r.repository.GetByShortURL(c, shortURL)
+	tracing.End(span, err)
+	return url, err
+}
+
+func (r *TracingRepository) FindUnexpiredByOriginalURL(c context.Context, ownerID, originalURL string) (*ShortURLWithExpireTime, error) {
+	c, span := r.tracer.Start(c, "ShortURLRepository.FindUnexpiredByOriginalURL")
+	url, err := r.repository.FindUnexpiredByOriginalURL(c, ownerID, originalURL)
+	span.SetAttributes(attribute.Bool("found", url != nil))
+	tracing.End(span, err)
+	return url, err
+}
+
+func (r *TracingRepository) Update(c context.Context, shortURL string, update *ShortURLUpdate) (*ShortURLWithExpireTime, error) {
+	c, span := r.tracer.Start(c, "ShortURLRepository.Update", trace.WithAttributes(tracing.SHORT_URL_KEY.String(shortURL)))
+	url, err := r.repository.Update(c, shortURL, update)
+	tracing.End(span, err)
+	return url, err
+}
+
+func (r *TracingRepository) Delete(c context.Context, shortURL string) (bool, error) {
+	c, span := r.tracer.Start(c, "ShortURLRepository.Delete", trace.WithAttributes(tracing.SHORT_URL_KEY.String(shortURL)))
+	deleted, err := r.repository.Delete(c, shortURL)
+	tracing.End(span, err)
+	return deleted, err
+}
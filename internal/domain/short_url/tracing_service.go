@@ -0,0 +1,72 @@
+package shorturl
+
+import (
+	"context"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingService starts a span for every method of the wrapped service.
+type TracingService struct {
+	service Service
+	tracer  trace.Tracer
+}
+
+func NewTracingService(s Service, tp trace.TracerProvider) *TracingService {
+	return &TracingService{s, tp.Tracer(tracing.TRACER_NAME)}
+}
+
+func (s *TracingService) CreateShortURL(c context.Context, ownerID, originalURL string, expireAt time.Time) (*ShortURLWithExpireTime, bool, error) {
+	c, span := s.tracer.Start(c, "Service.CreateShortURL")
+	shortURL, reused, err := s.service.CreateShortURL(c, ownerID, originalURL, expireAt)
+	if err == nil {
+		span.SetAttributes(tracing.SHORT_URL_KEY.String(shortURL.ShortUrl.ShortURL), attribute.Bool("reused", reused))
+	}
+	tracing.End(span, err)
+	return shortURL, reused, err
+}
+
+func (s *TracingService) CreateShortURLWithAlias(c context.Context, ownerID, alias, originalURL string, expireAt time.Time) (*ShortURLWithExpireTime, error) {
+	c, span := s.tracer.Start(c, "Service.CreateShortURLWithAlias", trace.WithAttributes(tracing.SHORT_URL_KEY.String(alias)))
+	shortURL, err := s.service.CreateShortURLWithAlias(c, ownerID, alias, originalURL, expireAt)
+	tracing.End(span, err)
+	return shortURL, err
+}
+
+func (s *TracingService) CreateShortURLs(c context.Context, ownerID string, inputs []*CreateShortURLInput) ([]*CreateShortURLResult, error) {
+	c, span := s.tracer.Start(c, "Service.CreateShortURLs", trace.WithAttributes(attribute.Int("batch_size", len(inputs))))
+	results, err := s.service.CreateShortURLs(c, ownerID, inputs)
+	tracing.End(span, err)
+	return results, err
+}
+
+func (s *TracingService) GetOriginalURL(c context.Context, short string) (*ShortURL, error) {
+	c, span := s.tracer.Start(c, "Service.GetOriginalURL", trace.WithAttributes(tracing.SHORT_URL_KEY.String(short)))
+	shortURL, err := s.service.GetOriginalURL(c, short)
+	tracing.End(span, err)
+	return shortURL, err
+}
+
+func (s *TracingService) GetShortURL(c context.Context, ownerID, short string) (*ShortURLWithExpireTime, error) {
+	c, span := s.tracer.Start(c, "Service.GetShortURL", trace.WithAttributes(tracing.SHORT_URL_KEY.String(short)))
+	shortURL, err := s.service.GetShortURL(c, ownerID, short)
+	tracing.End(span, err)
+	return shortURL, err
+}
+
+func (s *TracingService) UpdateShortURL(c context.Context, ownerID, short string, update *ShortURLUpdate) (*ShortURLWithExpireTime, error) {
+	c, span := s.tracer.Start(c, "Service.UpdateShortURL", trace.WithAttributes(tracing.SHORT_URL_KEY.String(short)))
+	shortURL, err := s.service.UpdateShortURL(c, ownerID, short, update)
+	tracing.End(span, err)
+	return shortURL, err
+}
+
+func (s *TracingService) DeleteShortURL(c context.Context, ownerID, short string) error {
+	c, span := s.tracer.Start(c, "Service.DeleteShortURL", trace.WithAttributes(tracing.SHORT_URL_KEY.String(short)))
+	err := s.service.DeleteShortURL(c, ownerID, short)
+	tracing.End(span, err)
+	return err
+}
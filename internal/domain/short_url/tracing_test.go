@@ -0,0 +1,75 @@
+package shorturl_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
+	mock_shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url/mocks"
+	"github.com/WeiAnAn/url-shortener/internal/tracing"
+	"github.com/WeiAnAn/url-shortener/internal/utils"
+	"github.com/golang/mock/gomock"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingPersistentStoreRecordError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	ps := mock_shorturl.NewMockPersistentStore(mockCtrl)
+	store := shorturl.NewTracingPersistentStore(ps, "mongo", tp)
+
+	mockErr := errors.New("connection refused")
+	ps.EXPECT().FindByShortURL(gomock.Any(), "short").Return(nil, mockErr)
+
+	_, err := store.FindByShortURL(context.Background(), "short")
+	if err != mockErr {
+		t.Errorf("expected error to be returned, got %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "PersistentStore.FindByShortURL" || span.SpanKind != trace.SpanKindClient || span.Status.Code != codes.Error {
+		t.Errorf("unexpected span %s %v %v", span.Name, span.SpanKind, span.Status)
+	}
+	attributes := map[string]string{}
+	for _, attribute := range span.Attributes {
+		attributes[string(attribute.Key)] = attribute.Value.Emit()
+	}
+	if attributes[string(tracing.STORE_KEY)] != "mongo" || attributes[string(tracing.SHORT_URL_KEY)] != "short" {
+		t.Errorf("unexpected attributes %v", attributes)
+	}
+}
+
+func TestTracingCacheStoreBehindTwoTierCacheStore(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	remote := mock_shorturl.NewMockTTLCacheStore(mockCtrl)
+	twoTier := shorturl.NewTwoTierCacheStore(shorturl.NewTracingTTLCacheStore(remote, "redis_cache", tp), 10, time.Second, nil, &utils.RealTime{})
+	store := shorturl.NewTracingCacheStore(twoTier, "two_tier_cache", tp)
+
+	remote.EXPECT().GetWithTTL(gomock.Any(), "short").Return(nil, time.Duration(0), nil)
+
+	store.Get(context.Background(), "short")
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 || spans[0].Name != "CacheStore.GetWithTTL" || spans[1].Name != "CacheStore.Get" {
+		t.Fatalf("unexpected spans %v", spans)
+	}
+	if spans[0].Parent.SpanID() != spans[1].SpanContext.SpanID() {
+		t.Error("remote span is not under the two tier span")
+	}
+}
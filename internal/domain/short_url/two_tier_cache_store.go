@@ -0,0 +1,153 @@
+package shorturl
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/lru"
+	"github.com/WeiAnAn/url-shortener/internal/utils"
+)
+
+// INVALIDATION_RETRY_INTERVAL is the wait before subscribing again after the subscription is lost.
+const INVALIDATION_RETRY_INTERVAL = time.Second
+
+// TTLCacheStore returns the remaining time to live with the value, so copies of the value do not outlive it.
+type TTLCacheStore interface {
+	CacheStore
+	// GetWithTTL returns a negative ttl if the value never expires.
+	GetWithTTL(c context.Context, key string) (*string, time.Duration, error)
+}
+
+// CacheInvalidator broadcasts deleted cache keys to all replicas.
+type CacheInvalidator interface {
+	Publish(c context.Context, key string) error
+	// Subscribe blocks and calls fn with every published key until the context is canceled
+	// or the subscription is lost.
+	Subscribe(c context.Context, fn func(key string)) error
+}
+
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// TwoTierCacheStore keeps recently used entries in process in front of a shared cache store.
+// A local entry never lives longer than the ttl, nor than the entry in the shared cache store.
+// Deleted keys are broadcast by the invalidator, so other replicas drop their local entries. Set keys are
+// not, since Set fills every cache miss; delete a key before setting it if other replicas may hold it.
+type TwoTierCacheStore struct {
+	local       *lru.Cache
+	remote      TTLCacheStore
+	ttl         time.Duration
+	invalidator CacheInvalidator
+	time        utils.TimeUtil
+
+	hits   uint64
+	misses uint64
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewTwoTierCacheStore keeps at most size entries in process, the invalidator is optional.
+func NewTwoTierCacheStore(remote TTLCacheStore, size int, ttl time.Duration, invalidator CacheInvalidator, t utils.TimeUtil) *TwoTierCacheStore {
+	return &TwoTierCacheStore{
+		local:       lru.New(size),
+		remote:      remote,
+		ttl:         ttl,
+		invalidator: invalidator,
+		time:        t,
+	}
+}
+
+func (s *TwoTierCacheStore) Get(c context.Context, key string) (*string, error) {
+	if value, ok := s.local.Get(key, s.time.Now()); ok {
+		atomic.AddUint64(&s.hits, 1)
+		return &value, nil
+	}
+	atomic.AddUint64(&s.misses, 1)
+
+	value, ttl, err := s.remote.GetWithTTL(c, key)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	s.setLocal(key, *value, ttl)
+	return value, nil
+}
+
+func (s *TwoTierCacheStore) Set(c context.Context, key, value string, expireSecond uint) error {
+	err := s.remote.Set(c, key, value, expireSecond)
+	if err != nil {
+		return err
+	}
+	s.setLocal(key, value, time.Duration(expireSecond)*time.Second)
+	return nil
+}
+
+func (s *TwoTierCacheStore) Delete(c context.Context, key string) error {
+	s.local.Delete(key)
+	err := s.remote.Delete(c, key)
+	if err != nil {
+		return err
+	}
+
+	if s.invalidator != nil {
+		return s.invalidator.Publish(c, key)
+	}
+	return nil
+}
+
+// Stats returns the hits and misses of the local entries.
+func (s *TwoTierCacheStore) Stats() CacheStats {
+	return CacheStats{atomic.LoadUint64(&s.hits), atomic.LoadUint64(&s.misses)}
+}
+
+// Start subscribes to the invalidator in the background, it does nothing without an invalidator.
+// Local entries are cleared whenever the subscription is (re)established, since deletes may be missed in between.
+func (s *TwoTierCacheStore) Start() {
+	if s.invalidator == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		for {
+			s.local.Clear()
+			err := s.invalidator.Subscribe(ctx, s.local.Delete)
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Warn("cache invalidation subscription lost", slog.Any("error", err))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(INVALIDATION_RETRY_INTERVAL):
+			}
+		}
+	}()
+}
+
+// Close stops the subscription started by Start.
+func (s *TwoTierCacheStore) Close() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+func (s *TwoTierCacheStore) setLocal(key, value string, ttl time.Duration) {
+	if ttl == 0 {
+		return
+	}
+	if ttl < 0 || ttl > s.ttl {
+		ttl = s.ttl
+	}
+	s.local.Set(key, value, s.time.Now().Add(ttl))
+}
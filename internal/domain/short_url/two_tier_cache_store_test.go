@@ -0,0 +1,191 @@
+package shorturl_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url"
+	mock_shorturl "github.com/WeiAnAn/url-shortener/internal/domain/short_url/mocks"
+	mock_utils "github.com/WeiAnAn/url-shortener/internal/utils/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+// createTwoTierCacheStore returns the store with a clock which only moves by the returned function.
+func createTwoTierCacheStore(ctrl *gomock.Controller, invalidator shorturl.CacheInvalidator) (*shorturl.TwoTierCacheStore, *mock_shorturl.MockTTLCacheStore, func(time.Duration)) {
+	remote := mock_shorturl.NewMockTTLCacheStore(ctrl)
+	now := time.Now()
+	tu := mock_utils.NewMockTimeUtil(ctrl)
+	tu.EXPECT().Now().DoAndReturn(func() time.Time { return now }).AnyTimes()
+
+	store := shorturl.NewTwoTierCacheStore(remote, 100, 10*time.Second, invalidator, tu)
+	return store, remote, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestTwoTierCacheStoreGetFromLocalAfterRemote(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	store, remote, _ := createTwoTierCacheStore(mockCtrl, nil)
+	originalURL := "https://example.com/long"
+	c := context.Background()
+	remote.EXPECT().GetWithTTL(c, "short").Times(1).Return(&originalURL, time.Minute, nil)
+
+	for i := 0; i < 2; i++ {
+		value, err := store.Get(c, "short")
+		if err != nil || value == nil || *value != originalURL {
+			t.Fatalf("unexpected value %v %v", value, err)
+		}
+	}
+
+	stats := store.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("expected 1 hit and 1 miss, got %+v", stats)
+	}
+}
+
+func TestTwoTierCacheStoreLocalEntryNotOutliveRemote(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	store, remote, advance := createTwoTierCacheStore(mockCtrl, nil)
+	originalURL := "https://example.com/long"
+	c := context.Background()
+	remote.EXPECT().GetWithTTL(c, "short").Times(2).Return(&originalURL, 2*time.Second, nil)
+
+	store.Get(c, "short")
+	advance(2 * time.Second)
+	store.Get(c, "short")
+}
+
+func TestTwoTierCacheStoreLocalEntryExpireAfterTTL(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	store, remote, advance := createTwoTierCacheStore(mockCtrl, nil)
+	originalURL := "https://example.com/long"
+	c := context.Background()
+	remote.EXPECT().GetWithTTL(c, "short").Times(2).Return(&originalURL, -1*time.Millisecond, nil)
+
+	store.Get(c, "short")
+	advance(9 * time.Second)
+	store.Get(c, "short")
+	advance(time.Second)
+	store.Get(c, "short")
+}
+
+func TestTwoTierCacheStoreNotCacheRemoteMiss(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	store, remote, _ := createTwoTierCacheStore(mockCtrl, nil)
+	c := context.Background()
+	remote.EXPECT().GetWithTTL(c, "short").Times(2).Return(nil, time.Duration(0), nil)
+
+	for i := 0; i < 2; i++ {
+		value, err := store.Get(c, "short")
+		if err != nil || value != nil {
+			t.Fatalf("unexpected value %v %v", value, err)
+		}
+	}
+}
+
+func TestTwoTierCacheStoreSetWriteBothTiers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	store, remote, advance := createTwoTierCacheStore(mockCtrl, nil)
+	originalURL := "https://example.com/long"
+	c := context.Background()
+	remote.EXPECT().Set(c, "short", originalURL, uint(5)).Return(nil)
+
+	store.Set(c, "short", originalURL, 5)
+	value, _ := store.Get(c, "short")
+	if value == nil || *value != originalURL {
+		t.Fatalf("unexpected value %v", value)
+	}
+
+	remote.EXPECT().GetWithTTL(c, "short").Return(nil, time.Duration(0), nil)
+	advance(5 * time.Second)
+	value, _ = store.Get(c, "short")
+	if value != nil {
+		t.Errorf("local entry outlives the expire second, got %v", *value)
+	}
+}
+
+func TestTwoTierCacheStoreDeletePublishInvalidation(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	invalidator := mock_shorturl.NewMockCacheInvalidator(mockCtrl)
+	store, remote, _ := createTwoTierCacheStore(mockCtrl, invalidator)
+	originalURL := "https://example.com/long"
+	c := context.Background()
+	remote.EXPECT().Set(c, "short", originalURL, uint(60)).Return(nil)
+	remote.EXPECT().Delete(c, "short").Return(nil)
+	invalidator.EXPECT().Publish(c, "short").Return(nil)
+	remote.EXPECT().GetWithTTL(c, "short").Return(nil, time.Duration(0), nil)
+
+	store.Set(c, "short", originalURL, 60)
+	store.Delete(c, "short")
+
+	value, _ := store.Get(c, "short")
+	if value != nil {
+		t.Errorf("deleted entry is returned, got %v", *value)
+	}
+}
+
+func TestTwoTierCacheStoreSetKeepLocalEntryWithoutPublishing(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// the invalidator fails the test if a miss fill is published
+	invalidator := mock_shorturl.NewMockCacheInvalidator(mockCtrl)
+	store, remote, _ := createTwoTierCacheStore(mockCtrl, invalidator)
+	originalURL := "https://example.com/long"
+	c := context.Background()
+	remote.EXPECT().Set(c, "short", originalURL, uint(60)).Return(nil)
+
+	err := store.Set(c, "short", originalURL, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value, _ := store.Get(c, "short")
+	if value == nil || *value != originalURL {
+		t.Errorf("expected the filled entry from the local tier, got %v", value)
+	}
+	if stats := store.Stats(); stats.Hits != 1 {
+		t.Errorf("expected a local hit, got %+v", stats)
+	}
+}
+
+func TestTwoTierCacheStoreDropLocalEntryOnInvalidation(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	invalidator := mock_shorturl.NewMockCacheInvalidator(mockCtrl)
+	store, remote, _ := createTwoTierCacheStore(mockCtrl, invalidator)
+	originalURL := "https://example.com/long"
+	c := context.Background()
+	remote.EXPECT().Set(c, "short", originalURL, uint(60)).Return(nil)
+
+	subscribed := make(chan func(string))
+	invalidator.EXPECT().Subscribe(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(string)) error {
+		subscribed <- fn
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	store.Start()
+	defer store.Close()
+	invalidate := <-subscribed
+
+	store.Set(c, "short", originalURL, 60)
+	invalidate("short")
+
+	remote.EXPECT().GetWithTTL(c, "short").Return(nil, time.Duration(0), nil)
+	value, _ := store.Get(c, "short")
+	if value != nil {
+		t.Errorf("invalidated entry is returned, got %v", *value)
+	}
+}
@@ -0,0 +1,44 @@
+package shorturl
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+	"github.com/gin-gonic/gin"
+)
+
+// warningPage is shown instead of redirecting to a blocked url. The url is shown as text only,
+// so visitors are not one click away from the blocked site.
+var warningPage = template.Must(template.New("warning").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Link blocked</title>
+</head>
+<body>
+<h1>This link has been blocked</h1>
+<p>The short link {{.ShortURL}} points to a destination which is flagged as unsafe, such as a phishing or malware site.</p>
+<p>Destination: <code>{{.URL}}</code></p>
+<p>Reason: {{.Reason}}</p>
+</body>
+</html>
+`))
+
+func renderWarningPage(ctx *gin.Context, short string, blockedErr *myerror.BlockedURLError) {
+	var buf bytes.Buffer
+	err := warningPage.Execute(&buf, map[string]string{
+		"ShortURL": short,
+		"URL":      blockedErr.URL,
+		"Reason":   blockedErr.Reason,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Data(http.StatusForbidden, "text/html; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,106 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+)
+
+const FEISTEL_ROUNDS = 4
+
+// Counter returns a new number on every call, shared by all replicas.
+type Counter interface {
+	Next(c context.Context) (uint64, error)
+}
+
+// CounterGenerator turns the numbers of a counter into short urls which are never repeated, so it does not
+// collide until the short urls of the length run out. The numbers are shuffled by a Feistel network keyed by
+// the secret, so consecutive short urls do not reveal how many were created or what the next one is.
+type CounterGenerator struct {
+	alphabet string
+	keys     [FEISTEL_ROUNDS]uint64
+	counter  Counter
+}
+
+func NewCounterGenerator(alphabet, secret string, counter Counter) (*CounterGenerator, error) {
+	if err := ValidateAlphabet(alphabet); err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		return nil, errors.New("counter generator requires a secret")
+	}
+
+	g := &CounterGenerator{alphabet: alphabet, counter: counter}
+	sum := sha256.Sum256([]byte(secret))
+	for i := range g.keys {
+		g.keys[i] = binary.BigEndian.Uint64(sum[i*8:])
+	}
+	return g, nil
+}
+
+// Generate returns a longer short url once the numbers no longer fit in the length.
+func (g *CounterGenerator) Generate(c context.Context, length int) (string, error) {
+	n, err := g.counter.Next(c)
+	if err != nil {
+		return "", err
+	}
+
+	size, full := domainSize(len(g.alphabet), length)
+	for !full && n >= size {
+		length++
+		size, full = domainSize(len(g.alphabet), length)
+	}
+	return encode(g.permute(n, size, full), g.alphabet, length), nil
+}
+
+// domainSize returns base^length, full is true if it does not fit in uint64.
+func domainSize(base, length int) (uint64, bool) {
+	size := uint64(1)
+	for i := 0; i < length; i++ {
+		if size > math.MaxUint64/uint64(base) {
+			return 0, true
+		}
+		size *= uint64(base)
+	}
+	return size, false
+}
+
+// permute maps n < size to another number < size, and different numbers to different numbers. The Feistel
+// network shuffles the smallest even number of bits holding size, and is applied again until the result is
+// less than size, which takes less than 4 rounds on average.
+func (g *CounterGenerator) permute(n, size uint64, full bool) uint64 {
+	width := 64
+	if !full {
+		width = bits.Len64(size - 1)
+		width += width % 2
+		if width == 0 {
+			width = 2
+		}
+	}
+	half := width / 2
+	mask := uint64(1)<<half - 1
+
+	for {
+		left, right := n>>half, n&mask
+		for _, key := range g.keys {
+			left, right = right, left^(mix(right^key)&mask)
+		}
+		n = left<<half | right
+		if full || n < size {
+			return n
+		}
+	}
+}
+
+// mix is the finalizer of splitmix64.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,106 @@
+package generator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/quick"
+
+	"github.com/WeiAnAn/url-shortener/internal/generator"
+)
+
+// fixedCounter returns its value, so a test can choose the number to generate from.
+type fixedCounter struct {
+	n   uint64
+	err error
+}
+
+func (f *fixedCounter) Next(c context.Context) (uint64, error) {
+	return f.n, f.err
+}
+
+func TestCounterGeneratorUsesEveryShortURLOfTheLength(t *testing.T) {
+	for _, tt := range []struct {
+		alphabet string
+		length   int
+	}{
+		{generator.BASE62_ALPHABET, 2},
+		{"ab", 5},
+		{"abc", 7},
+	} {
+		g, err := generator.NewCounterGenerator(tt.alphabet, "secret", generator.NewMemoryCounter())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		size := 1
+		for i := 0; i < tt.length; i++ {
+			size *= len(tt.alphabet)
+		}
+		// the counter starts from 1, so the last number is the first of the next length
+		seen := map[string]bool{}
+		for i := 1; i < size; i++ {
+			short, err := g.Generate(context.Background(), tt.length)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(short) != tt.length || seen[short] {
+				t.Fatalf("alphabet %q generated %q after %d short urls", tt.alphabet, short, len(seen))
+			}
+			seen[short] = true
+		}
+
+		short, _ := g.Generate(context.Background(), tt.length)
+		if len(short) != tt.length+1 {
+			t.Errorf("expect length to grow after the short urls run out, got %q", short)
+		}
+	}
+}
+
+func TestCounterGeneratorMapsDifferentNumbersToDifferentShortURLs(t *testing.T) {
+	counter := &fixedCounter{}
+	g, _ := generator.NewCounterGenerator(generator.BASE62_ALPHABET, "secret", counter)
+	generate := func(n uint64) string {
+		counter.n = n
+		short, err := g.Generate(context.Background(), 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return short
+	}
+
+	property := func(a, b uint64) bool {
+		return a == b || generate(a) != generate(b)
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCounterGeneratorDependsOnSecret(t *testing.T) {
+	a, _ := generator.NewCounterGenerator(generator.BASE62_ALPHABET, "secret", &fixedCounter{n: 42})
+	b, _ := generator.NewCounterGenerator(generator.BASE62_ALPHABET, "another secret", &fixedCounter{n: 42})
+
+	shortA, _ := a.Generate(context.Background(), 7)
+	shortB, _ := b.Generate(context.Background(), 7)
+	if shortA == shortB {
+		t.Errorf("expect secrets to shuffle differently, both got %s", shortA)
+	}
+}
+
+func TestCounterGeneratorReturnCounterError(t *testing.T) {
+	mockErr := errors.New("counter is down")
+	g, _ := generator.NewCounterGenerator(generator.BASE62_ALPHABET, "secret", &fixedCounter{err: mockErr})
+
+	_, err := g.Generate(context.Background(), 7)
+	if !errors.Is(err, mockErr) {
+		t.Errorf("expect error %v, got %v", mockErr, err)
+	}
+}
+
+func TestCounterGeneratorRequiresSecret(t *testing.T) {
+	_, err := generator.NewCounterGenerator(generator.BASE62_ALPHABET, "", generator.NewMemoryCounter())
+	if err == nil {
+		t.Error("expect error of empty secret")
+	}
+}
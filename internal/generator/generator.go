@@ -0,0 +1,149 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/WeiAnAn/url-shortener/internal/utils"
+)
+
+const (
+	BASE62_ALPHABET = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// BASE58_ALPHABET leaves out 0, O, I and l, which are easily confused when a short url is read aloud or typed.
+	BASE58_ALPHABET = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+)
+
+// URL_SAFE_CHARS are the chars allowed in alphabets, the same as the chars allowed in short urls.
+const URL_SAFE_CHARS = BASE62_ALPHABET + "-_"
+
+const (
+	BASE62    = "base62"
+	BASE58    = "base58"
+	SNOWFLAKE = "snowflake"
+	COUNTER   = "counter"
+)
+
+var ErrLengthTooShort = errors.New("short url length is too short for the generator")
+
+// Generator generates short urls of the length. It may return a used short url,
+// the caller is expected to retry on a collision.
+type Generator interface {
+	Generate(c context.Context, length int) (string, error)
+}
+
+// Options are shared by all generators, each generator reads the options it needs.
+type Options struct {
+	// Alphabet overrides the default alphabet of the generator if it is not empty.
+	Alphabet string
+	// Length is the configured short url length, generators producing longer ids reject it.
+	Length int
+	// NodeID tells apart the snowflake ids generated by replicas, it must be unique among them.
+	NodeID int64
+	// Secret keys the obfuscation of the counter generator.
+	Secret  string
+	Counter Counter
+	Time    utils.TimeUtil
+}
+
+// Factory creates a generator, it returns an error if the options are invalid for the generator.
+type Factory func(options Options) (Generator, error)
+
+var registry = map[string]Factory{
+	BASE62: func(options Options) (Generator, error) {
+		return NewRandomGenerator(alphabetOrDefault(options.Alphabet, BASE62_ALPHABET))
+	},
+	BASE58: func(options Options) (Generator, error) {
+		return NewRandomGenerator(alphabetOrDefault(options.Alphabet, BASE58_ALPHABET))
+	},
+	SNOWFLAKE: func(options Options) (Generator, error) {
+		t := options.Time
+		if t == nil {
+			t = &utils.RealTime{}
+		}
+		return NewSnowflakeGenerator(alphabetOrDefault(options.Alphabet, BASE62_ALPHABET), options.Length, options.NodeID, t)
+	},
+	COUNTER: func(options Options) (Generator, error) {
+		if options.Counter == nil {
+			return nil, errors.New("counter generator requires a counter")
+		}
+		return NewCounterGenerator(alphabetOrDefault(options.Alphabet, BASE62_ALPHABET), options.Secret, options.Counter)
+	},
+}
+
+// Register adds a generator selectable by the name, it replaces the generator registered with the same name
+// and a nil factory removes it. It is not safe to register generators concurrently with New.
+func Register(name string, factory Factory) {
+	if factory == nil {
+		delete(registry, name)
+		return
+	}
+	registry[name] = factory
+}
+
+// New creates the generator registered with the name.
+func New(name string, options Options) (Generator, error) {
+	factory, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown generator %q, expect one of %v", name, Names())
+	}
+	return factory(options)
+}
+
+// Names returns the registered generator names in order.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateAlphabet checks the alphabet has at least two distinct chars allowed in short urls.
+func ValidateAlphabet(alphabet string) error {
+	if len(alphabet) < 2 {
+		return errors.New("alphabet must have at least 2 chars")
+	}
+	for i := 0; i < len(alphabet); i++ {
+		if !strings.Contains(URL_SAFE_CHARS, alphabet[i:i+1]) {
+			return fmt.Errorf("alphabet char %q is not a letter, digit, - or _", alphabet[i])
+		}
+		if strings.LastIndexByte(alphabet, alphabet[i]) != i {
+			return fmt.Errorf("alphabet char %q is repeated", alphabet[i])
+		}
+	}
+	return nil
+}
+
+func alphabetOrDefault(alphabet, defaultAlphabet string) string {
+	if alphabet == "" {
+		return defaultAlphabet
+	}
+	return alphabet
+}
+
+// encode writes n in the base of the alphabet, left padded with the first char to the length.
+// The result is longer than the length if n does not fit.
+func encode(n uint64, alphabet string, length int) string {
+	base := uint64(len(alphabet))
+	buf := make([]byte, 0, length)
+	for n > 0 {
+		buf = append(buf, alphabet[n%base])
+		n /= base
+	}
+	for len(buf) < length {
+		buf = append(buf, alphabet[0])
+	}
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	return string(buf)
+}
+
+// digits returns how many chars of the alphabet are needed to encode n.
+func digits(n uint64, alphabet string) int {
+	return len(encode(n, alphabet, 1))
+}
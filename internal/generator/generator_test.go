@@ -0,0 +1,126 @@
+package generator_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/WeiAnAn/url-shortener/internal/generator"
+)
+
+func newGenerators(t *testing.T, alphabet string) map[string]generator.Generator {
+	generators := map[string]generator.Generator{}
+	for _, name := range generator.Names() {
+		g, err := generator.New(name, generator.Options{
+			Alphabet: alphabet,
+			Length:   64,
+			Secret:   "secret",
+			Counter:  generator.NewMemoryCounter(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		generators[name] = g
+	}
+	return generators
+}
+
+func TestGeneratorsUseOnlyAlphabetChars(t *testing.T) {
+	for _, alphabet := range []string{"", generator.BASE58_ALPHABET, "ab", "0123456789-_"} {
+		for name, g := range newGenerators(t, alphabet) {
+			alphabet := alphabet
+			if alphabet == "" {
+				alphabet = generator.BASE62_ALPHABET
+				if name == generator.BASE58 {
+					alphabet = generator.BASE58_ALPHABET
+				}
+			}
+			minLength, maxLength := 1, 32
+			if name == generator.SNOWFLAKE {
+				minLength = generator.SnowflakeLength(alphabet)
+				maxLength = minLength + 8
+			}
+
+			property := func(n uint8) bool {
+				length := minLength + int(n)%(maxLength-minLength+1)
+				short, err := g.Generate(context.Background(), length)
+				if err != nil {
+					t.Log(err)
+					return false
+				}
+				if len(short) < length || (name != generator.COUNTER && len(short) != length) {
+					t.Logf("%s generated %q for length %d", name, short, length)
+					return false
+				}
+				for _, c := range short {
+					if !strings.ContainsRune(alphabet, c) {
+						t.Logf("%s generated %q out of alphabet %q", name, short, alphabet)
+						return false
+					}
+				}
+				return true
+			}
+			if err := quick.Check(property, nil); err != nil {
+				t.Errorf("%s with alphabet %q: %v", name, alphabet, err)
+			}
+		}
+	}
+}
+
+func TestGeneratorsDoNotRepeat(t *testing.T) {
+	for name, g := range newGenerators(t, "") {
+		length := 11
+		seen := map[string]bool{}
+		for i := 0; i < 100000; i++ {
+			short, err := g.Generate(context.Background(), length)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if seen[short] {
+				t.Errorf("%s repeated %s after %d short urls", name, short, i)
+				break
+			}
+			seen[short] = true
+		}
+	}
+}
+
+func TestNewRejectsUnknownGenerator(t *testing.T) {
+	_, err := generator.New("uuid", generator.Options{})
+	if err == nil {
+		t.Error("expect error of unknown generator")
+	}
+}
+
+func TestRegisterGenerator(t *testing.T) {
+	generator.Register("test", func(options generator.Options) (generator.Generator, error) {
+		return generator.NewRandomGenerator("01")
+	})
+	defer generator.Register("test", nil)
+
+	g, err := generator.New("test", generator.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	short, _ := g.Generate(context.Background(), 8)
+	if strings.Trim(short, "01") != "" {
+		t.Errorf("expect registered generator to be used, got %s", short)
+	}
+}
+
+func TestValidateAlphabet(t *testing.T) {
+	for alphabet, valid := range map[string]bool{
+		generator.BASE62_ALPHABET: true,
+		generator.BASE58_ALPHABET: true,
+		"ab":                      true,
+		"a":                       false,
+		"aba":                     false,
+		"ab/":                     false,
+		"ab?":                     false,
+	} {
+		if err := generator.ValidateAlphabet(alphabet); (err == nil) != valid {
+			t.Errorf("alphabet %q expect valid %v, got %v", alphabet, valid, err)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package generator
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// MemoryCounter is only shared within the process, it starts from 1 again on restart.
+type MemoryCounter struct {
+	n atomic.Uint64
+}
+
+func NewMemoryCounter() *MemoryCounter {
+	return &MemoryCounter{}
+}
+
+func (m *MemoryCounter) Next(c context.Context) (uint64, error) {
+	return m.n.Add(1), nil
+}
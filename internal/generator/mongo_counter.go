@@ -0,0 +1,41 @@
+package generator
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const COLLECTION_NAME = "counters"
+
+type CounterDocument struct {
+	Name  string `bson:"_id"`
+	Value int64  `bson:"value"`
+}
+
+// MongoCounter increases the value of a counter document, which is created on the first call.
+type MongoCounter struct {
+	client   *mongo.Client
+	database string
+	name     string
+}
+
+func NewMongoCounter(c *mongo.Client, d, name string) *MongoCounter {
+	return &MongoCounter{c, d, name}
+}
+
+func (m *MongoCounter) Next(c context.Context) (uint64, error) {
+	var doc CounterDocument
+	err := m.client.Database(m.database).Collection(COLLECTION_NAME).FindOneAndUpdate(
+		c,
+		bson.M{"_id": m.name},
+		bson.M{"$inc": bson.M{"value": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(doc.Value), nil
+}
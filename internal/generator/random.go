@@ -0,0 +1,42 @@
+package generator
+
+import (
+	"context"
+	"crypto/rand"
+	"io"
+)
+
+// RandomGenerator draws every char uniformly from the alphabet with crypto/rand,
+// so short urls can not be guessed from the ones a client has seen.
+type RandomGenerator struct {
+	alphabet string
+	// bytes at or above limit are rejected, so every char is equally likely.
+	limit int
+}
+
+func NewRandomGenerator(alphabet string) (*RandomGenerator, error) {
+	if err := ValidateAlphabet(alphabet); err != nil {
+		return nil, err
+	}
+	return &RandomGenerator{alphabet, 256 - 256%len(alphabet)}, nil
+}
+
+func (r *RandomGenerator) Generate(c context.Context, length int) (string, error) {
+	result := make([]byte, 0, length)
+	buf := make([]byte, length)
+	for len(result) < length {
+		if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) >= r.limit {
+				continue
+			}
+			result = append(result, r.alphabet[int(b)%len(r.alphabet)])
+			if len(result) == length {
+				break
+			}
+		}
+	}
+	return string(result), nil
+}
@@ -0,0 +1,27 @@
+package generator
+
+import (
+	"context"
+
+	"github.com/redis/rueidis"
+)
+
+const KEY_PREFIX = "counter:"
+
+// RedisCounter increases a redis key, the key must be persisted or the short urls repeat after it is lost.
+type RedisCounter struct {
+	client rueidis.Client
+	key    string
+}
+
+func NewRedisCounter(client rueidis.Client, name string) *RedisCounter {
+	return &RedisCounter{client, KEY_PREFIX + name}
+}
+
+func (r *RedisCounter) Next(c context.Context) (uint64, error) {
+	n, err := r.client.Do(c, r.client.B().Incr().Key(r.key).Build()).AsUint64()
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/utils"
+)
+
+// SNOWFLAKE_EPOCH is the start of the snowflake timestamps, 41 bits of milliseconds last until 2092.
+var SNOWFLAKE_EPOCH = time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+const (
+	SNOWFLAKE_NODE_BITS     = 10
+	SNOWFLAKE_SEQUENCE_BITS = 12
+	MAX_SNOWFLAKE_NODE_ID   = 1<<SNOWFLAKE_NODE_BITS - 1
+	maxSnowflakeSequence    = 1<<SNOWFLAKE_SEQUENCE_BITS - 1
+)
+
+// SnowflakeGenerator generates time ordered ids made of the milliseconds since SNOWFLAKE_EPOCH,
+// the node id and a sequence within the millisecond. The ids are unique without a round trip
+// as long as every replica has its own node id.
+type SnowflakeGenerator struct {
+	alphabet string
+	nodeID   int64
+	time     utils.TimeUtil
+
+	mu            sync.Mutex
+	lastTimestamp int64
+	sequence      int64
+}
+
+// SnowflakeLength is the length of the largest snowflake id encoded in the alphabet.
+func SnowflakeLength(alphabet string) int {
+	return digits(math.MaxInt64, alphabet)
+}
+
+func NewSnowflakeGenerator(alphabet string, length int, nodeID int64, t utils.TimeUtil) (*SnowflakeGenerator, error) {
+	if err := ValidateAlphabet(alphabet); err != nil {
+		return nil, err
+	}
+	if nodeID < 0 || nodeID > MAX_SNOWFLAKE_NODE_ID {
+		return nil, fmt.Errorf("snowflake node id must be between 0 and %d, got %d", MAX_SNOWFLAKE_NODE_ID, nodeID)
+	}
+	if min := SnowflakeLength(alphabet); length < min {
+		return nil, fmt.Errorf("%w: snowflake ids need %d chars of the alphabet, got %d", ErrLengthTooShort, min, length)
+	}
+	return &SnowflakeGenerator{alphabet: alphabet, nodeID: nodeID, time: t}, nil
+}
+
+func (s *SnowflakeGenerator) Generate(c context.Context, length int) (string, error) {
+	if min := SnowflakeLength(s.alphabet); length < min {
+		return "", fmt.Errorf("%w: snowflake ids need %d chars of the alphabet, got %d", ErrLengthTooShort, min, length)
+	}
+	return encode(uint64(s.next()), s.alphabet, length), nil
+}
+
+func (s *SnowflakeGenerator) next() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// a clock moving backwards keeps using the last timestamp, and an exhausted sequence borrows
+	// the next millisecond, so ids never repeat and never block
+	timestamp := s.time.Now().Sub(SNOWFLAKE_EPOCH).Milliseconds()
+	if timestamp > s.lastTimestamp {
+		s.lastTimestamp = timestamp
+		s.sequence = 0
+	} else if s.sequence < maxSnowflakeSequence {
+		s.sequence++
+	} else {
+		s.lastTimestamp++
+		s.sequence = 0
+	}
+
+	return s.lastTimestamp<<(SNOWFLAKE_NODE_BITS+SNOWFLAKE_SEQUENCE_BITS) | s.nodeID<<SNOWFLAKE_SEQUENCE_BITS | s.sequence
+}
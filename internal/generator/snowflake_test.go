@@ -0,0 +1,69 @@
+package generator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/generator"
+	mock_utils "github.com/WeiAnAn/url-shortener/internal/utils/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+func TestSnowflakeGeneratorIsTimeOrdered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockTime := mock_utils.NewMockTimeUtil(ctrl)
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	gomock.InOrder(
+		mockTime.EXPECT().Now().Return(now),
+		mockTime.EXPECT().Now().Return(now),
+		mockTime.EXPECT().Now().Return(now.Add(time.Millisecond)),
+		// the clock moves backwards
+		mockTime.EXPECT().Now().Return(now),
+	)
+	g, err := generator.NewSnowflakeGenerator(generator.BASE62_ALPHABET, 11, 1, mockTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var last string
+	for i := 0; i < 4; i++ {
+		short, err := g.Generate(context.Background(), 11)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if short <= last {
+			t.Errorf("expect %s to be after %s", short, last)
+		}
+		last = short
+	}
+}
+
+func TestSnowflakeGeneratorDependsOnNodeID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockTime := mock_utils.NewMockTimeUtil(ctrl)
+	mockTime.EXPECT().Now().Return(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)).Times(2)
+	a, _ := generator.NewSnowflakeGenerator(generator.BASE62_ALPHABET, 11, 1, mockTime)
+	b, _ := generator.NewSnowflakeGenerator(generator.BASE62_ALPHABET, 11, 2, mockTime)
+
+	shortA, _ := a.Generate(context.Background(), 11)
+	shortB, _ := b.Generate(context.Background(), 11)
+	if shortA == shortB {
+		t.Errorf("expect nodes to generate different ids at the same time, both got %s", shortA)
+	}
+}
+
+func TestSnowflakeGeneratorRejectsShortLength(t *testing.T) {
+	_, err := generator.NewSnowflakeGenerator(generator.BASE62_ALPHABET, 7, 1, nil)
+	if !errors.Is(err, generator.ErrLengthTooShort) {
+		t.Errorf("expect error %v, got %v", generator.ErrLengthTooShort, err)
+	}
+}
+
+func TestSnowflakeGeneratorRejectsInvalidNodeID(t *testing.T) {
+	_, err := generator.NewSnowflakeGenerator(generator.BASE62_ALPHABET, 11, generator.MAX_SNOWFLAKE_NODE_ID+1, nil)
+	if err == nil {
+		t.Error("expect error of invalid node id")
+	}
+}
@@ -0,0 +1,92 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	STATUS_UP            = "up"
+	STATUS_DOWN          = "down"
+	STATUS_SHUTTING_DOWN = "shutting_down"
+)
+
+// CheckFunc returns an error when the dependency can not serve requests.
+type CheckFunc func(c context.Context) error
+
+type Dependency struct {
+	Name  string
+	Check CheckFunc
+}
+
+type DependencyReport struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+type Report struct {
+	Status       string                       `json:"status"`
+	Dependencies map[string]*DependencyReport `json:"dependencies,omitempty"`
+}
+
+// Checker checks the dependencies concurrently, each one is given at most timeout.
+type Checker struct {
+	dependencies []Dependency
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration, dependencies ...Dependency) *Checker {
+	return &Checker{dependencies: dependencies, timeout: timeout}
+}
+
+// Shutdown makes the following checks fail, so no more requests are routed to this instance.
+func (h *Checker) Shutdown() {
+	h.shuttingDown.Store(true)
+}
+
+// Check reports STATUS_DOWN if any dependency is down.
+func (h *Checker) Check(c context.Context) *Report {
+	if h.shuttingDown.Load() {
+		return &Report{Status: STATUS_SHUTTING_DOWN}
+	}
+
+	report := &Report{Status: STATUS_UP, Dependencies: make(map[string]*DependencyReport, len(h.dependencies))}
+	results := make([]*DependencyReport, len(h.dependencies))
+	var wg sync.WaitGroup
+	for i, dependency := range h.dependencies {
+		wg.Add(1)
+		go func(i int, dependency Dependency) {
+			defer wg.Done()
+			results[i] = h.check(c, dependency)
+		}(i, dependency)
+	}
+	wg.Wait()
+
+	for i, dependency := range h.dependencies {
+		report.Dependencies[dependency.Name] = results[i]
+		if results[i].Status != STATUS_UP {
+			report.Status = STATUS_DOWN
+		}
+	}
+	return report
+}
+
+func (h *Checker) check(c context.Context, dependency Dependency) *DependencyReport {
+	ctx, cancel := context.WithTimeout(c, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := dependency.Check(ctx)
+	latency := time.Since(start)
+
+	report := &DependencyReport{Status: STATUS_UP, LatencyMs: float64(latency.Microseconds()) / 1000}
+	if err != nil {
+		slog.WarnContext(c, "health check failed", slog.String("dependency", dependency.Name), slog.Any("error", err))
+		report.Status = STATUS_DOWN
+	}
+	return report
+}
@@ -0,0 +1,78 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/health"
+)
+
+func up(c context.Context) error {
+	return nil
+}
+
+func TestCheckerReportUpWhenAllDependenciesAreUp(t *testing.T) {
+	checker := health.NewChecker(time.Second, health.Dependency{Name: "mongo", Check: up}, health.Dependency{Name: "redis", Check: up})
+
+	report := checker.Check(context.Background())
+	if report.Status != health.STATUS_UP {
+		t.Errorf("expected %s, got %s", health.STATUS_UP, report.Status)
+	}
+	if len(report.Dependencies) != 2 || report.Dependencies["mongo"].Status != health.STATUS_UP || report.Dependencies["redis"].Status != health.STATUS_UP {
+		t.Errorf("unexpected dependencies %+v", report.Dependencies)
+	}
+}
+
+func TestCheckerReportDownWhenDependencyFails(t *testing.T) {
+	down := func(c context.Context) error {
+		return errors.New("connection refused")
+	}
+	checker := health.NewChecker(time.Second, health.Dependency{Name: "mongo", Check: up}, health.Dependency{Name: "redis", Check: down})
+
+	report := checker.Check(context.Background())
+	if report.Status != health.STATUS_DOWN {
+		t.Errorf("expected %s, got %s", health.STATUS_DOWN, report.Status)
+	}
+	if report.Dependencies["mongo"].Status != health.STATUS_UP || report.Dependencies["redis"].Status != health.STATUS_DOWN {
+		t.Errorf("unexpected dependencies %+v", report.Dependencies)
+	}
+}
+
+func TestCheckerTimeoutSlowDependency(t *testing.T) {
+	hang := func(c context.Context) error {
+		<-c.Done()
+		return c.Err()
+	}
+	checker := health.NewChecker(50*time.Millisecond, health.Dependency{Name: "mongo", Check: hang})
+
+	start := time.Now()
+	report := checker.Check(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("check took %v", elapsed)
+	}
+	if report.Status != health.STATUS_DOWN {
+		t.Errorf("expected %s, got %s", health.STATUS_DOWN, report.Status)
+	}
+	if report.Dependencies["mongo"].LatencyMs < 50 {
+		t.Errorf("unexpected latency %v", report.Dependencies["mongo"].LatencyMs)
+	}
+}
+
+func TestCheckerReportShuttingDownAfterShutdown(t *testing.T) {
+	called := false
+	checker := health.NewChecker(time.Second, health.Dependency{Name: "mongo", Check: func(c context.Context) error {
+		called = true
+		return nil
+	}})
+
+	checker.Shutdown()
+	report := checker.Check(context.Background())
+	if report.Status != health.STATUS_SHUTTING_DOWN {
+		t.Errorf("expected %s, got %s", health.STATUS_SHUTTING_DOWN, report.Status)
+	}
+	if called {
+		t.Error("dependencies are checked during shutdown")
+	}
+}
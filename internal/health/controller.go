@@ -0,0 +1,30 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Controller struct {
+	checker *Checker
+}
+
+func NewController(checker *Checker) *Controller {
+	return &Controller{checker}
+}
+
+// Liveness only tells the process is able to serve http requests.
+func (c *Controller) Liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, &Report{Status: STATUS_UP})
+}
+
+// Readiness responds 503 when a dependency is down or the server is shutting down.
+func (c *Controller) Readiness(ctx *gin.Context) {
+	report := c.checker.Check(ctx)
+	status := http.StatusOK
+	if report.Status != STATUS_UP {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, report)
+}
@@ -0,0 +1,61 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+const KEY_PREFIX = "lock:"
+
+// unlockScript only deletes the lock if it is still held by the same token,
+// so a lock which expired and was taken by others is not released.
+var unlockScript = rueidis.NewLuaScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisLocker is a lock shared across replicas. The lock is released after ttl even if
+// the holder does not unlock it.
+type RedisLocker struct {
+	client rueidis.Client
+}
+
+func NewRedisLocker(client rueidis.Client) *RedisLocker {
+	return &RedisLocker{client}
+}
+
+// TryLock returns false without waiting if the lock is held by others.
+func (r *RedisLocker) TryLock(c context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = r.client.Do(c, r.client.B().Set().Key(KEY_PREFIX+key).Value(token).Nx().PxMilliseconds(ttl.Milliseconds()).Build()).Error()
+	if rueidis.IsRedisNil(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	unlock := func() {
+		unlockScript.Exec(context.Background(), r.client, []string{KEY_PREFIX + key}, []string{token})
+	}
+	return unlock, true, nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,94 @@
+package lock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/lock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/rueidis"
+)
+
+func TestRedisLockerTryLockReturnFalseIfLockIsHeld(t *testing.T) {
+	m, locker := createRedisLocker(t)
+	c := context.Background()
+
+	unlock, locked, err := locker.TryLock(c, "key", time.Second)
+	if err != nil || !locked || unlock == nil {
+		t.Fatalf("expect the lock to be taken, got %v %v", locked, err)
+	}
+	if ttl := m.TTL(lock.KEY_PREFIX + "key"); ttl != time.Second {
+		t.Errorf("expect the lock to expire after ttl, got %v", ttl)
+	}
+
+	_, locked, err = locker.TryLock(c, "key", time.Second)
+	if err != nil || locked {
+		t.Errorf("expect the held lock not to be taken, got %v %v", locked, err)
+	}
+	_, locked, _ = locker.TryLock(c, "other", time.Second)
+	if !locked {
+		t.Error("expect locks of other keys to be taken")
+	}
+}
+
+func TestRedisLockerUnlockReleaseLock(t *testing.T) {
+	_, locker := createRedisLocker(t)
+	c := context.Background()
+
+	unlock, _, _ := locker.TryLock(c, "key", time.Second)
+	unlock()
+
+	_, locked, err := locker.TryLock(c, "key", time.Second)
+	if err != nil || !locked {
+		t.Errorf("expect the released lock to be taken, got %v %v", locked, err)
+	}
+}
+
+func TestRedisLockerReleaseLockAfterTTL(t *testing.T) {
+	m, locker := createRedisLocker(t)
+	c := context.Background()
+
+	locker.TryLock(c, "key", time.Second)
+	m.FastForward(time.Second)
+
+	_, locked, err := locker.TryLock(c, "key", time.Second)
+	if err != nil || !locked {
+		t.Errorf("expect the expired lock to be taken, got %v %v", locked, err)
+	}
+}
+
+func TestRedisLockerUnlockNotReleaseLockTakenByOthers(t *testing.T) {
+	m, locker := createRedisLocker(t)
+	c := context.Background()
+
+	unlock, _, _ := locker.TryLock(c, "key", time.Second)
+	m.FastForward(time.Second)
+	locker.TryLock(c, "key", time.Second)
+
+	unlock()
+
+	if !m.Exists(lock.KEY_PREFIX + "key") {
+		t.Error("expect the lock taken by others to be kept")
+	}
+}
+
+func TestRedisLockerReturnErrorIfRedisFails(t *testing.T) {
+	m, locker := createRedisLocker(t)
+	m.SetError("error")
+
+	_, locked, err := locker.TryLock(context.Background(), "key", time.Second)
+	if err == nil || locked {
+		t.Errorf("expect error, got %v %v", locked, err)
+	}
+}
+
+func createRedisLocker(t *testing.T) (*miniredis.Miniredis, *lock.RedisLocker) {
+	m := miniredis.RunT(t)
+	client, err := rueidis.NewClient(rueidis.ClientOption{InitAddress: []string{m.Addr()}, DisableCache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return m, lock.NewRedisLocker(client)
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID attaches the request id to the context, it is added to every line logged with the context.
+func WithRequestID(c context.Context, requestID string) context.Context {
+	return context.WithValue(c, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request id attached to the context, or an empty string.
+func RequestID(c context.Context) string {
+	requestID, _ := c.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// New logs JSON lines at or above the level, e.g. "info". Unknown levels fall back to info.
+func New(w io.Writer, level string) *slog.Logger {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		l = slog.LevelInfo
+	}
+	return slog.New(&contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{Level: l})})
+}
+
+// contextHandler adds the request id and the trace of the context to the records.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(c context.Context, r slog.Record) error {
+	if c != nil {
+		if requestID := RequestID(c); requestID != "" {
+			r.AddAttrs(slog.String("request_id", requestID))
+		}
+		if span := trace.SpanContextFromContext(c); span.IsValid() {
+			r.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+		}
+	}
+	return h.Handler.Handle(c, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,65 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/WeiAnAn/url-shortener/internal/logger"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLoggerAddRequestIDAndTraceOfContext(t *testing.T) {
+	var buf bytes.Buffer
+	l := logger.New(&buf, "info").With("component", "test")
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	c := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+	c = logger.WithRequestID(c, "request-id")
+
+	l.InfoContext(c, "hello", "key", "value")
+
+	var line map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("log line is not JSON: %s", buf.String())
+	}
+	expected := map[string]string{
+		"msg":        "hello",
+		"level":      "INFO",
+		"key":        "value",
+		"component":  "test",
+		"request_id": "request-id",
+		"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":    "00f067aa0ba902b7",
+	}
+	for key, value := range expected {
+		if line[key] != value {
+			t.Errorf("expected %s to be %s, got %s", key, value, line[key])
+		}
+	}
+}
+
+func TestLoggerSkipLinesBelowLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := logger.New(&buf, "warn")
+
+	l.Info("hidden")
+	l.Warn("shown")
+
+	if bytes.Contains(buf.Bytes(), []byte("hidden")) || !bytes.Contains(buf.Bytes(), []byte("shown")) {
+		t.Errorf("unexpected output %s", buf.String())
+	}
+}
+
+func TestLoggerWithoutContext(t *testing.T) {
+	var buf bytes.Buffer
+	l := logger.New(&buf, "info")
+
+	l.Info("hello")
+
+	if bytes.Contains(buf.Bytes(), []byte("request_id")) {
+		t.Errorf("unexpected request id in %s", buf.String())
+	}
+}
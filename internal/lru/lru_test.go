@@ -0,0 +1,76 @@
+package lru_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/lru"
+)
+
+func TestGetReturnValueBeforeExpired(t *testing.T) {
+	c := lru.New(10)
+	now := time.Now()
+	c.Set("key", "value", now.Add(time.Second))
+
+	value, ok := c.Get("key", now)
+	if !ok || value != "value" {
+		t.Errorf("expected value, got %s %v", value, ok)
+	}
+
+	_, ok = c.Get("key", now.Add(time.Second))
+	if ok {
+		t.Error("expired entry is returned")
+	}
+	if c.Len() != 0 {
+		t.Error("expired entry is not removed")
+	}
+}
+
+func TestSetEvictLeastRecentlyUsed(t *testing.T) {
+	c := lru.New(2)
+	now := time.Now()
+	expireAt := now.Add(time.Minute)
+	c.Set("a", "1", expireAt)
+	c.Set("b", "2", expireAt)
+	c.Get("a", now)
+	c.Set("c", "3", expireAt)
+
+	if _, ok := c.Get("b", now); ok {
+		t.Error("least recently used entry is not evicted")
+	}
+	if _, ok := c.Get("a", now); !ok {
+		t.Error("recently used entry is evicted")
+	}
+	if _, ok := c.Get("c", now); !ok {
+		t.Error("new entry is not added")
+	}
+}
+
+func TestSetOverwriteValue(t *testing.T) {
+	c := lru.New(2)
+	now := time.Now()
+	c.Set("a", "1", now.Add(time.Minute))
+	c.Set("a", "2", now.Add(time.Minute))
+
+	value, _ := c.Get("a", now)
+	if value != "2" || c.Len() != 1 {
+		t.Errorf("expected overwritten value, got %s with %d entries", value, c.Len())
+	}
+}
+
+func TestDeleteAndClear(t *testing.T) {
+	c := lru.New(10)
+	now := time.Now()
+	c.Set("a", "1", now.Add(time.Minute))
+	c.Set("b", "2", now.Add(time.Minute))
+
+	c.Delete("a")
+	if _, ok := c.Get("a", now); ok {
+		t.Error("deleted entry is returned")
+	}
+
+	c.Clear()
+	if c.Len() != 0 {
+		t.Error("entries are not cleared")
+	}
+}
@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const NAMESPACE = "url_shortener"
+
+const (
+	RESULT_HIT   = "hit"
+	RESULT_MISS  = "miss"
+	RESULT_OK    = "ok"
+	RESULT_ERROR = "error"
+)
+
+// Metrics holds the collectors in its own registry, so it can be created more than once in tests.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	HTTPRequests        *prometheus.CounterVec
+	HTTPRequestDuration *prometheus.HistogramVec
+	// Redirects counts redirect lookups by RESULT_HIT or RESULT_MISS.
+	Redirects *prometheus.CounterVec
+	// ExpiredLookups counts cache misses of expired short urls, they are counted by the repository.
+	// Lookups answered by the cached empty string are not counted.
+	ExpiredLookups prometheus.Counter
+	// CacheLookups counts cache reads by store and RESULT_HIT or RESULT_MISS.
+	CacheLookups           *prometheus.CounterVec
+	StoreOperationDuration *prometheus.HistogramVec
+	ShortURLsCreated       prometheus.Counter
+	// ShortURLsReused counts creations answered with an existing short url when deduplication is enabled.
+	ShortURLsReused prometheus.Counter
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: NAMESPACE,
+			Name:      "http_requests_total",
+			Help:      "Number of http requests by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		HTTPRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: NAMESPACE,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of http requests by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		Redirects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: NAMESPACE,
+			Name:      "redirects_total",
+			Help:      "Number of redirect lookups by result, miss if the short url does not exist or is expired.",
+		}, []string{"result"}),
+		ExpiredLookups: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: NAMESPACE,
+			Name:      "expired_lookups_total",
+			Help:      "Number of redirect lookups of expired short urls which missed the cache, later lookups served the cached not found result are not counted.",
+		}),
+		CacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: NAMESPACE,
+			Name:      "cache_lookups_total",
+			Help:      "Number of cache reads by store and result.",
+		}, []string{"store", "result"}),
+		StoreOperationDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: NAMESPACE,
+			Name:      "store_operation_duration_seconds",
+			Help:      "Latency of store operations by store, operation and result.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"store", "operation", "result"}),
+		ShortURLsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: NAMESPACE,
+			Name:      "short_urls_created_total",
+			Help:      "Number of created short urls.",
+		}),
+		ShortURLsReused: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: NAMESPACE,
+			Name:      "short_urls_reused_total",
+			Help:      "Number of short url creations which reused an existing short url.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.HTTPRequests,
+		m.HTTPRequestDuration,
+		m.Redirects,
+		m.ExpiredLookups,
+		m.CacheLookups,
+		m.StoreOperationDuration,
+		m.ShortURLsCreated,
+		m.ShortURLsReused,
+	)
+	return m
+}
+
+// ObserveStoreOperation records the time since start.
+func (m *Metrics) ObserveStoreOperation(store, operation string, start time.Time, err error) {
+	result := RESULT_OK
+	if err != nil {
+		result = RESULT_ERROR
+	}
+	m.StoreOperationDuration.WithLabelValues(store, operation, result).Observe(time.Since(start).Seconds())
+}
+
+// RegisterCacheStats exports hits and misses counted by a cache store itself, such as its in process tier.
+func (m *Metrics) RegisterCacheStats(tier string, stats func() (hits, misses uint64)) {
+	counter := func(result string, value func() float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace:   NAMESPACE,
+			Name:        "cache_tier_lookups_total",
+			Help:        "Number of reads of an in process cache tier by tier and result.",
+			ConstLabels: prometheus.Labels{"tier": tier, "result": result},
+		}, value)
+	}
+	m.registry.MustRegister(
+		counter(RESULT_HIT, func() float64 {
+			hits, _ := stats()
+			return float64(hits)
+		}),
+		counter(RESULT_MISS, func() float64 {
+			_, misses := stats()
+			return float64(misses)
+		}),
+	)
+}
+
+// Handler serves the metrics in Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
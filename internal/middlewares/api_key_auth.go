@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"strings"
+
+	apikey "github.com/WeiAnAn/url-shortener/internal/domain/api_key"
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+	"github.com/gin-gonic/gin"
+)
+
+const API_KEY_HEADER = "X-API-Key"
+
+// APIKeyAuth accepts the key from X-API-Key header or Authorization: Bearer header.
+func APIKeyAuth(service apikey.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(API_KEY_HEADER)
+		if key == "" {
+			key = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+
+		apiKey, err := service.Authenticate(c, key)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if apiKey == nil {
+			c.Error(myerror.NewUnauthorizedError("Invalid or missing API key"))
+			c.Abort()
+			return
+		}
+
+		c.Set(apikey.OWNER_ID_KEY, apiKey.ID)
+		c.Next()
+	}
+}
@@ -0,0 +1,58 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apikey "github.com/WeiAnAn/url-shortener/internal/domain/api_key"
+	mock_apikey "github.com/WeiAnAn/url-shortener/internal/domain/api_key/mocks"
+	"github.com/WeiAnAn/url-shortener/internal/middlewares"
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+)
+
+func TestAPIKeyAuthResponseUnauthorizedIfKeyIsInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	service := mock_apikey.NewMockService(ctrl)
+	service.EXPECT().Authenticate(gomock.Any(), "invalid").Return(nil, nil)
+	r := createAuthRouter(service)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/urls", nil)
+	req.Header.Set(middlewares.API_KEY_HEADER, "invalid")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("unexpected status code %d", w.Code)
+	}
+}
+
+func TestAPIKeyAuthSetOwnerIDFromBearerToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	service := mock_apikey.NewMockService(ctrl)
+	service.EXPECT().Authenticate(gomock.Any(), "valid").Return(&apikey.APIKey{ID: "owner"}, nil)
+	r := createAuthRouter(service)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/urls", nil)
+	req.Header.Set("Authorization", "Bearer valid")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != "owner" {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+}
+
+func createAuthRouter(service apikey.Service) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.ErrorHandler(myerror.ERROR_FORMAT_PROBLEM))
+	r.GET("/api/v1/urls", middlewares.APIKeyAuth(service), func(c *gin.Context) {
+		c.String(http.StatusOK, apikey.OwnerID(c))
+	})
+	return r
+}
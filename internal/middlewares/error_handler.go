@@ -1,45 +1,54 @@
 package middlewares
 
 import (
-	"fmt"
+	"log/slog"
 	"net/http"
-	"strings"
 
+	"github.com/WeiAnAn/url-shortener/internal/logger"
 	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 )
 
-func ErrorHandler() gin.HandlerFunc {
+// ErrorHandler responds the first error in the format and logs the underlying errors, which are hidden
+// from clients if they are unknown. The request id is responded, so a client report can be matched with the logs.
+func ErrorHandler(format myerror.ErrorFormat) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
 
+		ctx := c.Request.Context()
 		for _, err := range c.Errors {
-			var msg string
-			status := http.StatusInternalServerError
-
-			switch err.Err.(type) {
-			case validator.ValidationErrors:
-				myErr := err.Err.(validator.ValidationErrors)
-				status = http.StatusBadRequest
-				errorMessage := make([]string, len(myErr))
-				for i, fieldErr := range myErr {
-					errorMessage[i] = fmt.Sprintf("on %s with %v", fieldErr.Field(), fieldErr.Value())
-				}
-				msg = fmt.Sprintf("Validation errors: %s", strings.Join(errorMessage, ", "))
-			case *myerror.ValidationError:
-				myErr := err.Err.(*myerror.ValidationError)
-				status = http.StatusBadRequest
-				msg = myErr.Error()
-			default:
-				msg = "Internal server error"
+			status, _ := myerror.StatusAndMessage(err.Err)
+
+			if status >= http.StatusInternalServerError {
+				slog.ErrorContext(ctx, "request failed", slog.Int("status", status), slog.Any("error", err.Err))
+			} else {
+				slog.DebugContext(ctx, "request rejected", slog.Int("status", status), slog.Any("error", err.Err))
 			}
 
 			if !c.Writer.Written() {
-				c.JSON(status, gin.H{
-					"message": msg,
-				})
+				respondError(c, format, err.Err)
 			}
 		}
 	}
 }
+
+// respondError writes err as application/problem+json, or as {"message": ...} in ERROR_FORMAT_LEGACY.
+func respondError(c *gin.Context, format myerror.ErrorFormat, err error) {
+	requestID := logger.RequestID(c.Request.Context())
+
+	if format == myerror.ERROR_FORMAT_LEGACY {
+		status, msg := myerror.StatusAndMessage(err)
+		c.JSON(status, gin.H{
+			"message":   msg,
+			"requestId": requestID,
+		})
+		return
+	}
+
+	problem := myerror.NewProblem(err)
+	problem.Instance = c.Request.URL.Path
+	problem.RequestID = requestID
+	// gin keeps the content type which is already set
+	c.Header("Content-Type", myerror.PROBLEM_CONTENT_TYPE)
+	c.JSON(problem.Status, problem)
+}
@@ -0,0 +1,163 @@
+package middlewares_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/WeiAnAn/url-shortener/internal/logger"
+	"github.com/WeiAnAn/url-shortener/internal/middlewares"
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestErrorHandlerLogHiddenErrorWithRequestID(t *testing.T) {
+	buf := captureLogs(t)
+	r := createErrorRouter(myerror.ERROR_FORMAT_PROBLEM, errors.New("connection refused"))
+
+	w := serveErrorRequest(r, "abc-123")
+
+	var body myerror.Problem
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusInternalServerError || body.Code != myerror.CODE_INTERNAL_ERROR || body.Detail != "" || body.RequestID != "abc-123" {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	var line map[string]any
+	for _, l := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		json.Unmarshal(l, &line)
+		if line["msg"] == "request failed" {
+			break
+		}
+	}
+	if line["error"] != "connection refused" || line["request_id"] != "abc-123" || line["level"] != "ERROR" {
+		t.Errorf("underlying error is not logged, got %s", buf.String())
+	}
+}
+
+func TestErrorHandlerRespondKnownErrorAsProblem(t *testing.T) {
+	captureLogs(t)
+	r := createErrorRouter(myerror.ERROR_FORMAT_PROBLEM, myerror.NewNotFoundError("short url", "abc"))
+
+	w := serveErrorRequest(r, "abc-123")
+
+	if w.Header().Get("Content-Type") != myerror.PROBLEM_CONTENT_TYPE {
+		t.Errorf("expect content type %s, got %s", myerror.PROBLEM_CONTENT_TYPE, w.Header().Get("Content-Type"))
+	}
+	var body myerror.Problem
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusNotFound || body.Status != http.StatusNotFound || body.Code != myerror.CODE_NOT_FOUND ||
+		body.Type != myerror.PROBLEM_TYPE_PREFIX+myerror.CODE_NOT_FOUND || body.Instance != "/" || body.RequestID != "abc-123" {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestErrorHandlerRespondInvalidParamsOfValidationErrors(t *testing.T) {
+	captureLogs(t)
+	v := validator.New()
+	myerror.RegisterFieldNames(v)
+	err := v.Struct(struct {
+		OriginalURL string `json:"originalUrl" validate:"required,url"`
+		Format      string `json:"format" validate:"oneof=json csv"`
+	}{OriginalURL: "not a url", Format: "xml"})
+	r := createErrorRouter(myerror.ERROR_FORMAT_PROBLEM, err)
+
+	w := serveErrorRequest(r, "abc-123")
+
+	var body myerror.Problem
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusBadRequest || body.Code != myerror.CODE_VALIDATION_FAILED || len(body.InvalidParams) != 2 {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+	if *body.InvalidParams[0] != (myerror.InvalidParam{Name: "originalUrl", Reason: "must be a URL"}) ||
+		*body.InvalidParams[1] != (myerror.InvalidParam{Name: "format", Reason: "must be one of json, csv"}) {
+		t.Errorf("unexpected invalid params %s", w.Body.String())
+	}
+}
+
+func TestErrorHandlerRespondTimeoutAsUpstreamUnavailable(t *testing.T) {
+	captureLogs(t)
+	r := createErrorRouter(myerror.ERROR_FORMAT_PROBLEM, fmt.Errorf("find short url: %w", context.DeadlineExceeded))
+
+	w := serveErrorRequest(r, "abc-123")
+
+	var body myerror.Problem
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusServiceUnavailable || body.Code != myerror.CODE_UPSTREAM_UNAVAILABLE {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestErrorHandlerRespondLegacyFormat(t *testing.T) {
+	captureLogs(t)
+	r := createErrorRouter(myerror.ERROR_FORMAT_LEGACY, myerror.NewNotFoundError("short url", "abc"))
+
+	w := serveErrorRequest(r, "abc-123")
+
+	var body map[string]any
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusNotFound || body["message"] != "short url abc not found" || body["requestId"] != "abc-123" {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+	if _, ok := body["code"]; ok {
+		t.Errorf("legacy format should not respond problem members, got %s", w.Body.String())
+	}
+}
+
+func TestRecoveryLogPanic(t *testing.T) {
+	buf := captureLogs(t)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.RequestID(), middlewares.Recovery(myerror.ERROR_FORMAT_PROBLEM))
+	r.GET("/", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	w := serveErrorRequest(r, "abc-123")
+
+	if w.Code != http.StatusInternalServerError || !bytes.Contains(w.Body.Bytes(), []byte("abc-123")) {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+	if bytes.Contains(w.Body.Bytes(), []byte("boom")) {
+		t.Errorf("panic value should be hidden from clients, got %s", w.Body.String())
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"msg":"panic recovered"`)) || !bytes.Contains(buf.Bytes(), []byte(`"error":"boom"`)) {
+		t.Errorf("panic is not logged, got %s", buf.String())
+	}
+}
+
+// captureLogs redirects the default logger to the returned buffer until the test ends.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logger.New(&buf, "debug"))
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+	})
+	return &buf
+}
+
+func createErrorRouter(format myerror.ErrorFormat, err error) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.RequestID(), middlewares.ErrorHandler(format))
+	r.GET("/", func(c *gin.Context) {
+		c.Error(err)
+	})
+	return r
+}
+
+func serveErrorRequest(r *gin.Engine, requestID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(middlewares.REQUEST_ID_HEADER, requestID)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
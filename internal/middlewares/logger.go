@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+	"github.com/gin-gonic/gin"
+)
+
+// Logger logs every request as a JSON line, it replaces the text logger of gin.
+// Only the path is logged, since the query string may hold secrets.
+func Logger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		slog.InfoContext(c.Request.Context(), "request",
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", c.Writer.Status()),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("size", c.Writer.Size()),
+		)
+	}
+}
+
+// Recovery logs panics with the stack trace and responds 500 in the format, it replaces the recovery of gin.
+func Recovery(format myerror.ErrorFormat) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, err any) {
+		slog.ErrorContext(c.Request.Context(), "panic recovered", slog.Any("error", err), slog.String("stack", string(debug.Stack())))
+		respondError(c, format, fmt.Errorf("panic: %v", err))
+		c.Abort()
+	})
+}
@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// UNMATCHED_ROUTE labels requests which match no route, so unknown paths do not create new series.
+const UNMATCHED_ROUTE = "unmatched"
+
+// Metrics counts requests and records their latency by route pattern, e.g. "/:url", instead of the path.
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = UNMATCHED_ROUTE
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		m.HTTPRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		m.HTTPRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,36 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/WeiAnAn/url-shortener/internal/metrics"
+	"github.com/WeiAnAn/url-shortener/internal/middlewares"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsLabelRequestsByRoutePattern(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := metrics.New()
+	r := gin.New()
+	r.Use(middlewares.Metrics(m))
+	r.GET("/:url", func(c *gin.Context) {
+		c.Status(http.StatusFound)
+	})
+
+	for _, path := range []string{"/abc", "/def", "/abc/def"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if count := testutil.ToFloat64(m.HTTPRequests.WithLabelValues(http.MethodGet, "/:url", "302")); count != 2 {
+		t.Errorf("expected 2 requests of /:url, got %v", count)
+	}
+	if count := testutil.ToFloat64(m.HTTPRequests.WithLabelValues(http.MethodGet, middlewares.UNMATCHED_ROUTE, "404")); count != 1 {
+		t.Errorf("expected 1 unmatched request, got %v", count)
+	}
+	if count := testutil.CollectAndCount(m.HTTPRequestDuration); count != 2 {
+		t.Errorf("expected 2 latency series, got %d", count)
+	}
+}
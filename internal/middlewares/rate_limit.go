@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	apikey "github.com/WeiAnAn/url-shortener/internal/domain/api_key"
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+	"github.com/WeiAnAn/url-shortener/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit limits requests per API key, or per client IP if the request is not authenticated.
+// name separates the budgets of different routes. Requests are allowed if the limiter fails.
+func RateLimit(limiter ratelimit.Limiter, name string, limit int, window time.Duration) gin.HandlerFunc {
+	return RateLimitN(limiter, name, limit, window, func(c *gin.Context) int { return 1 })
+}
+
+// RateLimitN is RateLimit charging cost of the request to the budget, e.g. the number of items of a batch.
+// A request costing more than the whole limit can never be allowed, so it is rejected as invalid instead
+// of being told to retry.
+func RateLimitN(limiter ratelimit.Limiter, name string, limit int, window time.Duration, cost func(c *gin.Context) int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		n := cost(c)
+		if n > limit {
+			c.Error(myerror.NewValidationError("body", strconv.Itoa(n),
+				fmt.Sprintf("the request takes %d of the rate limit, which allows %d per %s", n, limit, window)))
+			c.Abort()
+			return
+		}
+
+		key := "ip:" + c.ClientIP()
+		if ownerID := apikey.OwnerID(c); ownerID != "" {
+			key = "key:" + ownerID
+		}
+
+		result, err := limiter.AllowN(c, name+":"+key, n, limit, window)
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "rate limit failed, allowing request", slog.Any("error", err))
+			c.Next()
+			return
+		}
+
+		resetSecond := strconv.Itoa(int(math.Ceil(result.ResetAfter.Seconds())))
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", resetSecond)
+
+		if !result.Allowed {
+			c.Header("Retry-After", resetSecond)
+			c.Error(myerror.NewRateLimitError(result.ResetAfter))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,125 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	apikey "github.com/WeiAnAn/url-shortener/internal/domain/api_key"
+	"github.com/WeiAnAn/url-shortener/internal/middlewares"
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+	"github.com/WeiAnAn/url-shortener/internal/ratelimit"
+	"github.com/WeiAnAn/url-shortener/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitResponseTooManyRequestsWithHeaders(t *testing.T) {
+	r := createRateLimitRouter("")
+
+	w := serveRateLimitRequest(r, "192.0.2.1:1234")
+	if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "1" || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("unexpected response %d %v", w.Code, w.Header())
+	}
+
+	w = serveRateLimitRequest(r, "192.0.2.1:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("unexpected status code %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "60" || w.Header().Get("X-RateLimit-Reset") != "60" {
+		t.Errorf("unexpected headers %v", w.Header())
+	}
+}
+
+func TestRateLimitKeyedByClientIP(t *testing.T) {
+	r := createRateLimitRouter("")
+
+	serveRateLimitRequest(r, "192.0.2.1:1234")
+	w := serveRateLimitRequest(r, "192.0.2.2:1234")
+	if w.Code != http.StatusOK {
+		t.Errorf("unexpected status code %d", w.Code)
+	}
+}
+
+func TestRateLimitKeyedByAPIKeyOwner(t *testing.T) {
+	r := createRateLimitRouter("owner")
+
+	serveRateLimitRequest(r, "192.0.2.1:1234")
+	w := serveRateLimitRequest(r, "192.0.2.2:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("unexpected status code %d", w.Code)
+	}
+}
+
+func TestRateLimitNChargeCostOfRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.ErrorHandler(myerror.ERROR_FORMAT_PROBLEM))
+	limiter := ratelimit.NewMemoryLimiter(&utils.RealTime{})
+	cost := func(c *gin.Context) int {
+		n, _ := strconv.Atoi(c.Query("n"))
+		return n
+	}
+	r.GET("/", middlewares.RateLimitN(limiter, "test", 5, time.Minute, cost), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, test := range []struct {
+		n         string
+		status    int
+		remaining string
+	}{
+		{"3", http.StatusOK, "2"},
+		{"3", http.StatusTooManyRequests, "2"},
+		{"2", http.StatusOK, "0"},
+		{"6", http.StatusBadRequest, ""},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?n="+test.n, nil))
+		if w.Code != test.status || w.Header().Get("X-RateLimit-Remaining") != test.remaining {
+			t.Errorf("cost %s expect %d with remaining %s, got %d %v", test.n, test.status, test.remaining, w.Code, w.Header())
+		}
+	}
+}
+
+func TestRateLimitNRejectRequestCostingMoreThanLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.ErrorHandler(myerror.ERROR_FORMAT_PROBLEM))
+	limiter := ratelimit.NewMemoryLimiter(&utils.RealTime{})
+	r.GET("/", middlewares.RateLimitN(limiter, "test", 5, time.Minute, func(c *gin.Context) int { return 6 }), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusBadRequest || w.Header().Get("Retry-After") != "" || !strings.Contains(w.Body.String(), "allows 5 per 1m0s") {
+		t.Errorf("expect the limit to be named without Retry-After, got %d %v %s", w.Code, w.Header(), w.Body.String())
+	}
+}
+
+func createRateLimitRouter(ownerID string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.ErrorHandler(myerror.ERROR_FORMAT_PROBLEM))
+	r.Use(func(c *gin.Context) {
+		if ownerID != "" {
+			c.Set(apikey.OWNER_ID_KEY, ownerID)
+		}
+	})
+	limiter := ratelimit.NewMemoryLimiter(&utils.RealTime{})
+	r.GET("/", middlewares.RateLimit(limiter, "test", 1, time.Minute), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func serveRateLimitRequest(r *gin.Engine, remoteAddr string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	r.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"regexp"
+
+	"github.com/WeiAnAn/url-shortener/internal/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const REQUEST_ID_HEADER = "X-Request-ID"
+
+// requestIDPattern limits the request ids taken from clients, so they can not inject arbitrary text into logs.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID propagates the X-Request-ID header of the request, or generates one if it is missing or invalid.
+// The request id is sent back in the response header and attached to the request context.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(REQUEST_ID_HEADER)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Header(REQUEST_ID_HEADER, requestID)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
+		c.Next()
+	}
+}
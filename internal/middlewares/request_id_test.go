@@ -0,0 +1,54 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/WeiAnAn/url-shortener/internal/logger"
+	"github.com/WeiAnAn/url-shortener/internal/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestIDPropagateHeader(t *testing.T) {
+	r, requestID := createRequestIDRouter()
+
+	w := serveRequestIDRequest(r, "abc-123")
+	if w.Header().Get(middlewares.REQUEST_ID_HEADER) != "abc-123" || *requestID != "abc-123" {
+		t.Errorf("expected request id abc-123, got header %s and context %s", w.Header().Get(middlewares.REQUEST_ID_HEADER), *requestID)
+	}
+}
+
+func TestRequestIDGenerateIfMissingOrInvalid(t *testing.T) {
+	r, requestID := createRequestIDRouter()
+
+	for _, header := range []string{"", "bad id\nwith newline", strings.Repeat("a", 129)} {
+		w := serveRequestIDRequest(r, header)
+		generated := w.Header().Get(middlewares.REQUEST_ID_HEADER)
+		if generated == "" || generated == header || *requestID != generated {
+			t.Errorf("expected generated request id for %q, got header %s and context %s", header, generated, *requestID)
+		}
+	}
+}
+
+func createRequestIDRouter() (*gin.Engine, *string) {
+	gin.SetMode(gin.TestMode)
+	var requestID string
+	r := gin.New()
+	r.Use(middlewares.RequestID())
+	r.GET("/", func(c *gin.Context) {
+		requestID = logger.RequestID(c.Request.Context())
+	})
+	return r, &requestID
+}
+
+func serveRequestIDRequest(r *gin.Engine, requestID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if requestID != "" {
+		req.Header.Set(middlewares.REQUEST_ID_HEADER, requestID)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,66 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/WeiAnAn/url-shortener/internal/middlewares"
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+	"github.com/WeiAnAn/url-shortener/internal/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingExposeRequestSpanThroughGinContext(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	r := createTracingRouter(tp, func(c *gin.Context) {
+		_, span := tp.Tracer(tracing.TRACER_NAME).Start(context.Context(c), "child")
+		span.End()
+		c.Status(http.StatusOK)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abc", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 || spans[0].Name != "child" || spans[1].Name != "GET /:url" {
+		t.Fatalf("unexpected spans %v", spans)
+	}
+	if spans[0].Parent.SpanID() != spans[1].SpanContext.SpanID() {
+		t.Error("child span is not under the request span")
+	}
+	if spans[1].SpanKind != trace.SpanKindServer || spans[1].Parent.IsValid() {
+		t.Errorf("expected root server span, got %v with parent %v", spans[1].SpanKind, spans[1].Parent)
+	}
+}
+
+func TestTracingMarkServerErrors(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	r := createTracingRouter(tp, func(c *gin.Context) {
+		c.Error(errors.New("connection refused"))
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abc", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Status.Code != codes.Error || len(spans[0].Events) != 1 {
+		t.Errorf("expected span with error status and event, got %+v", spans)
+	}
+}
+
+func createTracingRouter(tp trace.TracerProvider, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.ContextWithFallback = true
+	r.Use(middlewares.Tracing(tp))
+	r.Use(middlewares.ErrorHandler(myerror.ERROR_FORMAT_PROBLEM))
+	r.GET("/:url", handler)
+	return r
+}
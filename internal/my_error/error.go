@@ -1,6 +1,31 @@
 package myerror
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+// Machine readable codes of the errors, clients can rely on them not changing.
+const (
+	CODE_VALIDATION_FAILED    = "validation_failed"
+	CODE_URL_BLOCKED          = "url_blocked"
+	CODE_CONFLICT             = "conflict"
+	CODE_NOT_FOUND            = "not_found"
+	CODE_UNAUTHORIZED         = "unauthorized"
+	CODE_PAYLOAD_TOO_LARGE    = "payload_too_large"
+	CODE_RATE_LIMITED         = "rate_limited"
+	CODE_UPSTREAM_UNAVAILABLE = "upstream_unavailable"
+	CODE_INTERNAL_ERROR       = "internal_error"
+)
+
+// Error is implemented by the errors which are responded to clients.
+type Error interface {
+	error
+	Status() int
+	Code() string
+}
 
 type ValidationError struct {
 	Field   string
@@ -12,6 +37,165 @@ func (e *ValidationError) Error() string {
 	return fmt.Sprintf("Validation failed on %s with value %s. %s", e.Field, e.Value, e.Message)
 }
 
+func (e *ValidationError) Status() int {
+	return http.StatusBadRequest
+}
+
+func (e *ValidationError) Code() string {
+	return CODE_VALIDATION_FAILED
+}
+
 func NewValidationError(f, v, m string) *ValidationError {
 	return &ValidationError{f, v, m}
 }
+
+// BlockedURLError rejects an original url which is screened as unsafe, such as a phishing domain.
+type BlockedURLError struct {
+	URL    string
+	Reason string
+}
+
+func (e *BlockedURLError) Error() string {
+	return fmt.Sprintf("URL %s is blocked. %s", e.URL, e.Reason)
+}
+
+func (e *BlockedURLError) Status() int {
+	return http.StatusBadRequest
+}
+
+func (e *BlockedURLError) Code() string {
+	return CODE_URL_BLOCKED
+}
+
+func NewBlockedURLError(url, reason string) *BlockedURLError {
+	return &BlockedURLError{url, reason}
+}
+
+type ConflictError struct {
+	Field   string
+	Value   string
+	Message string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("Conflict on %s with value %s. %s", e.Field, e.Value, e.Message)
+}
+
+func (e *ConflictError) Status() int {
+	return http.StatusConflict
+}
+
+func (e *ConflictError) Code() string {
+	return CODE_CONFLICT
+}
+
+func NewConflictError(f, v, m string) *ConflictError {
+	return &ConflictError{f, v, m}
+}
+
+type NotFoundError struct {
+	Resource string
+	ID       string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %s not found", e.Resource, e.ID)
+}
+
+func (e *NotFoundError) Status() int {
+	return http.StatusNotFound
+}
+
+func (e *NotFoundError) Code() string {
+	return CODE_NOT_FOUND
+}
+
+func NewNotFoundError(r, id string) *NotFoundError {
+	return &NotFoundError{r, id}
+}
+
+type UnauthorizedError struct {
+	Message string
+}
+
+func (e *UnauthorizedError) Error() string {
+	return e.Message
+}
+
+func (e *UnauthorizedError) Status() int {
+	return http.StatusUnauthorized
+}
+
+func (e *UnauthorizedError) Code() string {
+	return CODE_UNAUTHORIZED
+}
+
+func NewUnauthorizedError(m string) *UnauthorizedError {
+	return &UnauthorizedError{m}
+}
+
+// PayloadTooLargeError rejects a request body which is larger than Limit bytes.
+type PayloadTooLargeError struct {
+	Limit int64
+}
+
+func (e *PayloadTooLargeError) Error() string {
+	return fmt.Sprintf("Request body is larger than %d bytes", e.Limit)
+}
+
+func (e *PayloadTooLargeError) Status() int {
+	return http.StatusRequestEntityTooLarge
+}
+
+func (e *PayloadTooLargeError) Code() string {
+	return CODE_PAYLOAD_TOO_LARGE
+}
+
+func NewPayloadTooLargeError(limit int64) *PayloadTooLargeError {
+	return &PayloadTooLargeError{limit}
+}
+
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("Rate limit exceeded. Retry after %d seconds", int(math.Ceil(e.RetryAfter.Seconds())))
+}
+
+func (e *RateLimitError) Status() int {
+	return http.StatusTooManyRequests
+}
+
+func (e *RateLimitError) Code() string {
+	return CODE_RATE_LIMITED
+}
+
+func NewRateLimitError(retryAfter time.Duration) *RateLimitError {
+	return &RateLimitError{retryAfter}
+}
+
+// UpstreamUnavailableError hides the error of a dependency, such as a database, which fails or times out.
+type UpstreamUnavailableError struct {
+	Err error
+}
+
+func (e *UpstreamUnavailableError) Error() string {
+	return "Service is temporarily unavailable"
+}
+
+func (e *UpstreamUnavailableError) Unwrap() error {
+	return e.Err
+}
+
+func (e *UpstreamUnavailableError) Status() int {
+	return http.StatusServiceUnavailable
+}
+
+func (e *UpstreamUnavailableError) Code() string {
+	return CODE_UPSTREAM_UNAVAILABLE
+}
+
+func NewUpstreamUnavailableError(err error) *UpstreamUnavailableError {
+	return &UpstreamUnavailableError{err}
+}
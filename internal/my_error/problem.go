@@ -0,0 +1,132 @@
+package myerror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// PROBLEM_CONTENT_TYPE is the media type of RFC 7807 problem details.
+const PROBLEM_CONTENT_TYPE = "application/problem+json"
+
+// PROBLEM_TYPE_PREFIX is followed by the code to form the problem type URI.
+const PROBLEM_TYPE_PREFIX = "urn:url-shortener:problem:"
+
+// ErrorFormat selects how errors are responded.
+type ErrorFormat string
+
+const (
+	// ERROR_FORMAT_PROBLEM responds application/problem+json.
+	ERROR_FORMAT_PROBLEM ErrorFormat = "problem"
+	// ERROR_FORMAT_LEGACY responds {"message": ...} for clients written before the problem details.
+	ERROR_FORMAT_LEGACY ErrorFormat = "legacy"
+)
+
+var titles = map[string]string{
+	CODE_VALIDATION_FAILED:    "Validation failed",
+	CODE_URL_BLOCKED:          "URL blocked",
+	CODE_CONFLICT:             "Conflict",
+	CODE_NOT_FOUND:            "Not found",
+	CODE_UNAUTHORIZED:         "Unauthorized",
+	CODE_PAYLOAD_TOO_LARGE:    "Payload too large",
+	CODE_RATE_LIMITED:         "Rate limit exceeded",
+	CODE_UPSTREAM_UNAVAILABLE: "Service unavailable",
+	CODE_INTERNAL_ERROR:       "Internal server error",
+}
+
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Problem is the RFC 7807 problem details of an error, with the code, the request id and the invalid
+// params as extension members.
+type Problem struct {
+	Type          string          `json:"type"`
+	Title         string          `json:"title"`
+	Status        int             `json:"status"`
+	Detail        string          `json:"detail,omitempty"`
+	Instance      string          `json:"instance,omitempty"`
+	Code          string          `json:"code"`
+	RequestID     string          `json:"requestId,omitempty"`
+	InvalidParams []*InvalidParam `json:"invalidParams,omitempty"`
+}
+
+// NewProblem describes the error, unknown errors are hidden behind CODE_INTERNAL_ERROR.
+func NewProblem(err error) *Problem {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		invalidParams := make([]*InvalidParam, len(validationErrs))
+		for i, fieldErr := range validationErrs {
+			invalidParams[i] = &InvalidParam{Name: fieldErr.Field(), Reason: reason(fieldErr)}
+		}
+		problem := newProblem(http.StatusBadRequest, CODE_VALIDATION_FAILED, "The request has invalid params")
+		problem.InvalidParams = invalidParams
+		return problem
+	}
+
+	myErr := asError(err)
+	if myErr == nil {
+		return newProblem(http.StatusInternalServerError, CODE_INTERNAL_ERROR, "")
+	}
+
+	problem := newProblem(myErr.Status(), myErr.Code(), myErr.Error())
+	var validationErr *ValidationError
+	if errors.As(myErr, &validationErr) {
+		problem.InvalidParams = []*InvalidParam{{Name: validationErr.Field, Reason: validationErr.Message}}
+	}
+	var blockedErr *BlockedURLError
+	if errors.As(myErr, &blockedErr) {
+		problem.InvalidParams = []*InvalidParam{{Name: "url", Reason: blockedErr.Reason}}
+	}
+	return problem
+}
+
+func newProblem(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   PROBLEM_TYPE_PREFIX + code,
+		Title:  titles[code],
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func reason(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "url":
+		return "must be a URL"
+	case "gt":
+		if fieldErr.Kind() == reflect.Struct {
+			return "must be in the future"
+		}
+		return fmt.Sprintf("must be greater than %s", fieldErr.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", strings.Join(strings.Fields(fieldErr.Param()), ", "))
+	default:
+		return fmt.Sprintf("failed on the %s rule", fieldErr.Tag())
+	}
+}
+
+// RegisterFieldNames makes validation errors name the fields as clients send them, by their json,
+// uri or form tag instead of the struct field name.
+func RegisterFieldNames(v *validator.Validate) {
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "uri", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+}
@@ -0,0 +1,45 @@
+package myerror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// StatusAndMessage maps the error to the http status code and the message responded to clients.
+// Unknown errors are hidden behind "Internal server error".
+func StatusAndMessage(err error) (int, string) {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		errorMessage := make([]string, len(validationErrs))
+		for i, fieldErr := range validationErrs {
+			errorMessage[i] = fmt.Sprintf("on %s with %v", fieldErr.Field(), fieldErr.Value())
+		}
+		return http.StatusBadRequest, fmt.Sprintf("Validation errors: %s", strings.Join(errorMessage, ", "))
+	}
+
+	if myErr := asError(err); myErr != nil {
+		return myErr.Status(), myErr.Error()
+	}
+	return http.StatusInternalServerError, "Internal server error"
+}
+
+// asError finds the Error in the chain of err. Timeouts and network errors of dependencies are
+// UpstreamUnavailableError, other unknown errors are nil.
+func asError(err error) Error {
+	var myErr Error
+	if errors.As(err, &myErr) {
+		return myErr
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		return NewUpstreamUnavailableError(err)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limiter counts requests of a key in fixed windows. AllowN consumes n of the limit at once, e.g. for the
+// items of a batch; a rejected request consumes nothing, so it can be retried with a smaller n.
+type Limiter interface {
+	AllowN(c context.Context, key string, n, limit int, window time.Duration) (*Result, error)
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+}
+
+// newResult reports the count of the window after the request is allowed or rejected.
+func newResult(allowed bool, count int64, limit int, resetAfter time.Duration) *Result {
+	remaining := limit - int(count)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &Result{
+		Allowed:    allowed,
+		Limit:      limit,
+		Remaining:  remaining,
+		ResetAfter: resetAfter,
+	}
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/utils"
+)
+
+// SWEEP_SIZE is the number of keys which triggers removing expired windows.
+const SWEEP_SIZE = 10000
+
+type window struct {
+	count   int64
+	resetAt time.Time
+}
+
+// MemoryLimiter keeps the counters in process, it is only suitable for single node setups.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	windows map[string]*window
+	time    utils.TimeUtil
+}
+
+func NewMemoryLimiter(t utils.TimeUtil) *MemoryLimiter {
+	return &MemoryLimiter{windows: make(map[string]*window), time: t}
+}
+
+func (m *MemoryLimiter) AllowN(c context.Context, key string, n, limit int, d time.Duration) (*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.time.Now()
+	if len(m.windows) >= SWEEP_SIZE {
+		m.sweep(now)
+	}
+
+	w, ok := m.windows[key]
+	if !ok || !now.Before(w.resetAt) {
+		w = &window{resetAt: now.Add(d)}
+		m.windows[key] = w
+	}
+	allowed := w.count+int64(n) <= int64(limit)
+	if allowed {
+		w.count += int64(n)
+	}
+
+	return newResult(allowed, w.count, limit, w.resetAt.Sub(now)), nil
+}
+
+func (m *MemoryLimiter) sweep(now time.Time) {
+	for key, w := range m.windows {
+		if !now.Before(w.resetAt) {
+			delete(m.windows, key)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/ratelimit"
+	mock_utils "github.com/WeiAnAn/url-shortener/internal/utils/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+func TestMemoryLimiterRejectRequestsOverLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	tu := mock_utils.NewMockTimeUtil(ctrl)
+	limiter := ratelimit.NewMemoryLimiter(tu)
+
+	now := time.Now()
+	tu.EXPECT().Now().Return(now).Times(3)
+	c := context.Background()
+
+	for i := 1; i <= 2; i++ {
+		result, err := limiter.AllowN(c, "key", 1, 2, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed || result.Remaining != 2-i || result.Limit != 2 || result.ResetAfter != time.Minute {
+			t.Errorf("unexpected result %+v", result)
+		}
+	}
+
+	result, _ := limiter.AllowN(c, "key", 1, 2, time.Minute)
+	if result.Allowed || result.Remaining != 0 {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestMemoryLimiterResetAfterWindow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	tu := mock_utils.NewMockTimeUtil(ctrl)
+	limiter := ratelimit.NewMemoryLimiter(tu)
+
+	now := time.Now()
+	c := context.Background()
+	gomock.InOrder(
+		tu.EXPECT().Now().Return(now),
+		tu.EXPECT().Now().Return(now.Add(30*time.Second)),
+		tu.EXPECT().Now().Return(now.Add(time.Minute)),
+	)
+
+	limiter.AllowN(c, "key", 1, 1, time.Minute)
+	result, _ := limiter.AllowN(c, "key", 1, 1, time.Minute)
+	if result.Allowed || result.ResetAfter != 30*time.Second {
+		t.Errorf("unexpected result %+v", result)
+	}
+	result, _ = limiter.AllowN(c, "key", 1, 1, time.Minute)
+	if !result.Allowed {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestMemoryLimiterCountKeysSeparately(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	tu := mock_utils.NewMockTimeUtil(ctrl)
+	limiter := ratelimit.NewMemoryLimiter(tu)
+
+	tu.EXPECT().Now().Return(time.Now()).AnyTimes()
+	c := context.Background()
+
+	limiter.AllowN(c, "a", 1, 1, time.Minute)
+	result, _ := limiter.AllowN(c, "b", 1, 1, time.Minute)
+	if !result.Allowed {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestMemoryLimiterAllowNRejectWithoutConsuming(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	tu := mock_utils.NewMockTimeUtil(ctrl)
+	limiter := ratelimit.NewMemoryLimiter(tu)
+
+	tu.EXPECT().Now().Return(time.Now()).AnyTimes()
+	c := context.Background()
+
+	result, _ := limiter.AllowN(c, "key", 3, 5, time.Minute)
+	if !result.Allowed || result.Remaining != 2 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	result, _ = limiter.AllowN(c, "key", 3, 5, time.Minute)
+	if result.Allowed || result.Remaining != 2 {
+		t.Errorf("expect the request over the remaining limit to be rejected, got %+v", result)
+	}
+	result, _ = limiter.AllowN(c, "key", 2, 5, time.Minute)
+	if !result.Allowed || result.Remaining != 0 {
+		t.Errorf("expect the rejected request not to consume the limit, got %+v", result)
+	}
+}
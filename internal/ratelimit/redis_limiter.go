@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+const KEY_PREFIX = "ratelimit:"
+
+// incrScript increases the counter by ARGV[2] if it does not exceed the limit ARGV[3]. It returns whether
+// the request is allowed, the count and the remaining milliseconds of the window. The window starts on the
+// first request, and also if the key somehow has no expiry, otherwise the counter would never reset.
+var incrScript = rueidis.NewLuaScript(`
+local n = tonumber(ARGV[2])
+local count = tonumber(redis.call("GET", KEYS[1]) or "0")
+local allowed = 0
+if count + n <= tonumber(ARGV[3]) then
+	count = redis.call("INCRBY", KEYS[1], n)
+	allowed = 1
+end
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+	if count > 0 then
+		redis.call("PEXPIRE", KEYS[1], ARGV[1])
+	end
+	ttl = tonumber(ARGV[1])
+end
+return {allowed, count, ttl}
+`)
+
+// RedisLimiter shares the counters across replicas.
+type RedisLimiter struct {
+	client rueidis.Client
+}
+
+func NewRedisLimiter(client rueidis.Client) *RedisLimiter {
+	return &RedisLimiter{client}
+}
+
+func (r *RedisLimiter) AllowN(c context.Context, key string, n, limit int, window time.Duration) (*Result, error) {
+	args := []string{strconv.FormatInt(window.Milliseconds(), 10), strconv.Itoa(n), strconv.Itoa(limit)}
+	v, err := incrScript.Exec(c, r.client, []string{KEY_PREFIX + key}, args).AsIntSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	return newResult(v[0] == 1, v[1], limit, time.Duration(v[2])*time.Millisecond), nil
+}
@@ -0,0 +1,111 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/WeiAnAn/url-shortener/internal/ratelimit"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/rueidis"
+)
+
+func TestRedisLimiterRejectRequestsOverLimit(t *testing.T) {
+	_, limiter := createRedisLimiter(t)
+	c := context.Background()
+
+	for i := 1; i <= 2; i++ {
+		result, err := limiter.AllowN(c, "key", 1, 2, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed || result.Remaining != 2-i || result.Limit != 2 || result.ResetAfter != time.Minute {
+			t.Errorf("unexpected result %+v", result)
+		}
+	}
+
+	result, _ := limiter.AllowN(c, "key", 1, 2, time.Minute)
+	if result.Allowed || result.Remaining != 0 || result.ResetAfter != time.Minute {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestRedisLimiterResetAfterWindow(t *testing.T) {
+	m, limiter := createRedisLimiter(t)
+	c := context.Background()
+
+	limiter.AllowN(c, "key", 1, 1, time.Minute)
+	m.FastForward(30 * time.Second)
+	result, _ := limiter.AllowN(c, "key", 1, 1, time.Minute)
+	if result.Allowed || result.ResetAfter != 30*time.Second {
+		t.Errorf("unexpected result %+v", result)
+	}
+	m.FastForward(30 * time.Second)
+	result, _ = limiter.AllowN(c, "key", 1, 1, time.Minute)
+	if !result.Allowed {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestRedisLimiterAllowNRejectWithoutConsuming(t *testing.T) {
+	_, limiter := createRedisLimiter(t)
+	c := context.Background()
+
+	result, _ := limiter.AllowN(c, "key", 6, 5, time.Minute)
+	if result.Allowed || result.Remaining != 5 || result.ResetAfter != time.Minute {
+		t.Errorf("expect the request over the limit to be rejected, got %+v", result)
+	}
+	result, _ = limiter.AllowN(c, "key", 3, 5, time.Minute)
+	if !result.Allowed || result.Remaining != 2 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	result, _ = limiter.AllowN(c, "key", 3, 5, time.Minute)
+	if result.Allowed || result.Remaining != 2 {
+		t.Errorf("expect the request over the remaining limit to be rejected, got %+v", result)
+	}
+	result, _ = limiter.AllowN(c, "key", 2, 5, time.Minute)
+	if !result.Allowed || result.Remaining != 0 {
+		t.Errorf("expect the rejected request not to consume the limit, got %+v", result)
+	}
+}
+
+func TestRedisLimiterStartWindowOfKeyWithoutExpiry(t *testing.T) {
+	m, limiter := createRedisLimiter(t)
+	c := context.Background()
+	m.Set(ratelimit.KEY_PREFIX+"key", "1")
+
+	result, err := limiter.AllowN(c, "key", 1, 1, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed || result.ResetAfter != time.Minute || m.TTL(ratelimit.KEY_PREFIX+"key") != time.Minute {
+		t.Errorf("expect the window to be started, got %+v ttl %v", result, m.TTL(ratelimit.KEY_PREFIX+"key"))
+	}
+
+	m.FastForward(time.Minute)
+	result, _ = limiter.AllowN(c, "key", 1, 1, time.Minute)
+	if !result.Allowed {
+		t.Errorf("expect the window to be reset, got %+v", result)
+	}
+}
+
+func TestRedisLimiterCountKeysSeparately(t *testing.T) {
+	_, limiter := createRedisLimiter(t)
+	c := context.Background()
+
+	limiter.AllowN(c, "a", 1, 1, time.Minute)
+	result, _ := limiter.AllowN(c, "b", 1, 1, time.Minute)
+	if !result.Allowed {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func createRedisLimiter(t *testing.T) (*miniredis.Miniredis, *ratelimit.RedisLimiter) {
+	m := miniredis.RunT(t)
+	client, err := rueidis.NewClient(rueidis.ClientOption{InitAddress: []string{m.Addr()}, DisableCache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return m, ratelimit.NewRedisLimiter(client)
+}
@@ -0,0 +1,95 @@
+package screener
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// List matches urls by domains and regular expressions. Each line of a list file is one of
+//
+//	# a comment, blank lines are ignored as well
+//	example.com      the domain and all its subdomains
+//	/^https?://[^/]*\.example\.net/login/    a regular expression matched against the whole url
+type List struct {
+	domains  map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// ParseList reads a list file, it returns an error with the line number of an invalid entry.
+func ParseList(r io.Reader) (*List, error) {
+	l := &List{domains: map[string]bool{}}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if len(line) > 2 && strings.HasPrefix(line, "/") && strings.HasSuffix(line, "/") {
+			pattern, err := regexp.Compile(line[1 : len(line)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			l.patterns = append(l.patterns, pattern)
+			continue
+		}
+
+		domain, err := parseDomain(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid domain %q: %w", n, line, err)
+		}
+		l.domains[domain] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Match returns the matched entry, or an empty string if the url matches none.
+func (l *List) Match(rawURL string) string {
+	host := hostname(rawURL)
+	for domain := host; domain != ""; {
+		if l.domains[domain] {
+			return domain
+		}
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok {
+			break
+		}
+		domain = parent
+	}
+
+	for _, pattern := range l.patterns {
+		if pattern.MatchString(rawURL) {
+			return "/" + pattern.String() + "/"
+		}
+	}
+	return ""
+}
+
+// Len returns the number of entries.
+func (l *List) Len() int {
+	return len(l.domains) + len(l.patterns)
+}
+
+// parseDomain lowercases the domain and converts an internationalized domain to punycode,
+// the same as the urls normalized by urlnorm.
+func parseDomain(line string) (string, error) {
+	domain := strings.TrimSuffix(strings.ToLower(line), ".")
+	if strings.ContainsAny(domain, "/:?#@ \t") {
+		return "", errors.New("must be a domain name")
+	}
+	for i := 0; i < len(domain); i++ {
+		if domain[i] >= 0x80 {
+			return idna.Lookup.ToASCII(domain)
+		}
+	}
+	return domain, nil
+}
@@ -0,0 +1,122 @@
+package screener
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+)
+
+// ListScreener screens urls by a list file, which is reloaded when it changes.
+// A blocklist blocks the matched urls, an allowlist blocks all urls but the matched ones.
+type ListScreener struct {
+	path      string
+	allowlist bool
+
+	mu      sync.RWMutex
+	list    *List
+	modTime time.Time
+	size    int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewBlocklistScreener loads the list file, it returns an error if the file can not be read or is invalid.
+func NewBlocklistScreener(path string) (*ListScreener, error) {
+	return newListScreener(path, false)
+}
+
+// NewAllowlistScreener loads the list file, it returns an error if the file can not be read or is invalid.
+func NewAllowlistScreener(path string) (*ListScreener, error) {
+	return newListScreener(path, true)
+}
+
+func newListScreener(path string, allowlist bool) (*ListScreener, error) {
+	s := &ListScreener{path: path, allowlist: allowlist, stop: make(chan struct{}), done: make(chan struct{})}
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *ListScreener) Screen(c context.Context, rawURL string) error {
+	s.mu.RLock()
+	matched := s.list.Match(rawURL)
+	s.mu.RUnlock()
+
+	switch {
+	case s.allowlist && matched == "":
+		return myerror.NewBlockedURLError(rawURL, "the domain is not in the allowlist")
+	case !s.allowlist && matched != "":
+		return myerror.NewBlockedURLError(rawURL, fmt.Sprintf("%s is in the blocklist", matched))
+	}
+	return nil
+}
+
+// Reload reads the list file again if its modification time or size changed, and reports whether it did.
+// The current list is kept if the file is invalid.
+func (s *ListScreener) Reload() (bool, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false, err
+	}
+	s.mu.RLock()
+	unchanged := s.list != nil && info.ModTime().Equal(s.modTime) && info.Size() == s.size
+	s.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	list, err := ParseList(f)
+	if err != nil {
+		return false, fmt.Errorf("parse %s: %w", s.path, err)
+	}
+
+	s.mu.Lock()
+	s.list, s.modTime, s.size = list, info.ModTime(), info.Size()
+	s.mu.Unlock()
+	return true, nil
+}
+
+// Start checks the list file for changes in the background every interval.
+func (s *ListScreener) Start(interval time.Duration) {
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+
+			reloaded, err := s.Reload()
+			if err != nil {
+				slog.Warn("reload url list failed, keeping the current list", slog.String("path", s.path), slog.Any("error", err))
+			} else if reloaded {
+				s.mu.RLock()
+				entries := s.list.Len()
+				s.mu.RUnlock()
+				slog.Info("url list reloaded", slog.String("path", s.path), slog.Int("entries", entries))
+			}
+		}
+	}()
+}
+
+// Close stops the reload started by Start.
+func (s *ListScreener) Close() {
+	close(s.stop)
+	<-s.done
+}
@@ -0,0 +1,135 @@
+package screener_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+	"github.com/WeiAnAn/url-shortener/internal/screener"
+)
+
+func writeList(t *testing.T, path, content string, modTime time.Time) {
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// the modification time may not change within the resolution of the file system
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func isBlocked(err error) bool {
+	_, ok := err.(*myerror.BlockedURLError)
+	return ok
+}
+
+func TestBlocklistScreener(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	writeList(t, path, "phishing.example\n", time.Now())
+	s, err := screener.NewBlocklistScreener(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := context.Background()
+	if err := s.Screen(c, "https://login.phishing.example/"); !isBlocked(err) {
+		t.Errorf("expect BlockedURLError, got %v", err)
+	}
+	if err := s.Screen(c, "https://example.com/"); err != nil {
+		t.Errorf("expect url not in the blocklist to be allowed, got %v", err)
+	}
+}
+
+func TestAllowlistScreener(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allowlist.txt")
+	writeList(t, path, "example.com\n", time.Now())
+	s, err := screener.NewAllowlistScreener(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := context.Background()
+	if err := s.Screen(c, "https://docs.example.com/"); err != nil {
+		t.Errorf("expect url in the allowlist to be allowed, got %v", err)
+	}
+	if err := s.Screen(c, "https://example.org/"); !isBlocked(err) {
+		t.Errorf("expect BlockedURLError, got %v", err)
+	}
+}
+
+func TestListScreenerReloadChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	modTime := time.Now().Add(-time.Hour)
+	writeList(t, path, "phishing.example\n", modTime)
+	s, err := screener.NewBlocklistScreener(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := s.Reload()
+	if err != nil || reloaded {
+		t.Errorf("expect unchanged file not to be reloaded, got %v %v", reloaded, err)
+	}
+
+	writeList(t, path, "malware.example\n", modTime.Add(time.Minute))
+	reloaded, err = s.Reload()
+	if err != nil || !reloaded {
+		t.Fatalf("expect changed file to be reloaded, got %v %v", reloaded, err)
+	}
+	c := context.Background()
+	if err := s.Screen(c, "https://malware.example/"); !isBlocked(err) {
+		t.Errorf("expect the new list to be used, got %v", err)
+	}
+	if err := s.Screen(c, "https://phishing.example/"); err != nil {
+		t.Errorf("expect the old list to be replaced, got %v", err)
+	}
+}
+
+func TestListScreenerKeepListIfFileIsInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	modTime := time.Now().Add(-time.Hour)
+	writeList(t, path, "phishing.example\n", modTime)
+	s, err := screener.NewBlocklistScreener(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeList(t, path, "/[/\n", modTime.Add(time.Minute))
+	if _, err := s.Reload(); err == nil {
+		t.Error("expect error of invalid list")
+	}
+	if err := s.Screen(context.Background(), "https://phishing.example/"); !isBlocked(err) {
+		t.Errorf("expect the current list to be kept, got %v", err)
+	}
+}
+
+func TestListScreenerStartReloadsInBackground(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	modTime := time.Now().Add(-time.Hour)
+	writeList(t, path, "phishing.example\n", modTime)
+	s, err := screener.NewBlocklistScreener(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start(10 * time.Millisecond)
+	defer s.Close()
+
+	writeList(t, path, "malware.example\n", modTime.Add(time.Minute))
+	deadline := time.Now().Add(time.Second)
+	for !isBlocked(s.Screen(context.Background(), "https://malware.example/")) {
+		if time.Now().After(deadline) {
+			t.Fatal("expect the list to be reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNewListScreenerReturnErrorOfMissingFile(t *testing.T) {
+	_, err := screener.NewBlocklistScreener(filepath.Join(t.TempDir(), "missing.txt"))
+	if err == nil {
+		t.Error("expect error of missing file")
+	}
+}
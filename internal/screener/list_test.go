@@ -0,0 +1,64 @@
+package screener_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/WeiAnAn/url-shortener/internal/screener"
+)
+
+const testList = `# phishing domains
+phishing.example
+Bad.Example.
+bücher.example
+
+/^https?://[^/]+/wp-login\.php/
+`
+
+func TestListMatch(t *testing.T) {
+	l, err := screener.ParseList(strings.NewReader(testList))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://phishing.example/login", "phishing.example"},
+		{"https://PHISHING.example:8443/", "phishing.example"},
+		{"https://phishing.example./", "phishing.example"},
+		{"https://www.phishing.example/", "phishing.example"},
+		{"https://a.b.phishing.example/", "phishing.example"},
+		{"https://bad.example/", "bad.example"},
+		{"https://xn--bcher-kva.example/", "xn--bcher-kva.example"},
+		{"https://bücher.example/", "xn--bcher-kva.example"},
+		{"https://notphishing.example/", ""},
+		{"https://phishing.example.com/", ""},
+		{"https://example.com/?next=https://phishing.example/", ""},
+		{"https://example.com/wp-login.php", `/^https?://[^/]+/wp-login\.php/`},
+		{"https://example.com/blog/wp-login.php", ""},
+		{"https://example.com/", ""},
+	}
+	for _, test := range tests {
+		if got := l.Match(test.url); got != test.want {
+			t.Errorf("match %s expect %q, got %q", test.url, test.want, got)
+		}
+	}
+	if l.Len() != 4 {
+		t.Errorf("expect 4 entries, got %d", l.Len())
+	}
+}
+
+func TestParseListReturnErrorOfInvalidLine(t *testing.T) {
+	for _, list := range []string{
+		"example.com\n/[/\n",
+		"https://example.com/\n",
+		"example.com:8080\n",
+	} {
+		_, err := screener.ParseList(strings.NewReader(list))
+		if err == nil {
+			t.Errorf("expect error of list %q", list)
+		}
+	}
+}
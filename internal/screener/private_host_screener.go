@@ -0,0 +1,131 @@
+package screener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	myerror "github.com/WeiAnAn/url-shortener/internal/my_error"
+)
+
+// Resolver looks up the addresses of a host, net.DefaultResolver implements it.
+type Resolver interface {
+	LookupNetIP(c context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// PrivateHostScreener blocks urls to loopback, private, link-local and unspecified addresses,
+// which would point the short urls of a public instance into the network of the visitor or the server.
+type PrivateHostScreener struct {
+	resolver Resolver
+}
+
+// NewPrivateHostScreener checks the IP addresses and localhost names in urls. With a resolver, domain
+// names are also resolved and blocked if any address is private; a failed lookup does not block the url.
+func NewPrivateHostScreener(r Resolver) *PrivateHostScreener {
+	return &PrivateHostScreener{r}
+}
+
+func (s *PrivateHostScreener) Screen(c context.Context, rawURL string) error {
+	host := hostname(rawURL)
+	if host == "" {
+		return nil
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return myerror.NewBlockedURLError(rawURL, "the host is a loopback address")
+	}
+
+	if addr, ok := parseAddr(host); ok {
+		return checkAddr(rawURL, addr)
+	}
+	if s.resolver == nil {
+		return nil
+	}
+
+	addrs, err := s.resolver.LookupNetIP(c, "ip", host)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) {
+			return nil
+		}
+		return err
+	}
+	for _, addr := range addrs {
+		if err := checkAddr(rawURL, addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseAddr parses the host as an IP address. Like browsers following the WHATWG URL standard, IPv4
+// addresses may have fewer than 4 parts, the last one filling the remaining bytes, and each part may be
+// hex with a 0x prefix or octal with a leading 0, so 127.1, 2130706433 and 0x7f.0.0.1 are all 127.0.0.1.
+func parseAddr(host string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return addr, true
+	}
+
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return netip.Addr{}, false
+	}
+	var ip uint64
+	for i, part := range parts {
+		n, ok := parseIPv4Number(part)
+		if !ok {
+			return netip.Addr{}, false
+		}
+		if i < len(parts)-1 {
+			if n > 255 {
+				return netip.Addr{}, false
+			}
+			ip |= n << (8 * (3 - i))
+			continue
+		}
+		if n >= 1<<(8*(4-i)) {
+			return netip.Addr{}, false
+		}
+		ip |= n
+	}
+	return netip.AddrFrom4([4]byte{byte(ip >> 24), byte(ip >> 16), byte(ip >> 8), byte(ip)}), true
+}
+
+func parseIPv4Number(part string) (uint64, bool) {
+	base := 10
+	switch {
+	case len(part) >= 2 && (part[:2] == "0x" || part[:2] == "0X"):
+		part, base = part[2:], 16
+		if part == "" {
+			return 0, true
+		}
+	case len(part) >= 2 && part[0] == '0':
+		part, base = part[1:], 8
+	}
+	if part == "" || part[0] == '+' || part[0] == '-' {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(part, base, 32)
+	return n, err == nil
+}
+
+func checkAddr(rawURL string, addr netip.Addr) error {
+	addr = addr.Unmap()
+	var kind string
+	switch {
+	case addr.IsLoopback():
+		kind = "a loopback"
+	case addr.IsPrivate():
+		kind = "a private"
+	case addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast():
+		kind = "a link-local"
+	case addr.IsUnspecified():
+		kind = "an unspecified"
+	default:
+		return nil
+	}
+	return myerror.NewBlockedURLError(rawURL, fmt.Sprintf("the host is %s address", kind))
+}
@@ -0,0 +1,103 @@
+package screener_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/WeiAnAn/url-shortener/internal/screener"
+)
+
+type fakeResolver map[string][]netip.Addr
+
+func (r fakeResolver) LookupNetIP(c context.Context, network, host string) ([]netip.Addr, error) {
+	addrs, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return addrs, nil
+}
+
+func TestPrivateHostScreener(t *testing.T) {
+	s := screener.NewPrivateHostScreener(nil)
+
+	tests := []struct {
+		url     string
+		blocked bool
+	}{
+		{"http://127.0.0.1/", true},
+		{"http://127.1.2.3:8080/", true},
+		{"http://10.0.0.1/", true},
+		{"http://172.16.0.1/", true},
+		{"http://192.168.1.1/admin", true},
+		{"http://169.254.169.254/latest/meta-data/", true},
+		{"http://0.0.0.0/", true},
+		{"http://[::1]/", true},
+		{"http://[fe80::1]/", true},
+		{"http://[fc00::1]/", true},
+		{"http://[::ffff:127.0.0.1]/", true},
+		{"http://127.1/", true},
+		{"http://2130706433/", true},
+		{"http://0x7f.0.0.1/", true},
+		{"http://0177.0.0.1/", true},
+		{"http://0x7F000001/", true},
+		{"http://10.1/", true},
+		{"http://192.168.257/", true},
+		{"http://0/", true},
+		{"http://localhost:8080/", true},
+		{"http://LOCALHOST./", true},
+		{"http://app.localhost/", true},
+		{"http://8.8.8.8/", false},
+		{"http://134744072/", false},
+		{"http://0x8.8.8.8/", false},
+		{"http://0x7f.example.com/", false},
+		{"http://1.2.3.4.5/", false},
+		{"http://[2001:4860:4860::8888]/", false},
+		{"https://example.com/", false},
+		{"https://localhost.example.com/", false},
+		{"https://intranet.example.com/", false},
+	}
+	for _, test := range tests {
+		err := s.Screen(context.Background(), test.url)
+		if isBlocked(err) != test.blocked {
+			t.Errorf("screen %s expect blocked %v, got %v", test.url, test.blocked, err)
+		}
+	}
+}
+
+func TestPrivateHostScreenerResolveHosts(t *testing.T) {
+	s := screener.NewPrivateHostScreener(fakeResolver{
+		"intranet.example.com": {netip.MustParseAddr("203.0.113.1"), netip.MustParseAddr("10.0.0.1")},
+		"example.com":          {netip.MustParseAddr("93.184.216.34")},
+	})
+
+	c := context.Background()
+	if err := s.Screen(c, "https://intranet.example.com/"); !isBlocked(err) {
+		t.Errorf("expect host resolved to a private address to be blocked, got %v", err)
+	}
+	if err := s.Screen(c, "https://example.com/"); err != nil {
+		t.Errorf("expect public host to be allowed, got %v", err)
+	}
+	if err := s.Screen(c, "https://missing.example.com/"); err != nil {
+		t.Errorf("expect host failing to resolve to be allowed, got %v", err)
+	}
+}
+
+func TestChainReturnFirstBlock(t *testing.T) {
+	chain := screener.Chain{screener.NewPrivateHostScreener(nil), failingScreener{}}
+
+	if err := chain.Screen(context.Background(), "http://127.0.0.1/"); !isBlocked(err) {
+		t.Errorf("expect BlockedURLError, got %v", err)
+	}
+	if err := chain.Screen(context.Background(), "https://example.com/"); err == nil || isBlocked(err) {
+		t.Errorf("expect error of the failing screener, got %v", err)
+	}
+}
+
+type failingScreener struct{}
+
+func (failingScreener) Screen(c context.Context, rawURL string) error {
+	return errors.New("error")
+}
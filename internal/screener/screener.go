@@ -0,0 +1,39 @@
+package screener
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// Screener checks whether an original url is safe to shorten and to redirect to.
+// Screen returns *myerror.BlockedURLError if the url is blocked, other errors mean the url could not be screened.
+type Screener interface {
+	Screen(c context.Context, rawURL string) error
+}
+
+// Chain blocks the url if any of its screeners blocks it, the screeners are called in order.
+type Chain []Screener
+
+func (ch Chain) Screen(c context.Context, rawURL string) error {
+	for _, s := range ch {
+		if err := s.Screen(c, rawURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hostname returns the lowercased host of the url without the port and the trailing dot, in punycode
+// if it is internationalized, or an empty string if the url can not be parsed.
+func hostname(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host, err := parseDomain(u.Hostname())
+	if err != nil {
+		return strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	}
+	return host
+}
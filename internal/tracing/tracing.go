@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TRACER_NAME is the instrumentation scope of all spans created by this app.
+const TRACER_NAME = "github.com/WeiAnAn/url-shortener"
+
+const (
+	EXPORTER_NONE = "none"
+	EXPORTER_OTLP = "otlp"
+)
+
+// STORE_KEY is the attribute naming the store of a span, e.g. "mongo" or "redis_cache".
+const STORE_KEY = attribute.Key("store")
+
+// SHORT_URL_KEY is the attribute holding the short url id a span works on.
+const SHORT_URL_KEY = attribute.Key("short_url")
+
+// Propagator reads and writes W3C trace context and baggage headers.
+var Propagator propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+type Config struct {
+	// Exporter is EXPORTER_NONE or EXPORTER_OTLP.
+	Exporter string
+	// OTLPEndpoint is the host and port of the OTLP/HTTP collector.
+	OTLPEndpoint string
+	OTLPInsecure bool
+	// SampleRatio is the fraction of new traces recorded, traces started by callers follow their sampling decision.
+	SampleRatio float64
+	ServiceName string
+}
+
+// NewTracerProvider returns a no-op provider if the exporter is EXPORTER_NONE.
+// The returned function exports the remaining spans and stops the provider.
+func NewTracerProvider(c context.Context, config Config) (trace.TracerProvider, func(context.Context) error, error) {
+	switch config.Exporter {
+	case EXPORTER_NONE:
+		return trace.NewNoopTracerProvider(), func(context.Context) error { return nil }, nil
+	case EXPORTER_OTLP:
+	default:
+		return nil, nil, fmt.Errorf("unknown tracing exporter %s", config.Exporter)
+	}
+
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.OTLPEndpoint)}
+	if config.OTLPInsecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(c, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(config.ServiceName))),
+	)
+	return tp, tp.Shutdown, nil
+}
+
+// End records err on the span if it is not nil, then ends the span.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,212 @@
+package urlnorm
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// Rule names, applied in the order below regardless of the configured order.
+const (
+	// LOWERCASE lowercases the scheme and the host, which are case insensitive.
+	LOWERCASE = "lowercase"
+	// DEFAULT_PORT removes the port if it is the default port of the scheme, e.g. 443 of https.
+	DEFAULT_PORT = "default_port"
+	// IDN converts an internationalized host to punycode, e.g. bücher.de to xn--bcher-kva.de.
+	IDN = "idn"
+	// DOT_SEGMENTS resolves "." and ".." segments of the path as RFC 3986 section 5.2.4.
+	DOT_SEGMENTS = "dot_segments"
+	// TRACKING_PARAMS removes the query params matching the tracking params.
+	TRACKING_PARAMS = "tracking_params"
+)
+
+// DEFAULT_RULES keep the url pointing to the same resource, TRACKING_PARAMS is opt-in since
+// some sites read the params.
+var DEFAULT_RULES = []string{LOWERCASE, DEFAULT_PORT, IDN, DOT_SEGMENTS}
+
+// DEFAULT_TRACKING_PARAMS are removed by TRACKING_PARAMS, a trailing * matches any suffix.
+var DEFAULT_TRACKING_PARAMS = []string{"utm_*", "fbclid", "gclid"}
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+var ErrInvalidURL = errors.New("invalid url")
+
+// Normalizer rewrites urls into a canonical form, so the same resource is stored and deduplicated as one url.
+type Normalizer struct {
+	rules          map[string]bool
+	trackingParams []string
+}
+
+// New creates a normalizer applying the rules, it returns an error if a rule is unknown.
+func New(rules []string, trackingParams []string) (*Normalizer, error) {
+	n := &Normalizer{rules: map[string]bool{}, trackingParams: trackingParams}
+	for _, rule := range rules {
+		if !isRule(rule) {
+			return nil, fmt.Errorf("unknown url normalization rule %q, expect one of %v", rule, Rules())
+		}
+		n.rules[rule] = true
+	}
+	return n, nil
+}
+
+// Rules returns every rule name in the order they are applied.
+func Rules() []string {
+	return []string{LOWERCASE, DEFAULT_PORT, IDN, DOT_SEGMENTS, TRACKING_PARAMS}
+}
+
+func isRule(name string) bool {
+	for _, rule := range Rules() {
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize returns the url unchanged if there is no rule, otherwise the url rewritten by the rules.
+// The error wraps ErrInvalidURL if the url can not be parsed or the host is not a valid domain name.
+func (n *Normalizer) Normalize(rawURL string) (string, error) {
+	if len(n.rules) == 0 {
+		return rawURL, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+
+	hostname, port := splitHostPort(u.Host)
+	if n.rules[LOWERCASE] {
+		u.Scheme = strings.ToLower(u.Scheme)
+		hostname = strings.ToLower(hostname)
+	}
+	if n.rules[DEFAULT_PORT] && port == defaultPorts[strings.ToLower(u.Scheme)] {
+		port = ""
+	}
+	if n.rules[IDN] && !isASCII(hostname) {
+		hostname, err = idna.Lookup.ToASCII(hostname)
+		if err != nil {
+			return "", fmt.Errorf("%w: host %s: %v", ErrInvalidURL, u.Hostname(), err)
+		}
+	}
+	u.Host = joinHostPort(hostname, port)
+
+	if n.rules[DOT_SEGMENTS] && u.Opaque == "" {
+		escaped := removeDotSegments(u.EscapedPath())
+		path, err := url.PathUnescape(escaped)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidURL, err)
+		}
+		u.Path, u.RawPath = path, escaped
+	}
+
+	if n.rules[TRACKING_PARAMS] {
+		u.RawQuery = n.removeTrackingParams(u.RawQuery)
+		// drop the "?" left by removing every param
+		u.ForceQuery = false
+	}
+
+	return u.String(), nil
+}
+
+// removeTrackingParams keeps the order and the encoding of the other params.
+func (n *Normalizer) removeTrackingParams(rawQuery string) string {
+	params := strings.Split(rawQuery, "&")
+	kept := params[:0]
+	for _, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		if !n.isTrackingParam(key) {
+			kept = append(kept, param)
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+func (n *Normalizer) isTrackingParam(key string) bool {
+	for _, param := range n.trackingParams {
+		if prefix, ok := strings.CutSuffix(param, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == param {
+			return true
+		}
+	}
+	return false
+}
+
+// removeDotSegments is the remove_dot_segments algorithm of RFC 3986 section 5.2.4.
+// It works on the escaped path, so an escaped "/" is not treated as a separator.
+func removeDotSegments(path string) string {
+	var out []string
+	in := path
+	for in != "" {
+		switch {
+		case strings.HasPrefix(in, "../"):
+			in = in[3:]
+		case strings.HasPrefix(in, "./"):
+			in = in[2:]
+		case strings.HasPrefix(in, "/./"):
+			in = in[2:]
+		case in == "/.":
+			in = "/"
+		case strings.HasPrefix(in, "/../"):
+			in = in[3:]
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+		case in == "/..":
+			in = "/"
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+		case in == "." || in == "..":
+			in = ""
+		default:
+			// move the first segment, including its leading "/", to the output
+			end := strings.IndexByte(in[1:], '/') + 1
+			if end == 0 {
+				end = len(in)
+			}
+			out = append(out, in[:end])
+			in = in[end:]
+		}
+	}
+	return strings.Join(out, "")
+}
+
+// splitHostPort splits the port off the host, keeping the brackets of an IPv6 host.
+func splitHostPort(hostPort string) (string, string) {
+	colon := strings.LastIndexByte(hostPort, ':')
+	if colon == -1 || strings.LastIndexByte(hostPort, ']') > colon {
+		return hostPort, ""
+	}
+	return hostPort[:colon], hostPort[colon+1:]
+}
+
+func joinHostPort(host, port string) string {
+	if port == "" {
+		return host
+	}
+	// the host of net.JoinHostPort must not be bracketed
+	return net.JoinHostPort(strings.Trim(host, "[]"), port)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -34,6 +34,20 @@ func (m *MockTimeUtil) EXPECT() *MockTimeUtilMockRecorder {
 	return m.recorder
 }
 
+// Now mocks base method.
+func (m *MockTimeUtil) Now() time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Now")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// Now indicates an expected call of Now.
+func (mr *MockTimeUtilMockRecorder) Now() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Now", reflect.TypeOf((*MockTimeUtil)(nil).Now))
+}
+
 // Until mocks base method.
 func (m *MockTimeUtil) Until(t time.Time) time.Duration {
 	m.ctrl.T.Helper()
@@ -3,11 +3,16 @@ package utils
 import "time"
 
 type TimeUtil interface {
+	Now() time.Time
 	Until(t time.Time) time.Duration
 }
 
 type RealTime struct{}
 
+func (rt *RealTime) Now() time.Time {
+	return time.Now()
+}
+
 func (rt *RealTime) Until(t time.Time) time.Duration {
 	return time.Until(t)
 }